@@ -1,27 +1,32 @@
 package config
 
 import (
-	"expense-tracker/utils"
+	"fmt"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	// the SQLite driver the dialect uses, required directly so that its
+	// version is pinned by this module
+	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *gorm.DB
-var DB_URL string
+// Supported values for the DB_DRIVER environment variable
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
 
-func Connect() {
-	env := utils.LoadEnv()
-	DB_URL = env.DBURL
-	con, err := gorm.Open("mysql", DB_URL)
-	if err != nil {
-		panic(err)
+// Connect opens a gorm connection for the given driver. For SQLite the url is
+// the path of the database file.
+func Connect(driver, url string) (*gorm.DB, error) {
+	switch driver {
+	case DriverMySQL:
+		return gorm.Open("mysql", url)
+	case DriverSQLite:
+		return gorm.Open("sqlite3", url)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
 	}
-
-	db = con
 }
-
-func GetDB () *gorm.DB {
-	return db
-}
-
@@ -0,0 +1,432 @@
+package controller
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminUser struct to represent a user in the admin API
+type AdminUser struct {
+	Profile
+	// DisabledAt is when the account was disabled, null while it is active
+	DisabledAt *time.Time `json:"disabledAt"`
+	// DeleteAt is when the account is deleted for good, null unless the user deleted it
+	DeleteAt  *time.Time `json:"deleteAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// UserStats struct to represent what a user stores in the admin API
+type UserStats struct {
+	Expenses   int64 `json:"expenses"`
+	Categories int64 `json:"categories"`
+	Tags       int64 `json:"tags"`
+	Recurring  int64 `json:"recurring"`
+	Budgets    int64 `json:"budgets"`
+	APITokens  int64 `json:"apiTokens"`
+	// Sessions counts the sessions that can still be refreshed
+	Sessions int64 `json:"sessions"`
+	// FirstExpense and LastExpense are the dates of the oldest and newest expenses
+	FirstExpense string `json:"firstExpense,omitempty" example:"2025-01-31"`
+	LastExpense  string `json:"lastExpense,omitempty" example:"2026-10-16"`
+}
+
+// DisableRequest struct to represent why an account is disabled
+type DisableRequest struct {
+	Reason string `json:"reason" example:"Spam"`
+}
+
+// RoleRequest struct to represent the role given to a user
+type RoleRequest struct {
+	Role string `json:"role" example:"support"`
+}
+
+// @Tags Admin
+// @Summary Search the users
+// @Description List the users matching the filters, sorted by ID. Requires the admin or support role.
+// @Accept  json
+// @Produce json
+// @Param q query string false "Text matched against the email and names"
+// @Param role query string false "Role: user, admin or support"
+// @Param disabled query bool false "Only disabled (true) or active (false) users"
+// @Param limit query int false "Page size, 100 by default"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {array} AdminUser "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of users matching the filters"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users [get]
+func (h *Handler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := model.UserQuery{Search: strings.TrimSpace(params.Get("q")), Role: params.Get("role")}
+	if query.Role != "" && !model.ValidRole(query.Role) {
+		http.Error(w, jsonMessage("Invalid role. Use one of "+strings.Join(model.Roles, ", ")+"."), http.StatusBadRequest)
+		return
+	}
+	if v := params.Get("disabled"); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, `{"message": "Invalid disabled. Use true or false."}`, http.StatusBadRequest)
+			return
+		}
+		query.Disabled = &disabled
+	}
+	var err error
+	if query.Limit, query.Offset, err = parsePage(params.Get("limit"), params.Get("offset")); err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if !h.audit(w, r, model.AuditAdminSearchUsers, nil, r.URL.RawQuery) {
+		return
+	}
+	page, err := h.Users.Search(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := make([]AdminUser, len(page.Users))
+	for i := range page.Users {
+		res[i] = adminUser(&page.Users[i])
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	writeJSON(w, http.StatusOK, res)
+}
+
+// @Tags Admin
+// @Summary Get a user
+// @Description Get the account of a user. Requires the admin or support role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} AdminUser "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id} [get]
+func (h *Handler) AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok || !h.audit(w, r, model.AuditAdminViewUser, user, "") {
+		return
+	}
+	writeJSON(w, http.StatusOK, adminUser(user))
+}
+
+// @Tags Admin
+// @Summary Get the storage of a user
+// @Description Count the expenses, categories, tags, recurring expenses, budgets, API tokens and sessions of a user. Requires the admin or support role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserStats "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/stats [get]
+func (h *Handler) AdminGetUserStats(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok || !h.audit(w, r, model.AuditAdminViewStats, user, "") {
+		return
+	}
+	stats, err := h.Users.Stats(int64(user.ID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := UserStats{
+		Expenses:   stats.Expenses,
+		Categories: stats.Categories,
+		Tags:       stats.Tags,
+		Recurring:  stats.Recurring,
+		Budgets:    stats.Budgets,
+		APITokens:  stats.APITokens,
+		Sessions:   stats.Sessions,
+	}
+	if stats.FirstExpense != nil {
+		res.FirstExpense, res.LastExpense = stats.FirstExpense.String(), stats.LastExpense.String()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// @Tags Admin
+// @Summary Disable a user
+// @Description Disable an account: its sessions end and its tokens, API tokens included, are rejected until it is enabled again. Requires the admin role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param reason body DisableRequest false "Why the account is disabled"
+// @Success 200 {object} AdminUser "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/disable [post]
+func (h *Handler) AdminDisableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == currentUser(r).ID {
+		http.Error(w, `{"message": "You cannot disable your own account"}`, http.StatusBadRequest)
+		return
+	}
+	body := &DisableRequest{}
+	utils.ParseBody(r, body)
+	if !h.audit(w, r, model.AuditAdminDisable, user, strings.TrimSpace(body.Reason)) {
+		return
+	}
+
+	if !user.Disabled() {
+		now := time.Now().UTC()
+		user.DisabledAt = &now
+		if err := h.Users.Update(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.Tokens.RevokeUser(int64(user.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, adminUser(user))
+}
+
+// @Tags Admin
+// @Summary Enable a user
+// @Description Enable a disabled account again, its user has to log in again. Requires the admin role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} AdminUser "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/enable [post]
+func (h *Handler) AdminEnableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok || !h.audit(w, r, model.AuditAdminEnable, user, "") {
+		return
+	}
+	if user.Disabled() {
+		user.DisabledAt = nil
+		if err := h.Users.Update(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, adminUser(user))
+}
+
+// @Tags Admin
+// @Summary Log a user out everywhere
+// @Description End every session of a user, API tokens keep working. Requires the admin or support role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/logout [post]
+func (h *Handler) AdminLogoutUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok || !h.audit(w, r, model.AuditAdminLogout, user, "") {
+		return
+	}
+	if err := h.Tokens.RevokeUser(int64(user.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, http.StatusOK, "Every session of the user has ended")
+}
+
+// @Tags Admin
+// @Summary Send a password reset to a user
+// @Description Email a password reset link to a user, as if they had asked for it. The password is not changed until they use it. Requires the admin or support role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 202 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/password-reset [post]
+func (h *Handler) AdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok || !h.audit(w, r, model.AuditAdminPasswordReset, user, "") {
+		return
+	}
+	if err := h.sendEmailToken(user, model.TokenResetPassword); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, http.StatusAccepted, "A password reset link has been sent to "+user.Email)
+}
+
+// @Tags Admin
+// @Summary Change the role of a user
+// @Description Make a user an admin, a support agent or a regular user. Requires the admin role.
+// @Accept  json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body RoleRequest true "New role"
+// @Success 200 {object} AdminUser "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) AdminSetRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+	body := &RoleRequest{}
+	utils.ParseBody(r, body)
+	if !model.ValidRole(body.Role) {
+		http.Error(w, jsonMessage("Invalid role. Use one of "+strings.Join(model.Roles, ", ")+"."), http.StatusBadRequest)
+		return
+	}
+	if user.ID == currentUser(r).ID {
+		http.Error(w, `{"message": "You cannot change your own role"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.audit(w, r, model.AuditAdminSetRole, user, user.Role+" to "+body.Role) {
+		return
+	}
+
+	user.Role = body.Role
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, adminUser(user))
+}
+
+// @Tags Admin
+// @Summary Get the audit log
+// @Description List the audit log, newest first: the actions of the staff and the login lockouts. Requires the admin role.
+// @Accept  json
+// @Produce json
+// @Param userId query int false "Only entries about this user"
+// @Param actorId query int false "Only entries of actions by this user"
+// @Param action query string false "Only this action, or every action it prefixes when it ends with a dot, e.g. admin."
+// @Param limit query int false "Page size, 100 by default"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {array} model.AuditEntry "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of entries matching the filters"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/audit [get]
+func (h *Handler) AdminGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := model.AuditQuery{Action: params.Get("action")}
+	var err error
+	for name, id := range map[string]*int64{"userId": &query.UserId, "actorId": &query.ActorId} {
+		if v := params.Get(name); v != "" {
+			if *id, err = strconv.ParseInt(v, 10, 64); err != nil {
+				http.Error(w, jsonMessage("Invalid "+name+"."), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if query.Limit, query.Offset, err = parsePage(params.Get("limit"), params.Get("offset")); err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if !h.audit(w, r, model.AuditAdminViewAudit, nil, r.URL.RawQuery) {
+		return
+	}
+	page, err := h.Audit.List(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	writeJSON(w, http.StatusOK, page.Entries)
+}
+
+// targetUser loads the user the admin request is about and writes the error
+// response if there is none
+func (h *Handler) targetUser(w http.ResponseWriter, r *http.Request) (*model.UserData, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, `{"message": "Invalid user ID"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	user, err := h.Users.FindById(id)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "User not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// audit records an action of the signed in staff member before it is carried
+// out, about the user or about no single user when nil, and writes the error
+// response if it cannot be recorded
+func (h *Handler) audit(w http.ResponseWriter, r *http.Request, action string, user *model.UserData, detail string) bool {
+	entry := &model.AuditEntry{
+		UserId:    userIdOf(user),
+		ActorId:   userIdOf(currentUser(r)),
+		Action:    action,
+		IP:        h.clientIP(r),
+		Detail:    detail,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.Audit.Record(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// parsePage reads the limit and offset of a listing
+func parsePage(limit, offset string) (int, int, error) {
+	l, o := defaultPageSize, 0
+	var err error
+	if limit != "" {
+		if l, err = strconv.Atoi(limit); err != nil || l < 1 || l > maxPageSize {
+			return 0, 0, fmt.Errorf("Invalid limit. Use a number between 1 and %d.", maxPageSize)
+		}
+	}
+	if offset != "" {
+		if o, err = strconv.Atoi(offset); err != nil || o < 0 {
+			return 0, 0, errors.New("Invalid offset. Use a positive number.")
+		}
+	}
+	return l, o, nil
+}
+
+// adminUser converts a user into their admin API representation
+func adminUser(user *model.UserData) AdminUser {
+	return AdminUser{Profile: profile(user), DisabledAt: user.DisabledAt, DeleteAt: user.DeleteAt, UpdatedAt: user.UpdatedAt}
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultAPITokenDays is the lifetime of API tokens created without one
+const defaultAPITokenDays = 90
+
+// APITokenRequest struct to represent a new API token in the API
+type APITokenRequest struct {
+	Name   string   `json:"name" example:"Monthly export script"`
+	Scopes []string `json:"scopes" example:"expenses:read"`
+	// ExpiresInDays is the lifetime of the token, 90 days by default and at most 366
+	ExpiresInDays int `json:"expiresInDays" example:"90"`
+}
+
+// APIToken struct to represent an API token in the API, the token itself is only returned at creation
+type APIToken struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" example:"Monthly export script"`
+	// Prefix is the start of the token
+	Prefix     string     `json:"prefix" example:"etk_Xb3k9Q"`
+	Scopes     []string   `json:"scopes" example:"expenses:read"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	// Token is sent as a Bearer token, it is shown only once
+	Token string `json:"token,omitempty"`
+}
+
+// @Tags User
+// @Summary Get my API tokens
+// @Description List the API tokens of the current user with their scopes and when they were last used
+// @Accept  json
+// @Produce json
+// @Success 200 {array} APIToken "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/tokens [get]
+func (h *Handler) GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	tokens, err := h.APITokens.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := make([]APIToken, len(tokens))
+	for i := range tokens {
+		res[i] = apiToken(&tokens[i])
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// @Tags User
+// @Summary Create an API token
+// @Description Create a token for scripts and integrations, limited to the given scopes: expenses:read, expenses:write and reports:read. The token is returned only once.
+// @Accept  json
+// @Produce json
+// @Param token body APITokenRequest true "Token data"
+// @Success 201 {object} APIToken "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/tokens [post]
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	body := &APITokenRequest{}
+	utils.ParseBody(r, body)
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		http.Error(w, `{"message": "The name is required"}`, http.StatusBadRequest)
+		return
+	}
+	scopes, ok := model.NormalizeScopes(body.Scopes)
+	if !ok || len(scopes) == 0 {
+		http.Error(w, jsonMessage("Give at least one scope out of "+strings.Join(model.APIScopes, ", ")+"."), http.StatusBadRequest)
+		return
+	}
+	if body.ExpiresInDays == 0 {
+		body.ExpiresInDays = defaultAPITokenDays
+	}
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	if body.ExpiresInDays < 0 || ttl > model.APITokenMaxTTL {
+		http.Error(w, `{"message": "The expiresInDays must be between 1 and 366"}`, http.StatusBadRequest)
+		return
+	}
+
+	raw, token := model.NewAPIToken(userId, body.Name, scopes, ttl)
+	if err := h.APITokens.Create(token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := apiToken(token)
+	res.Token = raw
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// @Tags User
+// @Summary Revoke an API token
+// @Description Revoke an API token of the current user, it stops working immediately
+// @Accept  json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Token not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/tokens/{id} [delete]
+func (h *Handler) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, `{"message": "Invalid token ID"}`, http.StatusBadRequest)
+		return
+	}
+
+	err = h.APITokens.Delete(userId, uint(ID))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Token not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiToken converts a stored API token into its API representation
+func apiToken(token *model.APIToken) APIToken {
+	return APIToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
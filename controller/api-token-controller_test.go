@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAPITokens(t *testing.T) {
+	h, store := newTestHandler()
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/users/me/tokens", h.GetAPITokens).Methods("GET")
+	router.HandleFunc("/users/me/tokens", h.CreateAPIToken).Methods("POST")
+	router.HandleFunc("/users/me/tokens/{id}", h.DeleteAPIToken).Methods("DELETE")
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	h.RequireScopes(router.HandleFunc("/expenses", ok).Methods("GET"), model.ScopeExpensesRead)
+	h.RequireScopes(router.HandleFunc("/expenses", ok).Methods("POST"), model.ScopeExpensesWrite)
+	h.RequireScopes(router.HandleFunc("/reports/summary", ok).Methods("GET"), model.ScopeReportsRead)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	session := login(t, h, user).Token
+
+	for _, body := range []string{
+		`{"scopes": ["expenses:read"]}`,
+		`{"name": "script", "scopes": []}`,
+		`{"name": "script", "scopes": ["expenses:read", "admin"]}`,
+		`{"name": "script", "scopes": ["expenses:read"], "expiresInDays": 367}`,
+		`{"name": "script", "scopes": ["expenses:read"], "expiresInDays": -1}`,
+	} {
+		if w := serve(router, "POST", "/users/me/tokens", session, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d %s, want 400", body, w.Code, w.Body)
+		}
+	}
+
+	w := serve(router, "POST", "/users/me/tokens", session, `{"name": "script", "scopes": ["reports:read", "expenses:read", "expenses:read"]}`)
+	var created APIToken
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", w.Code, w.Body)
+	}
+	raw := created.Token
+	if !model.IsAPIToken(raw) || !strings.HasPrefix(raw, created.Prefix) || len(created.Scopes) != 2 {
+		t.Errorf("created: got %+v", created)
+	}
+	if days := time.Until(created.ExpiresAt).Hours() / 24; days < 89.9 || days > 90 {
+		t.Errorf("created: expires in %.1f days, want 90", days)
+	}
+
+	// only the hash of the token is stored, and it is never shown again
+	stored, err := store.APITokens.List(int64(user.ID))
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("stored tokens: got %+v, %v", stored, err)
+	}
+	if stored[0].Hash != model.HashToken(raw) || strings.Contains(fmt.Sprintf("%+v", stored[0]), raw) {
+		t.Errorf("stored token: got %+v", stored[0])
+	}
+	w = serve(router, "GET", "/users/me/tokens", session, "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), raw) || strings.Contains(w.Body.String(), `"token"`) {
+		t.Errorf("list: got %d %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/expenses", http.StatusOK},
+		{"GET", "/reports/summary", http.StatusOK},
+		{"POST", "/expenses", http.StatusForbidden},
+		// API tokens cannot manage API tokens
+		{"GET", "/users/me/tokens", http.StatusForbidden},
+		{"POST", "/users/me/tokens", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := serve(router, tt.method, tt.path, raw, `{"name": "more", "scopes": ["expenses:write"]}`); w.Code != tt.want {
+			t.Errorf("%s %s: got %d %s, want %d", tt.method, tt.path, w.Code, w.Body, tt.want)
+		}
+	}
+	if stored, _ := store.APITokens.List(int64(user.ID)); len(stored) != 1 || stored[0].LastUsedAt == nil {
+		t.Errorf("used token: got %+v", stored)
+	}
+
+	// tokens of other users cannot be revoked
+	path := fmt.Sprintf("/users/me/tokens/%d", created.ID)
+	if w := serve(router, "DELETE", path, login(t, h, other).Token, ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoking the token of another user: got %d, want 404", w.Code)
+	}
+	if w := serve(router, "DELETE", path, session, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: got %d %s", w.Code, w.Body)
+	}
+	if w := serve(router, "GET", "/expenses", raw, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: got %d, want 401", w.Code)
+	}
+	if w := serve(router, "DELETE", path, session, ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoking again: got %d, want 404", w.Code)
+	}
+
+	expired, token := model.NewAPIToken(int64(user.ID), "old script", []string{model.ScopeExpensesRead}, -time.Second)
+	if err := store.APITokens.Create(token); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, "GET", "/expenses", expired, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired token: got %d, want 401", w.Code)
+	}
+}
@@ -2,10 +2,13 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"expense-tracker/model"
 	"expense-tracker/utils"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/alexedwards/argon2id"
 )
@@ -15,24 +18,59 @@ type Login struct {
 	Password string `json:"password"`
 }
 
+// RefreshRequest struct to represent the refresh token sent to refresh or end a session
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// TokenResponse struct to represent the tokens issued at login and refresh
+type TokenResponse struct {
+	Message string `json:"message" example:"Login successful"`
+	// Token is the access token, sent as a Bearer token
+	Token string `json:"token"`
+	// RefreshToken is exchanged once at /auth/refresh for new tokens
+	RefreshToken string `json:"refreshToken"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int64 `json:"expiresIn" example:"3600"`
+}
+
 // @Tags Auth
 // @Summary Register a user
 // @Description Register a new user
 // @Accept  json
 // @Produce json
 // @Param user body User true "User data"
-// @Success 201 {object} User "Successful operation"
+// @Success 201 {object} Profile "Successful operation"
+// @Failure 400 {string} string "Bad request"
 // @Failure 500 {string} string "Internal server error"
 // @Router /auth/register [post]
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	// parse the body contents and hash the password
-	newUser := &model.UserData{}
-	utils.ParseBody(r, newUser)
-	if newUser.FirstName == "" || newUser.LastName == "" || newUser.Email == "" || newUser.Password == "" {
+	body := &User{}
+	utils.ParseBody(r, body)
+	if body.FirstName == "" || body.LastName == "" || body.Email == "" || body.Password == "" {
 		http.Error(w, `{"message": "All fields are required"}`, http.StatusBadRequest)
 		return
 	}
-	
+	newUser := &model.UserData{
+		FirstName:    body.FirstName,
+		LastName:     body.LastName,
+		Email:        body.Email,
+		Password:     body.Password,
+		DateFormat:   body.DateFormat,
+		BaseCurrency: strings.ToUpper(body.BaseCurrency),
+		Timezone:     body.Timezone,
+		Locale:       body.Locale,
+		Role:         model.RoleUser,
+	}
+	if newUser.BaseCurrency == "" {
+		newUser.BaseCurrency = h.Currency
+	}
+	if message := validatePreferences(newUser); message != "" {
+		http.Error(w, jsonMessage(message), http.StatusBadRequest)
+		return
+	}
+
 	hash, err := argon2id.CreateHash(newUser.Password, argon2id.DefaultParams)
 	if err != nil {
 		log.Fatal(err)
@@ -40,37 +78,46 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 	newUser.Password = hash
 
 	// confirm the email provided is not previously registered
-	existingUser := model.GetUsers()
-	for _, u := range existingUser {
-		if u.Email == newUser.Email {
-			http.Error(w, `{"message": "Email already registered"}`, http.StatusConflict)
-			return
-		}
+	if _, err := h.Users.FindByEmail(newUser.Email); err == nil {
+		http.Error(w, `{"message": "Email already registered"}`, http.StatusConflict)
+		return
+	} else if !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	// create the new user and marshall the contents as a response
-	user := newUser.CreateUser()
-	res, err := json.Marshal(user)
-	if err != nil {
+	if err := h.Users.Create(newUser); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	w.Write(res)
+	if err := h.Categories.Seed(int64(newUser.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// the account works without the email, a new one can be requested
+	if err := h.sendEmailToken(newUser, model.TokenVerifyEmail); err != nil {
+		log.Printf("Verification email for user %d: %v", newUser.ID, err)
+	}
+	writeJSON(w, http.StatusCreated, profile(newUser))
 }
 
 // @Tags Auth
 // @Summary Login as a user
-// @Description Login with user credentials
+// @Description Login with user credentials. Users with two-factor authentication get a challenge instead of the tokens, completed at /auth/mfa/verify. Repeated failures for an email or from an address make further attempts wait, with 429 and a Retry-After header, and eventually lock them out for 15 minutes.
 // @Accept  json
 // @Produce json
 // @Param user body Login true "User data"
-// @Success 200 {string} string "Successful operation"
+// @Success 200 {object} TokenResponse "Successful operation"
+// @Success 202 {object} MFAChallengeResponse "Two-factor authentication required"
 // @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Email not verified, account disabled or scheduled for deletion"
+// @Failure 429 {string} string "Too many failed attempts"
 // @Failure 500 {string} string "Internal server error"
 // @Router /auth/login [post]
-func LoginUser(w http.ResponseWriter, r *http.Request) {
-	var loginUser model.UserData
+func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
+	var loginUser Login
 	utils.ParseBody(r, &loginUser)
 
 	if loginUser.Email == "" || loginUser.Password == "" {
@@ -78,25 +125,225 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := model.GetUsers()
+	attempt := h.newLoginAttempt(r, loginUser.Email)
+	if h.throttled(w, attempt) {
+		return
+	}
+	u, err := h.Users.FindByEmail(loginUser.Email)
+	if errors.Is(err, model.ErrNotFound) {
+		u, err = nil, nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	for _, u := range user {
-		if u.Email == loginUser.Email {
-			match, err := argon2id.ComparePasswordAndHash(loginUser.Password, u.Password)
-			if err != nil || !match {
-				http.Error(w, `{"message": "Invalid email or password"}`, http.StatusUnauthorized)
-				return
-			}
-			token, err := utils.SignJWTToken(int64(u.ID), u.Email)
-			if err != nil {
+	// unknown emails are checked against a dummy hash and get the same
+	// answer as wrong passwords, neither tells whether an account exists
+	hash := dummyPasswordHash
+	if u != nil {
+		hash = u.Password
+	}
+	match, err := argon2id.ComparePasswordAndHash(loginUser.Password, hash)
+	if u == nil || err != nil || !match {
+		if err := h.loginFailed(attempt, u); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"message": "Invalid email or password"}`, http.StatusUnauthorized)
+		return
+	}
+	if u.Disabled() {
+		http.Error(w, `{"message": "This account is disabled"}`, http.StatusForbidden)
+		return
+	}
+	if u.DeletionPending() {
+		http.Error(w, `{"message": "This account is scheduled for deletion, open the link sent by email to restore it"}`, http.StatusForbidden)
+		return
+	}
+	if !h.verified(u) {
+		http.Error(w, `{"message": "Please verify your email before logging in"}`, http.StatusForbidden)
+		return
+	}
+	if h.startMFAChallenge(w, u) {
+		return
+	}
+	h.loginSucceeded(attempt)
+	// every login starts a new family of refresh tokens
+	h.issueTokens(w, u, model.NewTokenId(), nil, "Login successful")
+}
+
+// @Tags Auth
+// @Summary Refresh the tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token. A refresh token can be exchanged only once, presenting it again ends the session it belongs to.
+// @Accept  json
+// @Produce json
+// @Param token body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Email not verified, account disabled or scheduled for deletion"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/refresh [post]
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	body := &RefreshRequest{}
+	utils.ParseBody(r, body)
+	if body.RefreshToken == "" {
+		http.Error(w, `{"message": "The refreshToken is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.Tokens.FindByHash(model.HashToken(body.RefreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Invalid refresh token"}`, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		h.endReusedFamily(w, token)
+		return
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		http.Error(w, `{"message": "Refresh token has expired, please log in again"}`, http.StatusUnauthorized)
+		return
+	}
+	user, err := h.Users.FindById(token.UserId)
+	if err != nil {
+		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	if user.Disabled() {
+		http.Error(w, `{"message": "This account is disabled"}`, http.StatusForbidden)
+		return
+	}
+	if user.DeletionPending() {
+		http.Error(w, `{"message": "This account is scheduled for deletion, open the link sent by email to restore it"}`, http.StatusForbidden)
+		return
+	}
+	if !h.verified(user) {
+		http.Error(w, `{"message": "Please verify your email before logging in"}`, http.StatusForbidden)
+		return
+	}
+
+	h.issueTokens(w, user, token.Family, token, "Tokens refreshed")
+}
+
+// @Tags Auth
+// @Summary Log out
+// @Description End the current session, the access token of the request and the session of the refresh token, when given, are revoked
+// @Accept  json
+// @Produce json
+// @Param token body RefreshRequest false "Refresh token of the session"
+// @Success 204 {string} string "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	session := currentSession(r)
+	userId := int64(session.User.ID)
+
+	body := &RefreshRequest{}
+	utils.ParseBody(r, body)
+	if body.RefreshToken != "" {
+		token, err := h.Tokens.FindByHash(model.HashToken(body.RefreshToken))
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err == nil && token.UserId == userId {
+			if err := h.Tokens.RevokeFamily(token.Family); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"message": "Login successful", "token": "` + token + `"}`))
-			return
 		}
 	}
-	http.Error(w, `{"message": "Account does not exist"}`, http.StatusNotFound)
+	if err := h.Tokens.RevokeAccess(session.TokenId, session.ExpiresAt); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Auth
+// @Summary Log out everywhere
+// @Description End every session of the current user, all refresh tokens and the access tokens issued with them are revoked
+// @Accept  json
+// @Produce json
+// @Success 204 {string} string "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/logout-all [post]
+func (h *Handler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	session := currentSession(r)
+	userId := int64(session.User.ID)
+
+	if err := h.Tokens.RevokeUser(userId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.Tokens.RevokeAccess(session.TokenId, session.ExpiresAt); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueTokens signs an access token and stores a refresh token of the family,
+// rotating previous when refreshing, and writes them as the response
+func (h *Handler) issueTokens(w http.ResponseWriter, user *model.UserData, family string, previous *model.RefreshToken, message string) {
+	jti := model.NewTokenId()
+	access, err := utils.SignJWTToken(int64(user.ID), user.Email, jti)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	refresh, token := model.NewRefreshToken(int64(user.ID), family)
+	token.AccessId = jti
+	token.AccessExpiresAt = time.Now().Add(utils.AccessTokenTTL).UTC()
+
+	if previous == nil {
+		err = h.Tokens.Create(token)
+	} else {
+		err = h.Tokens.Rotate(previous, token)
+	}
+	if errors.Is(err, model.ErrTokenReused) {
+		// another request exchanged the token first
+		h.endReusedFamily(w, previous)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(TokenResponse{
+		Message:      message,
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(utils.AccessTokenTTL / time.Second),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// endReusedFamily revokes the session of a refresh token presented after it
+// was used or revoked, since either the client or an attacker holds a stolen
+// copy
+func (h *Handler) endReusedFamily(w http.ResponseWriter, token *model.RefreshToken) {
+	if err := h.Tokens.RevokeFamily(token.Family); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Refresh token reused for user %d, session revoked", token.UserId)
+	http.Error(w, `{"message": "Refresh token is no longer valid, please log in again"}`, http.StatusUnauthorized)
 }
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// refreshRouter serves the refresh endpoint and a route that needs a session
+func refreshRouter(h *Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	h.Public(router.HandleFunc("/auth/refresh", h.RefreshToken).Methods("POST"))
+	router.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	return router
+}
+
+func refresh(t *testing.T, router http.Handler, refreshToken string) (int, TokenResponse) {
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	w := serve(router, "POST", "/auth/refresh", "", string(body))
+	var tokens TokenResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, tokens
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	h, store := newTestHandler()
+	router := refreshRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	first := login(t, h, user)
+	other := login(t, h, user)
+
+	// every exchange returns a new pair and retires the refresh token
+	status, second := refresh(t, router, first.RefreshToken)
+	if status != http.StatusOK || second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatalf("first refresh: got %d", status)
+	}
+	status, third := refresh(t, router, second.RefreshToken)
+	if status != http.StatusOK {
+		t.Fatalf("second refresh: got %d", status)
+	}
+	if code := serve(router, "GET", "/me", third.Token, "").Code; code != http.StatusOK {
+		t.Errorf("rotated access token: got %d, want 200", code)
+	}
+
+	// presenting a retired token again ends the whole session
+	if status, _ := refresh(t, router, first.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: got %d, want 401", status)
+	}
+	if status, _ := refresh(t, router, third.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("latest refresh token of the reused session: got %d, want 401", status)
+	}
+	if code := serve(router, "GET", "/me", third.Token, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("access token of the reused session: got %d, want 401", code)
+	}
+
+	// other sessions of the user are left alone
+	if code := serve(router, "GET", "/me", other.Token, "").Code; code != http.StatusOK {
+		t.Errorf("access token of another session: got %d, want 200", code)
+	}
+	if status, _ := refresh(t, router, other.RefreshToken); status != http.StatusOK {
+		t.Errorf("refresh token of another session: got %d, want 200", status)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	h, store := newTestHandler()
+	router := refreshRouter(h)
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		user model.UserData
+		// prepare returns the refresh token to present
+		prepare func(user *model.UserData) string
+		want    int
+	}{
+		{"missing", model.UserData{}, func(*model.UserData) string { return "" }, http.StatusBadRequest},
+		{"unknown", model.UserData{}, func(*model.UserData) string { return "not-a-token" }, http.StatusUnauthorized},
+		{"expired", model.UserData{}, func(user *model.UserData) string {
+			raw, token := model.NewRefreshToken(int64(user.ID), model.NewTokenId())
+			token.ExpiresAt = now.Add(-time.Minute)
+			store.Tokens.Create(token)
+			return raw
+		}, http.StatusUnauthorized},
+		{"revoked", model.UserData{}, func(user *model.UserData) string {
+			raw, token := model.NewRefreshToken(int64(user.ID), model.NewTokenId())
+			store.Tokens.Create(token)
+			store.Tokens.RevokeUser(int64(user.ID))
+			return raw
+		}, http.StatusUnauthorized},
+		{"disabled user", model.UserData{DisabledAt: &now}, func(user *model.UserData) string {
+			return login(t, h, user).RefreshToken
+		}, http.StatusForbidden},
+		{"user pending deletion", model.UserData{DeleteAt: &now}, func(user *model.UserData) string {
+			return login(t, h, user).RefreshToken
+		}, http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		tt.user.Email = string(rune('a'+i)) + "@example.com"
+		user := createUser(t, store, tt.user)
+		if status, _ := refresh(t, router, tt.prepare(user)); status != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, status, tt.want)
+		}
+	}
+}
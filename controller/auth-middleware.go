@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Access declares who may call a route. Routes declare nothing by default,
+// which requires a signed in user and rejects API tokens.
+type Access struct {
+	// Public routes are served without authentication
+	Public bool
+	// Scopes lets API tokens carrying all of them call the route, session
+	// tokens are allowed every scope
+	Scopes []string
+	// Roles limits the route to users with one of them
+	Roles []string
+}
+
+// Session is the authentication of a request, Authenticate puts it into the
+// request context
+type Session struct {
+	User *model.UserData
+	// TokenId and ExpiresAt identify the session token, they are empty when
+	// the request carries an API token
+	TokenId   string
+	ExpiresAt time.Time
+	// APIToken is the API token of the request, nil for session tokens
+	APIToken *model.APIToken
+}
+
+type sessionKey struct{}
+
+// Public declares the route public
+func (h *Handler) Public(route *mux.Route) *mux.Route {
+	return h.declare(route, Access{Public: true})
+}
+
+// RequireScopes lets API tokens carrying the scopes call the route
+func (h *Handler) RequireScopes(route *mux.Route, scopes ...string) *mux.Route {
+	return h.declare(route, Access{Scopes: scopes})
+}
+
+// RequireRoles limits the route to signed in users with one of the roles
+func (h *Handler) RequireRoles(route *mux.Route, roles ...string) *mux.Route {
+	return h.declare(route, Access{Roles: roles})
+}
+
+func (h *Handler) declare(route *mux.Route, access Access) *mux.Route {
+	if h.access == nil {
+		h.access = map[*mux.Route]Access{}
+	}
+	h.access[route] = access
+	return route
+}
+
+// Authenticate is a mux middleware that authenticates the request by its
+// session token or API token according to the access the matched route
+// declares, loads the user and puts the Session into the request context.
+// Disabled users are rejected whatever their token.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access := h.access[mux.CurrentRoute(r)]
+		if access.Public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		raw, err := utils.GetJWTTokenFromHeader(r)
+		if err != nil {
+			http.Error(w, jsonMessage(capitalize(err.Error())), http.StatusUnauthorized)
+			return
+		}
+		var session *Session
+		var userId int64
+		if model.IsAPIToken(raw) {
+			token, ok := h.authenticateAPIToken(w, raw, access)
+			if !ok {
+				return
+			}
+			session, userId = &Session{APIToken: token}, token.UserId
+		} else {
+			claims, err := utils.ParseAccessToken(raw)
+			if err != nil {
+				http.Error(w, jsonMessage("Invalid or expired token"), http.StatusUnauthorized)
+				return
+			}
+			session, userId = &Session{TokenId: claims.TokenId, ExpiresAt: claims.ExpiresAt}, claims.UserId
+		}
+
+		session.User, err = h.Users.FindById(userId)
+		if errors.Is(err, model.ErrNotFound) {
+			http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if session.User.Disabled() {
+			http.Error(w, `{"message": "This account is disabled"}`, http.StatusForbidden)
+			return
+		}
+		if session.User.DeletionPending() {
+			http.Error(w, `{"message": "This account is scheduled for deletion"}`, http.StatusForbidden)
+			return
+		}
+		if len(access.Roles) > 0 && !slices.Contains(access.Roles, session.User.Role) {
+			http.Error(w, `{"message": "Forbidden"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, session)))
+	})
+}
+
+// authenticateAPIToken checks an API token against the scopes of the route
+// and writes the error response if it may not call it
+func (h *Handler) authenticateAPIToken(w http.ResponseWriter, raw string, access Access) (*model.APIToken, bool) {
+	if len(access.Scopes) == 0 {
+		http.Error(w, `{"message": "API tokens cannot be used for this endpoint"}`, http.StatusForbidden)
+		return nil, false
+	}
+	token, err := h.APITokens.FindByHash(model.HashToken(raw))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Invalid API token"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	now := time.Now().UTC()
+	if !token.ExpiresAt.After(now) {
+		http.Error(w, `{"message": "API token has expired"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	for _, scope := range access.Scopes {
+		if !token.HasScope(scope) {
+			http.Error(w, jsonMessage("The API token is missing the "+scope+" scope"), http.StatusForbidden)
+			return nil, false
+		}
+	}
+
+	// a minute is precise enough and spares a write on every request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+		if err := h.APITokens.Touch(token.ID, now); err != nil {
+			log.Printf("API token %d: %v", token.ID, err)
+		}
+	}
+	return token, true
+}
+
+// currentSession returns the session Authenticate put into the request
+// context, handlers of routes that are not public can rely on it
+func currentSession(r *http.Request) *Session {
+	return r.Context().Value(sessionKey{}).(*Session)
+}
+
+// currentUser returns the user the request is authenticated as
+func currentUser(r *http.Request) *model.UserData {
+	return currentSession(r).User
+}
+
+// currentUserId returns the ID of the user the request is authenticated as
+func currentUserId(r *http.Request) int64 {
+	return int64(currentSession(r).User.ID)
+}
+
+// capitalize upper-cases the first letter of an error message
+func capitalize(message string) string {
+	if message == "" {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:]
+}
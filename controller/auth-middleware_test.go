@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAuthenticate(t *testing.T) {
+	h, store := newTestHandler()
+	now := time.Now()
+	jane := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	admin := createUser(t, store, model.UserData{Email: "admin@example.com", Role: model.RoleAdmin})
+	disabled := createUser(t, store, model.UserData{Email: "disabled@example.com", DisabledAt: &now})
+	deleting := createUser(t, store, model.UserData{Email: "deleting@example.com", DeleteAt: &now})
+
+	apiToken := func(user *model.UserData, ttl time.Duration, scopes ...string) string {
+		raw, token := model.NewAPIToken(int64(user.ID), "script", scopes, ttl)
+		if err := store.APITokens.Create(token); err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	session := login(t, h, jane).Token
+	revoked := login(t, h, jane).Token
+	store.Tokens.RevokeAccess(mustParse(t, revoked).TokenId, now.Add(time.Hour))
+	// a valid token of a user who no longer exists
+	ghost, _ := utils.SignJWTToken(999, "ghost@example.com", model.NewTokenId())
+	reader := apiToken(jane, time.Hour, model.ScopeExpensesRead)
+
+	// every route answers with the email of the user it is called as
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	whoami := func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(sessionKey{}) != nil {
+			w.Write([]byte(currentUser(r).Email))
+		}
+	}
+	h.Public(router.HandleFunc("/public", whoami))
+	router.HandleFunc("/session", whoami)
+	h.RequireScopes(router.HandleFunc("/read", whoami), model.ScopeExpensesRead)
+	h.RequireScopes(router.HandleFunc("/write", whoami), model.ScopeExpensesRead, model.ScopeExpensesWrite)
+	h.RequireRoles(router.HandleFunc("/admin", whoami), model.RoleAdmin)
+
+	tests := []struct {
+		name, path, token string
+		want              int
+		// as is the email the route must see, when it is served
+		as string
+	}{
+		{"public without token", "/public", "", http.StatusOK, ""},
+		{"public with token", "/public", session, http.StatusOK, ""},
+		{"without token", "/session", "", http.StatusUnauthorized, ""},
+		{"malformed token", "/session", "not-a-jwt", http.StatusUnauthorized, ""},
+		{"session", "/session", session, http.StatusOK, "jane@example.com"},
+		{"revoked session", "/session", revoked, http.StatusUnauthorized, ""},
+		{"session of an unknown user", "/session", ghost, http.StatusUnauthorized, ""},
+		{"session of a disabled user", "/session", login(t, h, disabled).Token, http.StatusForbidden, ""},
+		{"session of a user pending deletion", "/session", login(t, h, deleting).Token, http.StatusForbidden, ""},
+		// sessions are allowed every scope
+		{"session on a scoped route", "/write", session, http.StatusOK, "jane@example.com"},
+		{"API token on a session route", "/session", reader, http.StatusForbidden, ""},
+		{"API token with the scope", "/read", reader, http.StatusOK, "jane@example.com"},
+		{"API token missing a scope", "/write", reader, http.StatusForbidden, ""},
+		{"API token with every scope", "/write", apiToken(jane, time.Hour, model.ScopeExpensesWrite, model.ScopeExpensesRead), http.StatusOK, "jane@example.com"},
+		{"expired API token", "/read", apiToken(jane, -time.Minute, model.ScopeExpensesRead), http.StatusUnauthorized, ""},
+		{"unknown API token", "/read", model.APITokenPrefix + "unknown", http.StatusUnauthorized, ""},
+		{"API token of a disabled user", "/read", apiToken(disabled, time.Hour, model.ScopeExpensesRead), http.StatusForbidden, ""},
+		{"user on an admin route", "/admin", session, http.StatusForbidden, ""},
+		{"admin on an admin route", "/admin", login(t, h, admin).Token, http.StatusOK, "admin@example.com"},
+		{"API token of an admin on an admin route", "/admin", apiToken(admin, time.Hour, model.APIScopes...), http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		w := serve(router, "GET", tt.path, tt.token, "")
+		if w.Code != tt.want || (w.Code == http.StatusOK && w.Body.String() != tt.as) {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, w.Body, tt.want, tt.as)
+		}
+	}
+}
+
+func mustParse(t *testing.T, token string) *utils.AccessClaims {
+	claims, err := utils.ParseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
@@ -0,0 +1,466 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Budget struct to represent a budget in the API, dates are given as
+// YYYY-MM-DD or in the user's preferred date format
+type Budget struct {
+	Name string `json:"name" example:"Groceries"`
+	// CategoryId limits the budget to a category and its subcategories, 0
+	// turns it into an overall budget
+	CategoryId *uint       `json:"categoryId"`
+	Category   string      `json:"category" example:"Groceries"`
+	Amount     json.Number `json:"amount" swaggertype:"number" example:"400.00"`
+	// Currency defaults to the user's base currency
+	Currency string `json:"currency" example:"USD"`
+	Period   string `json:"period" enums:"month,week,custom" example:"month"`
+	// StartDate is moved back to the start of its month or week, today when
+	// omitted
+	StartDate string `json:"startDate" example:"2026-10-01"`
+	// EndDate is required for custom budgets, an empty string removes it
+	// from the others
+	EndDate  *string `json:"endDate" example:"2026-12-31"`
+	Rollover *bool   `json:"rollover"`
+}
+
+// @Tags Budget
+// @Summary Get all budgets
+// @Description Retrieve the budgets of the current user
+// @Accept  json
+// @Produce json
+// @Success 200 {array} Budget "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets [get]
+func (h *Handler) GetBudgets(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	budgets, err := h.Budgets.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(budgets)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Budget
+// @Summary Get a budget
+// @Description Retrieve a budget by its ID
+// @Accept  json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} Budget "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [get]
+func (h *Handler) GetBudgetById(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	budget, ok := h.ownedBudget(w, r, userId)
+	if !ok {
+		return
+	}
+	res, err := json.Marshal(budget)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Budget
+// @Summary Create a budget
+// @Description Create a monthly, weekly or custom budget, overall or for a category and its subcategories
+// @Accept  json
+// @Produce json
+// @Param Budget body Budget true "Budget data"
+// @Success 201 {object} Budget "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets [post]
+func (h *Handler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	body := &Budget{}
+	utils.ParseBody(r, body)
+	if body.Amount == "" || body.Period == "" {
+		http.Error(w, `{"message":"Amount and period are required."}`, http.StatusBadRequest)
+		return
+	}
+	if body.Currency == "" {
+		body.Currency = h.baseCurrency(user)
+	}
+
+	budget := &model.Budget{UserId: userId}
+	if !h.applyBudget(w, user, budget, body) {
+		return
+	}
+	if err := h.Budgets.Create(budget); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(budget)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(res)
+}
+
+// @Tags Budget
+// @Summary Update a budget
+// @Description Update a budget, only the fields given are changed
+// @Accept  json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param Budget body Budget true "Budget data"
+// @Success 202 {object} Budget "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [patch]
+func (h *Handler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	body := &Budget{}
+	utils.ParseBody(r, body)
+	budget, ok := h.ownedBudget(w, r, userId)
+	if !ok {
+		return
+	}
+	if !h.applyBudget(w, user, budget, body) {
+		return
+	}
+	if err := h.Budgets.Update(budget); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(budget)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(res)
+}
+
+// @Tags Budget
+// @Summary Delete a budget
+// @Description Delete a budget, expenses are not touched
+// @Accept  json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [delete]
+func (h *Handler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	budget, ok := h.ownedBudget(w, r, userId)
+	if !ok {
+		return
+	}
+	if err := h.Budgets.Delete(int64(budget.ID)); err != nil {
+		http.Error(w, `{"message": "Failed to delete budget"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Budget
+// @Summary Get the status of all budgets
+// @Description Compare, for every budget active on a date, the amount budgeted for the period containing the date with the expenses in it
+// @Accept  json
+// @Produce json
+// @Param date query string false "Date in the period, today by default"
+// @Success 200 {array} model.BudgetStatus "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/status [get]
+func (h *Handler) GetBudgetStatuses(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	date, ok := statusDate(w, r, user)
+	if !ok {
+		return
+	}
+	statuses, err := h.budgetStatuses(userId, date, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(statuses)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Budget
+// @Summary Get the status of a budget
+// @Description Compare the amount budgeted for the period containing a date with the expenses in it
+// @Accept  json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param date query string false "Date in the period, today by default"
+// @Success 200 {object} model.BudgetStatus "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id}/status [get]
+func (h *Handler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	budget, ok := h.ownedBudget(w, r, userId)
+	if !ok {
+		return
+	}
+	date, ok := statusDate(w, r, user)
+	if !ok {
+		return
+	}
+	var categoryIds []uint
+	if budget.CategoryId != nil {
+		categories, err := h.Categories.List(userId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		categoryIds = model.Descendants(categories, *budget.CategoryId)
+	}
+	status, err := model.ComputeBudgetStatus(h.Expenses, model.NewConverter(h.Rates), budget, categoryIds, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// budgetStatuses computes the status of the user's budgets active on date,
+// only keeping the budgets an expense in categoryId counts against unless
+// categoryId is 0
+func (h *Handler) budgetStatuses(userId int64, date model.Date, categoryId uint) ([]model.BudgetStatus, error) {
+	budgets, err := h.Budgets.List(userId)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := h.Categories.List(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := model.NewConverter(h.Rates)
+	statuses := []model.BudgetStatus{}
+	for i := range budgets {
+		budget := &budgets[i]
+		if !budget.ActiveOn(date) {
+			continue
+		}
+		var categoryIds []uint
+		if budget.CategoryId != nil {
+			categoryIds = model.Descendants(categories, *budget.CategoryId)
+		}
+		if categoryId != 0 && !budget.Covers(categoryId, categoryIds) {
+			continue
+		}
+		status, err := model.ComputeBudgetStatus(h.Expenses, converter, budget, categoryIds, date)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// applyBudget validates the fields given in body and copies them onto the
+// budget, writing a JSON error when one is invalid
+func (h *Handler) applyBudget(w http.ResponseWriter, user *model.UserData, budget *model.Budget, body *Budget) bool {
+	if name := strings.TrimSpace(body.Name); name != "" {
+		budget.Name = name
+	}
+	if body.Period != "" {
+		if !model.ValidPeriod(body.Period) {
+			http.Error(w, `{"message":"Invalid period. Use month, week or custom."}`, http.StatusBadRequest)
+			return false
+		}
+		budget.Period = body.Period
+	}
+	if body.Rollover != nil {
+		budget.Rollover = *body.Rollover
+	}
+
+	currency := budget.Currency
+	if body.Currency != "" {
+		currency = strings.ToUpper(body.Currency)
+		if !model.ValidCurrency(currency) {
+			http.Error(w, `{"message":"Invalid currency. Use an ISO 4217 code such as USD."}`, http.StatusBadRequest)
+			return false
+		}
+	}
+	if body.Amount != "" || currency != budget.Currency {
+		value := body.Amount.String()
+		if value == "" {
+			value = model.FormatAmount(budget.Amount, budget.Currency)
+		}
+		amount, err := model.ParseAmount(value, currency)
+		if err != nil || amount <= 0 {
+			http.Error(w, jsonMessage(amountError(err)), http.StatusBadRequest)
+			return false
+		}
+		budget.Amount, budget.Currency = amount, currency
+	}
+
+	switch {
+	case body.CategoryId != nil && *body.CategoryId == 0:
+		budget.CategoryId = nil
+	case body.CategoryId != nil || body.Category != "":
+		var id uint
+		if body.CategoryId != nil {
+			id = *body.CategoryId
+		}
+		category, err := h.resolveCategory(budget.UserId, id, body.Category)
+		if errors.Is(err, model.ErrNotFound) {
+			http.Error(w, `{"message":"Invalid category provided"}`, http.StatusBadRequest)
+			return false
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
+		budget.CategoryId = &category.ID
+		if budget.Name == "" {
+			budget.Name = category.Name
+		}
+	}
+	if budget.Name == "" {
+		budget.Name = "Overall"
+	}
+
+	if body.StartDate != "" {
+		date, err := model.ParseDate(body.StartDate, user.DateFormat)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid startDate. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return false
+		}
+		budget.StartDate = date
+	}
+	if budget.StartDate.IsZero() {
+		budget.StartDate = model.NewDate(time.Now())
+	}
+	if body.EndDate != nil {
+		budget.EndDate = model.Date{}
+		if *body.EndDate != "" {
+			date, err := model.ParseDate(*body.EndDate, user.DateFormat)
+			if err != nil {
+				http.Error(w, jsonMessage("Invalid endDate. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+				return false
+			}
+			budget.EndDate = date
+		}
+	}
+
+	if budget.Period == model.PeriodCustom {
+		if budget.EndDate.IsZero() {
+			http.Error(w, `{"message":"A custom budget needs an endDate."}`, http.StatusBadRequest)
+			return false
+		}
+		if budget.Rollover {
+			http.Error(w, `{"message":"Only monthly and weekly budgets can roll over."}`, http.StatusBadRequest)
+			return false
+		}
+	} else {
+		// monthly and weekly budgets cover whole periods
+		budget.StartDate, _ = budget.PeriodOf(budget.StartDate)
+	}
+	if !budget.EndDate.IsZero() && budget.EndDate.Before(budget.StartDate.Time) {
+		http.Error(w, `{"message":"The endDate cannot be before the startDate."}`, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// ownedBudget loads the budget named by the id path parameter and writes a
+// JSON error unless it belongs to the user
+func (h *Handler) ownedBudget(w http.ResponseWriter, r *http.Request, userId int64) (*model.Budget, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	budget, err := h.Budgets.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Budget not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if budget.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to budget"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return budget, true
+}
+
+// statusDate reads the date query parameter, today when it is missing, and
+// writes a JSON error when it is invalid
+func statusDate(w http.ResponseWriter, r *http.Request, user *model.UserData) (model.Date, bool) {
+	v := r.URL.Query().Get("date")
+	if v == "" {
+		return model.NewDate(time.Now()), true
+	}
+	date, err := model.ParseDate(v, user.DateFormat)
+	if err != nil {
+		http.Error(w, jsonMessage("Invalid date. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+		return date, false
+	}
+	return date, true
+}
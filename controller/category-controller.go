@@ -0,0 +1,337 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Category struct to represent an expense category in the API
+type Category struct {
+	Name string `json:"name" example:"Groceries"`
+	// ParentId nests the category under another one, 0 moves it back to
+	// the top level
+	ParentId *uint  `json:"parentId"`
+	Color    string `json:"color" example:"#4caf50"`
+	Icon     string `json:"icon" example:"cart"`
+	// Account is the account of the category in ledger, hledger and
+	// beancount exports, an empty string derives it from the name again
+	Account *string `json:"account" example:"Expenses:Food:Groceries"`
+}
+
+// resolveCategory finds the user's category by ID or, when no ID is given,
+// by name. ErrNotFound is returned when the user has no such category.
+func (h *Handler) resolveCategory(userId int64, id uint, name string) (*model.Category, error) {
+	var category *model.Category
+	var err error
+	switch {
+	case id != 0:
+		category, err = h.Categories.FindById(int64(id))
+	case name != "":
+		category, err = h.Categories.FindByName(userId, name)
+	default:
+		return nil, model.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if category.UserId != userId {
+		return nil, model.ErrNotFound
+	}
+	return category, nil
+}
+
+// setParent validates the parent of a category against the user's other
+// categories and writes a JSON error when it is not acceptable
+func (h *Handler) setParent(w http.ResponseWriter, category *model.Category, parentId uint) bool {
+	if parentId == 0 {
+		category.ParentId = nil
+		return true
+	}
+
+	categories, err := h.Categories.List(category.UserId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if !slices.ContainsFunc(categories, func(c model.Category) bool { return c.ID == parentId }) {
+		http.Error(w, `{"message": "Invalid parent category"}`, http.StatusBadRequest)
+		return false
+	}
+	if err := model.CheckParent(categories, category.ID, parentId); err != nil {
+		http.Error(w, `{"message": "A category cannot be nested under itself or one of its subcategories"}`, http.StatusBadRequest)
+		return false
+	}
+	category.ParentId = &parentId
+	return true
+}
+
+// setAccount validates the journal account of a category and writes a JSON
+// error when it is not acceptable
+func setAccount(w http.ResponseWriter, category *model.Category, account string) bool {
+	account = strings.TrimSpace(account)
+	if account != "" {
+		if err := model.ValidateAccount(account); err != nil {
+			http.Error(w, jsonMessage("Invalid account, "+err.Error()), http.StatusBadRequest)
+			return false
+		}
+	}
+	category.Account = account
+	return true
+}
+
+// @Tags Category
+// @Summary Get all categories
+// @Description Retrieve the categories of the current user, subcategories reference their parent through parentId
+// @Accept  json
+// @Produce json
+// @Success 200 {array} Category "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories [get]
+func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	categories, err := h.Categories.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if categories == nil {
+		categories = []model.Category{}
+	}
+	res, err := json.Marshal(categories)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Category
+// @Summary Get a category
+// @Description Retrieve a category by its ID
+// @Accept  json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} Category "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Category not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [get]
+func (h *Handler) GetCategoryById(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	category, ok := h.ownedCategory(w, r, userId)
+	if !ok {
+		return
+	}
+	res, err := json.Marshal(category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Category
+// @Summary Create a category
+// @Description Create a new category, optionally nested under one of the user's categories
+// @Accept  json
+// @Produce json
+// @Param Category body Category true "Category data"
+// @Success 201 {object} Category "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Category already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories [post]
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	body := &Category{}
+	utils.ParseBody(r, body)
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		http.Error(w, `{"message": "Name is required"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.nameAvailable(w, userId, body.Name, 0) {
+		return
+	}
+
+	category := &model.Category{UserId: userId, Name: body.Name, Color: body.Color, Icon: body.Icon}
+	if body.ParentId != nil && !h.setParent(w, category, *body.ParentId) {
+		return
+	}
+	if body.Account != nil && !setAccount(w, category, *body.Account) {
+		return
+	}
+	if err := h.Categories.Create(category); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(res)
+}
+
+// @Tags Category
+// @Summary Update a category
+// @Description Rename, move or restyle a category or change its journal account, renaming also renames the category on its expenses
+// @Accept  json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param Category body Category true "Category data"
+// @Success 202 {object} Category "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Category not found"
+// @Failure 409 {string} string "Category already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [patch]
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	body := &Category{}
+	utils.ParseBody(r, body)
+	category, ok := h.ownedCategory(w, r, userId)
+	if !ok {
+		return
+	}
+
+	if name := strings.TrimSpace(body.Name); name != "" && name != category.Name {
+		if !h.nameAvailable(w, userId, name, category.ID) {
+			return
+		}
+		category.Name = name
+	}
+	if body.ParentId != nil && !h.setParent(w, category, *body.ParentId) {
+		return
+	}
+	if body.Color != "" {
+		category.Color = body.Color
+	}
+	if body.Icon != "" {
+		category.Icon = body.Icon
+	}
+	if body.Account != nil && !setAccount(w, category, *body.Account) {
+		return
+	}
+
+	if err := h.Categories.Update(category); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(res)
+}
+
+// @Tags Category
+// @Summary Delete a category
+// @Description Delete a category, its expenses are moved to the replacement category and its subcategories up to its parent
+// @Accept  json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param replacement_id query int true "ID of the category taking over the expenses"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Category not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [delete]
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	category, ok := h.ownedCategory(w, r, userId)
+	if !ok {
+		return
+	}
+
+	replacementId, err := strconv.ParseUint(r.URL.Query().Get("replacement_id"), 10, 0)
+	if err != nil {
+		http.Error(w, `{"message": "The replacement_id query parameter is required."}`, http.StatusBadRequest)
+		return
+	}
+	replacement, err := h.resolveCategory(userId, uint(replacementId), "")
+	if errors.Is(err, model.ErrNotFound) || (err == nil && replacement.ID == category.ID) {
+		http.Error(w, `{"message": "Invalid replacement category"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Categories.Delete(int64(category.ID), replacement); err != nil {
+		http.Error(w, `{"message": "Failed to delete category"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedCategory loads the category named by the id path parameter and
+// writes a JSON error unless it belongs to the user
+func (h *Handler) ownedCategory(w http.ResponseWriter, r *http.Request, userId int64) (*model.Category, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	category, err := h.Categories.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Category not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if category.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to category"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return category, true
+}
+
+// nameAvailable writes a conflict error when the user already has another
+// category with the given name
+func (h *Handler) nameAvailable(w http.ResponseWriter, userId int64, name string, id uint) bool {
+	existing, err := h.Categories.FindByName(userId, name)
+	if errors.Is(err, model.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if existing.ID != id {
+		http.Error(w, `{"message": "Category already exists"}`, http.StatusConflict)
+		return false
+	}
+	return true
+}
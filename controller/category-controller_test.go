@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"expense-tracker/model"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func categoryRouter(h *Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/categories/{id}", h.UpdateCategory).Methods("PATCH")
+	router.HandleFunc("/categories/{id}", h.DeleteCategory).Methods("DELETE")
+	return router
+}
+
+func TestUpdateCategoryParent(t *testing.T) {
+	h, store := newTestHandler()
+	router := categoryRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	token := login(t, h, user).Token
+
+	create := func(userId int64, name string, parentId *uint) *model.Category {
+		category := &model.Category{UserId: userId, Name: name, ParentId: parentId}
+		if err := store.Categories.Create(category); err != nil {
+			t.Fatal(err)
+		}
+		return category
+	}
+	food := create(int64(user.ID), "food", nil)
+	groceries := create(int64(user.ID), "groceries", &food.ID)
+	fruit := create(int64(user.ID), "fruit", &groceries.ID)
+	rent := create(int64(user.ID), "rent", nil)
+	foreign := create(int64(other.ID), "food", nil)
+
+	tests := []struct {
+		id, parentId uint
+		want         int
+	}{
+		{food.ID, food.ID, http.StatusBadRequest},
+		{food.ID, fruit.ID, http.StatusBadRequest},
+		{groceries.ID, fruit.ID, http.StatusBadRequest},
+		// categories of other users cannot be parents
+		{rent.ID, foreign.ID, http.StatusBadRequest},
+		{rent.ID, 999, http.StatusBadRequest},
+		{fruit.ID, rent.ID, http.StatusAccepted},
+		{groceries.ID, 0, http.StatusAccepted},
+		// now that fruit moved away, food can go under groceries
+		{food.ID, groceries.ID, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		w := serve(router, "PATCH", fmt.Sprintf("/categories/%d", tt.id), token, fmt.Sprintf(`{"parentId": %d}`, tt.parentId))
+		if w.Code != tt.want {
+			t.Errorf("%d under %d: got %d %s, want %d", tt.id, tt.parentId, w.Code, w.Body, tt.want)
+		}
+	}
+
+	parents := map[uint]*uint{food.ID: &groceries.ID, groceries.ID: nil, fruit.ID: &rent.ID, rent.ID: nil}
+	for id, want := range parents {
+		got, err := store.Categories.FindById(int64(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got.ParentId == nil) != (want == nil) || want != nil && *got.ParentId != *want {
+			t.Errorf("%s: got parent %v, want %v", got.Name, got.ParentId, want)
+		}
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	h, store := newTestHandler()
+	router := categoryRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	token := login(t, h, user).Token
+
+	food := &model.Category{UserId: int64(user.ID), Name: "food"}
+	rent := &model.Category{UserId: int64(user.ID), Name: "rent"}
+	foreign := &model.Category{UserId: int64(other.ID), Name: "rent"}
+	for _, c := range []*model.Category{food, rent, foreign} {
+		if err := store.Categories.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expense := &model.ExpenseData{UserId: int64(user.ID), Title: "apples", CategoryId: food.ID, Category: "food", Amount: 300, Currency: "USD"}
+	if err := store.Expenses.Create(expense); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{fmt.Sprintf("/categories/%d", food.ID), http.StatusBadRequest},
+		{fmt.Sprintf("/categories/%d?replacement_id=%d", food.ID, food.ID), http.StatusBadRequest},
+		{fmt.Sprintf("/categories/%d?replacement_id=%d", food.ID, foreign.ID), http.StatusBadRequest},
+		{fmt.Sprintf("/categories/%d?replacement_id=%d", foreign.ID, rent.ID), http.StatusForbidden},
+		{fmt.Sprintf("/categories/%d?replacement_id=%d", food.ID, rent.ID), http.StatusNoContent},
+		{fmt.Sprintf("/categories/%d?replacement_id=%d", food.ID, rent.ID), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := serve(router, "DELETE", tt.path, token, ""); w.Code != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.path, w.Code, w.Body, tt.want)
+		}
+	}
+
+	got, err := store.Expenses.FindById(int64(expense.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CategoryId != rent.ID || got.Category != "rent" {
+		t.Errorf("expense: got category %d %q, want %d rent", got.CategoryId, got.Category, rent.ID)
+	}
+}
@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+)
+
+// EmailRequest struct to represent the address an email is requested for
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest struct to represent the token of a reset email with the new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest struct to represent the token of a verification email
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// @Tags Auth
+// @Summary Request a password reset
+// @Description Send a password reset link to the email if it belongs to an account. The response is the same whether it does or not.
+// @Accept  json
+// @Produce json
+// @Param email body EmailRequest true "Email of the account"
+// @Success 202 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	body := &EmailRequest{}
+	utils.ParseBody(r, body)
+	if body.Email == "" {
+		http.Error(w, `{"message": "The email is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Users.FindByEmail(body.Email)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		if err := h.sendEmailToken(user, model.TokenResetPassword); err != nil {
+			log.Printf("Password reset email for user %d: %v", user.ID, err)
+		}
+	}
+	writeMessage(w, http.StatusAccepted, "If the email belongs to an account, a password reset link has been sent to it")
+}
+
+// @Tags Auth
+// @Summary Reset the password
+// @Description Set a new password with the token of a password reset email. The token works once and every session of the user is ended.
+// @Accept  json
+// @Produce json
+// @Param reset body ResetPasswordRequest true "Token and new password"
+// @Success 200 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/reset-password [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	body := &ResetPasswordRequest{}
+	utils.ParseBody(r, body)
+	if body.Token == "" || body.Password == "" {
+		http.Error(w, `{"message": "The token and password are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.EmailTokens.Consume(model.HashToken(body.Token), model.TokenResetPassword)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The reset link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Users.FindById(token.UserId)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The reset link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hash, err := argon2id.CreateHash(body.Password, argon2id.DefaultParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user.Password = hash
+	// receiving the link proves the address, unless it changed since
+	if user.EmailVerifiedAt == nil && user.Email == token.Email {
+		now := time.Now().UTC()
+		user.EmailVerifiedAt = &now
+	}
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// whoever knew the old password must not stay signed in
+	if err := h.Tokens.RevokeUser(token.UserId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, http.StatusOK, "Password has been reset, please log in again")
+}
+
+// @Tags Auth
+// @Summary Verify the email
+// @Description Confirm the email of an account with the token of a verification email
+// @Accept  json
+// @Produce json
+// @Param verify body VerifyEmailRequest true "Verification token"
+// @Success 200 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/verify-email [post]
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	body := &VerifyEmailRequest{}
+	utils.ParseBody(r, body)
+	if body.Token == "" {
+		http.Error(w, `{"message": "The token is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.EmailTokens.Consume(model.HashToken(body.Token), model.TokenVerifyEmail)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The verification link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Users.FindById(token.UserId)
+	if errors.Is(err, model.ErrNotFound) || (err == nil && user.Email != token.Email) {
+		http.Error(w, `{"message": "The verification link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now().UTC()
+		user.EmailVerifiedAt = &now
+		if err := h.Users.Update(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeMessage(w, http.StatusOK, "Email verified")
+}
+
+// @Tags Auth
+// @Summary Confirm a new email
+// @Description Change the email of an account to the new address requested through PATCH /users/me, with the token of the confirmation email sent to it. The new email counts as verified.
+// @Accept  json
+// @Produce json
+// @Param verify body VerifyEmailRequest true "Confirmation token"
+// @Success 200 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "Email already registered"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/confirm-email [post]
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	body := &VerifyEmailRequest{}
+	utils.ParseBody(r, body)
+	if body.Token == "" {
+		http.Error(w, `{"message": "The token is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.EmailTokens.Consume(model.HashToken(body.Token), model.TokenChangeEmail)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The confirmation link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Users.FindById(token.UserId)
+	// a later request for another address replaces this one
+	if errors.Is(err, model.ErrNotFound) || (err == nil && user.PendingEmail != token.Email) {
+		http.Error(w, `{"message": "The confirmation link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// someone may have registered the address since it was requested
+	if _, err := h.Users.FindByEmail(token.Email); err == nil {
+		http.Error(w, `{"message": "Email already registered"}`, http.StatusConflict)
+		return
+	} else if !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	user.Email, user.PendingEmail, user.EmailVerifiedAt = token.Email, "", &now
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, http.StatusOK, "Email changed")
+}
+
+// @Tags Auth
+// @Summary Restore a deleted account
+// @Description Cancel the deletion of an account with the token of the email sent when it was deleted, before the grace period is over. The user can log in again afterwards.
+// @Accept  json
+// @Produce json
+// @Param restore body VerifyEmailRequest true "Restore token"
+// @Success 200 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/restore-account [post]
+func (h *Handler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	body := &VerifyEmailRequest{}
+	utils.ParseBody(r, body)
+	if body.Token == "" {
+		http.Error(w, `{"message": "The token is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.EmailTokens.Consume(model.HashToken(body.Token), model.TokenRestoreAccount)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The restore link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Users.FindById(token.UserId)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The restore link is invalid or has expired"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	userId := int64(user.ID)
+	entry := &model.AuditEntry{UserId: &userId, ActorId: &userId, Action: model.AuditAccountRestore, IP: h.clientIP(r)}
+	if err := h.Audit.Record(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user.DeleteAt = nil
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, http.StatusOK, "Account restored, you can log in again")
+}
+
+// @Tags Auth
+// @Summary Resend the verification email
+// @Description Send a new verification link to the email if it belongs to an unverified account, earlier links stop working. The response is the same whether it does or not.
+// @Accept  json
+// @Produce json
+// @Param email body EmailRequest true "Email of the account"
+// @Success 202 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/verify-email/resend [post]
+func (h *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	body := &EmailRequest{}
+	utils.ParseBody(r, body)
+	if body.Email == "" {
+		http.Error(w, `{"message": "The email is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Users.FindByEmail(body.Email)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil && user.EmailVerifiedAt == nil {
+		if err := h.sendEmailToken(user, model.TokenVerifyEmail); err != nil {
+			log.Printf("Verification email for user %d: %v", user.ID, err)
+		}
+	}
+	writeMessage(w, http.StatusAccepted, "If the email belongs to an unverified account, a verification link has been sent to it")
+}
+
+// sendEmailToken stores a new token of the purpose for the user and emails
+// them the link carrying it
+func (h *Handler) sendEmailToken(user *model.UserData, purpose string) error {
+	ttl, subject, path, text := model.VerifyEmailTTL, "Verify your email", "/verify-email",
+		"Please confirm your email address by opening the link below."
+	switch purpose {
+	case model.TokenResetPassword:
+		ttl, subject, path, text = model.ResetPasswordTTL, "Reset your password", "/reset-password",
+			"A password reset was requested for your account. If it was not you, you can ignore this email."
+	case model.TokenChangeEmail:
+		ttl, subject, path, text = model.ChangeEmailTTL, "Confirm your new email", "/confirm-email",
+			"Please confirm this address as the new email of your account by opening the link below."
+	case model.TokenRestoreAccount:
+		ttl, subject, path, text = time.Until(*user.DeleteAt), "Your account will be deleted", "/restore-account",
+			"Your account and everything it stores will be deleted for good on "+user.DeleteAt.Format("2 January 2006")+
+				". If you change your mind, restore it by opening the link below."
+	}
+
+	raw, token := model.NewEmailToken(user, purpose, ttl)
+	if err := h.EmailTokens.Create(token); err != nil {
+		return err
+	}
+	link := h.AppURL + path + "?token=" + url.QueryEscape(raw)
+	return h.Mailer.Send(utils.Message{
+		To:      token.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("Hello %s,\n\n%s\n\n%s\n\nThe link expires in %s.\n",
+			user.FirstName, text, link, formatTTL(ttl)),
+	})
+}
+
+// formatTTL writes a token lifetime in whole hours, or in days from 3 days on
+func formatTTL(ttl time.Duration) string {
+	ttl = ttl.Round(time.Hour)
+	if days := int(ttl / (24 * time.Hour)); days >= 3 {
+		return fmt.Sprintf("%d days", days)
+	}
+	if hours := int(ttl / time.Hour); hours != 1 {
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return "1 hour"
+}
+
+// verified tells whether the user may start a session, unverified users may
+// only when verification is not required
+func (h *Handler) verified(user *model.UserData) bool {
+	return !h.RequireVerified || user.EmailVerifiedAt != nil
+}
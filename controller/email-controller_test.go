@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/gorilla/mux"
+)
+
+// recordingMailer keeps the emails sent instead of sending them
+type recordingMailer struct {
+	sent []utils.Message
+}
+
+func (m *recordingMailer) Send(msg utils.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var linkToken = regexp.MustCompile(`\?token=(\S+)`)
+
+// lastToken returns the token of the link in the last email sent
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	if len(m.sent) == 0 {
+		t.Fatal("no email was sent")
+	}
+	match := linkToken.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatalf("no link in %q", m.sent[len(m.sent)-1].Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func emailRouter(h *Handler) *mux.Router {
+	router := refreshRouter(h)
+	h.Public(router.HandleFunc("/auth/forgot-password", h.ForgotPassword).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/reset-password", h.ResetPassword).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/verify-email", h.VerifyEmail).Methods("POST"))
+	return router
+}
+
+func TestResetPassword(t *testing.T) {
+	h, store := newTestHandler()
+	mailer := &recordingMailer{}
+	h.Mailer = mailer
+	router := emailRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com", Password: "old"})
+	session := login(t, h, user)
+
+	reset := func(token, password string) int {
+		body, _ := json.Marshal(ResetPasswordRequest{Token: token, Password: password})
+		return serve(router, "POST", "/auth/reset-password", "", string(body)).Code
+	}
+
+	// unknown addresses get the same answer and no email
+	if code := serve(router, "POST", "/auth/forgot-password", "", `{"email": "nobody@example.com"}`).Code; code != http.StatusAccepted || len(mailer.sent) != 0 {
+		t.Errorf("unknown email: got %d and %d emails, want 202 and none", code, len(mailer.sent))
+	}
+	if code := serve(router, "POST", "/auth/forgot-password", "", `{"email": "jane@example.com"}`).Code; code != http.StatusAccepted {
+		t.Fatalf("forgot password: got %d, want 202", code)
+	}
+	replaced := mailer.lastToken(t)
+	serve(router, "POST", "/auth/forgot-password", "", `{"email": "jane@example.com"}`)
+	token := mailer.lastToken(t)
+	if len(mailer.sent) != 2 || mailer.sent[1].To != "jane@example.com" {
+		t.Fatalf("got emails %+v", mailer.sent)
+	}
+
+	// a new link replaces the previous one
+	if code := reset(replaced, "new password"); code != http.StatusBadRequest {
+		t.Errorf("replaced token: got %d, want 400", code)
+	}
+	if code := reset("not a token", "new password"); code != http.StatusBadRequest {
+		t.Errorf("unknown token: got %d, want 400", code)
+	}
+	if code := reset(token, "new password"); code != http.StatusOK {
+		t.Fatalf("reset: got %d, want 200", code)
+	}
+	if code := reset(token, "another password"); code != http.StatusBadRequest {
+		t.Errorf("reused token: got %d, want 400", code)
+	}
+
+	updated, err := store.Users.FindById(int64(user.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := argon2id.ComparePasswordAndHash("new password", updated.Password); !match || err != nil {
+		t.Errorf("new password: got %t, %v", match, err)
+	}
+	// the link proves the address
+	if updated.EmailVerifiedAt == nil {
+		t.Error("the email is not verified after the reset")
+	}
+
+	// the sessions started before the reset are over
+	if code := serve(router, "GET", "/me", session.Token, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("old access token: got %d, want 401", code)
+	}
+	if code, _ := refresh(t, router, session.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("old refresh token: got %d, want 401", code)
+	}
+	// unlike the ones started after it
+	if code := serve(router, "GET", "/me", login(t, h, updated).Token, "").Code; code != http.StatusOK {
+		t.Errorf("new session: got %d, want 200", code)
+	}
+
+	// expired links are refused
+	raw, expired := model.NewEmailToken(updated, model.TokenResetPassword, -time.Minute)
+	if err := store.EmailTokens.Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	if code := reset(raw, "expired password"); code != http.StatusBadRequest {
+		t.Errorf("expired token: got %d, want 400", code)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	h, store := newTestHandler()
+	mailer := &recordingMailer{}
+	h.Mailer = mailer
+	router := emailRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+
+	verify := func(token string) int {
+		body, _ := json.Marshal(VerifyEmailRequest{Token: token})
+		return serve(router, "POST", "/auth/verify-email", "", string(body)).Code
+	}
+
+	if err := h.sendEmailToken(user, model.TokenResetPassword); err != nil {
+		t.Fatal(err)
+	}
+	if code := verify(mailer.lastToken(t)); code != http.StatusBadRequest {
+		t.Errorf("reset token: got %d, want 400", code)
+	}
+
+	if err := h.sendEmailToken(user, model.TokenVerifyEmail); err != nil {
+		t.Fatal(err)
+	}
+	token := mailer.lastToken(t)
+	if code := verify(token); code != http.StatusOK {
+		t.Fatalf("verify: got %d, want 200", code)
+	}
+	if found, err := store.Users.FindById(int64(user.ID)); err != nil || found.EmailVerifiedAt == nil {
+		t.Errorf("verified user: got %+v, %v", found, err)
+	}
+	if code := verify(token); code != http.StatusBadRequest {
+		t.Errorf("reused token: got %d, want 400", code)
+	}
+
+	// a link only verifies the address it was sent to
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	if err := h.sendEmailToken(other, model.TokenVerifyEmail); err != nil {
+		t.Fatal(err)
+	}
+	token = mailer.lastToken(t)
+	other.Email = "johnny@example.com"
+	if err := store.Users.Update(other); err != nil {
+		t.Fatal(err)
+	}
+	if code := verify(token); code != http.StatusBadRequest {
+		t.Errorf("token of a previous address: got %d, want 400", code)
+	}
+
+	raw, expired := model.NewEmailToken(other, model.TokenVerifyEmail, -time.Minute)
+	if err := store.EmailTokens.Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	if code := verify(raw); code != http.StatusBadRequest {
+		t.Errorf("expired token: got %d, want 400", code)
+	}
+	if found, _ := store.Users.FindById(int64(other.ID)); found.EmailVerifiedAt != nil {
+		t.Error("the address was verified by a refused token")
+	}
+}
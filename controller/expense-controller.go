@@ -2,82 +2,73 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"expense-tracker/model"
 	"expense-tracker/utils"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
-// Expense struct to represent an expense in the API
+// Expense struct to represent an expense in the API, the date is given as
+// YYYY-MM-DD or in the user's preferred date format
 type Expense struct {
-	Title      string  `json:"title"`
-	Description string  `json:"description"`
-	Amount     float64 `json:"amount"`
-	Date       string  `json:"date"`
-	Category   string  `json:"category"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Amount is a decimal number, or a string holding one, with no more
+	// decimal places than the currency uses
+	Amount json.Number `json:"amount" swaggertype:"number" example:"12.50"`
+	// Currency is an ISO 4217 code, the server default is used when omitted
+	Currency string `json:"currency" example:"USD"`
+	Date     string `json:"date"`
+	// CategoryId references one of the user's categories, the category may
+	// also be given by name
+	CategoryId uint   `json:"categoryId"`
+	Category   string `json:"category"`
+	// Tags replace the tags of the expense when given, an empty list
+	// removes them all
+	Tags *[]string `json:"tags" example:"trip-lagos,client-x"`
 }
 
-// Categories struct to represent an expense category in the API
-var Categories = [7]string{
-	"Groceries",
-	"Leisure",
-	"Electronics",
-	"Utilities",
-	"Clothing",
-	"Health",
-	"Others",
-}
-
-
 // @Tags Expense
 // @Summary Get all expenses
-// @Description Retrieve a list of all expenses
+// @Description Retrieve a list of all expenses, optionally filtered, sorted and paginated
 // @Accept  json
 // @Produce json
+// @Param start_date query string false "Start date in YYYY-MM-DD format"
+// @Param end_date query string false "End date in YYYY-MM-DD format"
+// @Param category query string false "Category of the expense"
+// @Param category_id query int false "Category ID, subcategories included"
+// @Param tags query string false "Comma separated tags, prefixed with any: (default) or all:"
+// @Param recurring_id query int false "Only expenses created by this recurring expense"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search for in the title and description"
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
+// @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses [get]
-func GetExpense(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
-
-	allExpenses := model.GetExpense()
-    var userExpenses []model.ExpenseData
-    
-    for _, expense := range allExpenses {
-        if expense.UserId == userId {
-            userExpenses = append(userExpenses, expense)
-        }
-    }
-
-    if len(userExpenses) == 0 {
-        http.Error(w, `{"message": "No expenses found"}`, http.StatusNotFound)
-        return
-    }
-
-	res, err := json.Marshal(userExpenses)
+func (h *Handler) GetExpense(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	h.writeExpensePage(w, r, user, query, "No expenses found")
 }
 
 // @Tags Expense
@@ -92,17 +83,9 @@ func GetExpense(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/{id} [get]
-func GetExpenseById(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+func (h *Handler) GetExpenseById(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
 
 	// get the id parameter from the request and convert to integer
 	vars := mux.Vars(r)
@@ -114,22 +97,33 @@ func GetExpenseById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get expense first, then check ownership
-	expense, _ := model.GetExpenseById(ID)
-    if expense.ID == 0 {
-        http.Error(w, `{"message": "Expense not found"}`, http.StatusNotFound)
-        return
-    }
-
-    // Check if the expense belongs to the current user
-    if expense.UserId != userId {
-        http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
-        return
-    }
-
-    res, _ := json.Marshal(expense)
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(res)
+	expense, err := h.Expenses.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Expense not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Check if the expense belongs to the current user
+	if expense.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
+		return
+	}
+
+	// convert the amount into the user's base currency when a rate is known
+	expenses := []model.ExpenseData{*expense}
+	if err := h.convertExpenses(user, expenses); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, _ := json.Marshal(expenses[0])
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
 }
 
 // @Tags Expense
@@ -137,53 +131,31 @@ func GetExpenseById(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve a list of all expenses for the past week
 // @Accept  json
 // @Produce json
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/week [get]
-func FilterExpenseByWeek(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+func (h *Handler) FilterExpenseByWeek(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
 
-	// get the past week expenses based on the current day
-	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
-	var pastWeekExpenses []model.ExpenseData
-	for _, expense := range model.GetExpense() {
-		expenseDate, err := time.Parse("02/01/2006", expense.Date)
-		if err != nil {
-			log.Printf("Failed to parse date for expense ID %d: %v", expense.ID, err)
-			continue
-		}
-
-		if expense.UserId == userId && expenseDate.After(sevenDaysAgo) {
-			pastWeekExpenses = append(pastWeekExpenses, expense)
-		}
-	}
-
-	if len(pastWeekExpenses) == 0 {
-		http.Error(w, `{"message": "No expenses found for the past week"}`, http.StatusNotFound)
-		return
-	}
-
-	res, err := json.Marshal(pastWeekExpenses)
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(res)
+	// get the past week expenses based on the current day
+	query.StartDate = since(time.Now().AddDate(0, 0, -7))
+	h.writeExpensePage(w, r, user, query, "No expenses found for the past week")
 }
 
 // @Tags Expense
@@ -191,53 +163,31 @@ func FilterExpenseByWeek(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve a list of all expenses for the past month
 // @Accept  json
 // @Produce json
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/month [get]
-func FilterExpenseByMonth(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+func (h *Handler) FilterExpenseByMonth(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
 
-	// get the past month expenses based on the current day
-	oneMonthAgo := time.Now().AddDate(0, -1, 0)
-	var pastMonthExpenses []model.ExpenseData
-	for _, expense := range model.GetExpense() {
-		expenseDate, err := time.Parse("02/01/2006", expense.Date)
-		if err != nil {
-			log.Printf("Failed to parse date for expense ID %d: %v", expense.ID, err)
-			continue
-		}
-
-		if expense.UserId == userId && expenseDate.After(oneMonthAgo) {
-			pastMonthExpenses = append(pastMonthExpenses, expense)
-		}
-	}
-
-	if len(pastMonthExpenses) == 0 {
-		http.Error(w, `{"message": "No expenses found for the past month"}`, http.StatusNotFound)
-		return
-	}
-
-	res, err := json.Marshal(pastMonthExpenses)
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(res)
+	// get the past month expenses based on the current day
+	query.StartDate = since(time.Now().AddDate(0, -1, 0))
+	h.writeExpensePage(w, r, user, query, "No expenses found for the past month")
 }
 
 // @Tags Expense
@@ -245,53 +195,31 @@ func FilterExpenseByMonth(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve a list of all expenses for the past three month
 // @Accept  json
 // @Produce json
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/past-three-month [get]
-func FilterExpenseByPastThreeMonth(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
-
-	// get the past three month expenses based on the current day
-	threeMonthAgo := time.Now().AddDate(0, -3, 0)
-	var pastThreeMonthExpenses []model.ExpenseData
-	for _, expense := range model.GetExpense() {
-		expenseDate, err := time.Parse("02/01/2006", expense.Date)
-		if err != nil {
-			log.Printf("Failed to parse date for expense ID %d: %v", expense.ID, err)
-			continue
-		}
-
-		if expense.UserId == userId && expenseDate.After(threeMonthAgo) {
-			pastThreeMonthExpenses = append(pastThreeMonthExpenses, expense)
-		}
-	}
+func (h *Handler) FilterExpenseByPastThreeMonth(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
 
-	if len(pastThreeMonthExpenses) == 0 {
-		http.Error(w, `{"message": "No expenses found for the past three month"}`, http.StatusNotFound)
-		return
-	}
-
-	res, err := json.Marshal(pastThreeMonthExpenses)
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(res)
+	// get the past three month expenses based on the current day
+	query.StartDate = since(time.Now().AddDate(0, -3, 0))
+	h.writeExpensePage(w, r, user, query, "No expenses found for the past three month")
 }
 
 // @Tags Expense
@@ -301,181 +229,159 @@ func FilterExpenseByPastThreeMonth(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param start_date query string true "Start date in YYYY-MM-DD format"
 // @Param end_date query string true "End date in YYYY-MM-DD format"
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/dates [get]
-func FilterExpenseByCustomDate(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-	if user.ID == 0 {
-		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-		return
-	}
-
-	// get the start and end date from query parameters.
-	startDateStr := r.URL.Query().Get("start_date")
-	endDateStr := r.URL.Query().Get("end_date")
+func (h *Handler) FilterExpenseByCustomDate(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
 
-	if startDateStr == "" || endDateStr == "" {
+	// both ends of the range are required here, the expense date is checked
+	// against them inclusively
+	if r.URL.Query().Get("start_date") == "" || r.URL.Query().Get("end_date") == "" {
 		http.Error(w, `{"message": "Both start_date and end_date query parameters are required."}`, http.StatusBadRequest)
 		return
 	}
 
-	// parse the date strings into time.Time objects.
-	const dateFormat = "02/01/2006"
-	startDate, err := time.Parse(dateFormat, startDateStr)
-	if err != nil {
-		http.Error(w, `{"message": "Invalid start_date format. Please use YYYY-MM-DD."}`, http.StatusBadRequest)
-		return
-	}
-	endDate, err := time.Parse(dateFormat, endDateStr)
-	if err != nil {
-		http.Error(w, `{"message": "Invalid end_date format. Please use YYYY-MM-DD."}`, http.StatusBadRequest)
-		return
-	}
-
-	var filteredExpenses []model.ExpenseData
-	allExpenses := model.GetExpense()
-
-	for _, expense := range allExpenses {
-		// skip invalid expenses or those that don't belong to the user.
-		if expense.UserId != userId {
-			continue
-		}
-
-		expenseDate, err := time.Parse(dateFormat, expense.Date)
-		if err != nil {
-			log.Printf("Failed to parse date for expense ID %d: %v", expense.ID, err)
-			continue
-		}
-
-		// check if the expense date is within the custom date range (inclusive).
-		isAfterOrEqualStart := !expenseDate.Before(startDate)
-		isBeforeOrEqualEnd := !expenseDate.After(endDate)
-		if isAfterOrEqualStart && isBeforeOrEqualEnd {
-			filteredExpenses = append(filteredExpenses, expense)
-		}
-	}
-
-	if len(filteredExpenses) == 0 {
-		http.Error(w, `{"message": "No expenses found for the specified date range."}`, http.StatusNotFound)
-		return
-	}
-
-	res, err := json.Marshal(filteredExpenses)
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, `{"message": "Failed to marshal expenses."}`, http.StatusInternalServerError)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	h.writeExpensePage(w, r, user, query, "No expenses found for the specified date range.")
 }
 
 // @Tags Expense
 // @Summary Filter expenses by category
-// @Description Retrieve a list of all expenses by category
+// @Description Retrieve a list of all expenses by category name or ID, one of them is required
 // @Accept  json
 // @Produce json
-// @Param category query string true "Category of the expense"
+// @Param category query string false "Category of the expense"
+// @Param category_id query int false "Category ID, subcategories included"
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 1000" default(100)
+// @Param offset query int false "Number of expenses to skip"
+// @Param cursor query string false "Cursor from the Link header of the previous page"
 // @Success 200 {array} Expense "Successful operation"
+// @Header 200 {integer} X-Total-Count "Number of expenses matching the filters"
+// @Header 200 {string} Link "Link to the next page"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/category [get]
-func FilterExpenseByCategory(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-	if user.ID == 0 {
-		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-		return
-	}
+func (h *Handler) FilterExpenseByCategory(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
 
 	// get the category from query parameters.
-	categoryStr := r.URL.Query().Get("category")
-	if categoryStr == "" {
-		http.Error(w, `{"message": "Category query parameter is required."}`, http.StatusBadRequest)
-		return
-	}
-
-	var filteredExpenses []model.ExpenseData
-	allExpenses := model.GetExpense()
-	for _, expense := range allExpenses {
-		// skip invalid expenses or those that don't belong to the user.
-		if expense.UserId != userId || expense.Category != categoryStr {
-			continue
-		} else {
-			filteredExpenses = append(filteredExpenses, expense)
-		}
-	}
-
-	if len(filteredExpenses) == 0 {
-		http.Error(w, `{"message": "No expenses found for the specified category."}`, http.StatusNotFound)
+	if r.URL.Query().Get("category") == "" && r.URL.Query().Get("category_id") == "" {
+		http.Error(w, `{"message": "Category or category_id query parameter is required."}`, http.StatusBadRequest)
 		return
 	}
 
-	res, err := json.Marshal(filteredExpenses)
+	query, err := h.parseExpenseQuery(r, user)
 	if err != nil {
-		http.Error(w, `{"message": "Failed to marshal expenses."}`, http.StatusInternalServerError)
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	h.writeExpensePage(w, r, user, query, "No expenses found for the specified category.")
 }
 
 // @Tags Expense
 // @Summary Create a expense
-// @Description Create a new expense
+// @Description Create a new expense, the response includes under budgets the status of the budgets the expense counts against
 // @Accept  json
 // @Produce json
 // @Param Expense body Expense true "Expense data"
 // @Success 201 {object} Expense "Successful operation"
+// @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses [post]
-func CreateExpense(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+func (h *Handler) CreateExpense(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
 
 	// parse the request body to create a new expense
-	newExpense := &model.ExpenseData{}
-	utils.ParseBody(r, newExpense)
+	body := &Expense{}
+	utils.ParseBody(r, body)
 
-	if newExpense.Title == "" || newExpense.Description == "" || newExpense.Amount <= 0 || newExpense.Date == "" {
+	if body.Title == "" || body.Description == "" || body.Amount == "" || body.Date == "" {
 		http.Error(w, `{"message":"All fields are required."}`, http.StatusBadRequest)
 		return
 	}
 
-	if newExpense.Category != Categories[0] && newExpense.Category != Categories[1] && newExpense.Category != Categories[2] && newExpense.Category != Categories[3] && newExpense.Category != Categories[4] && newExpense.Category != Categories[5] && newExpense.Category != Categories[6] {
+	currency := strings.ToUpper(body.Currency)
+	if currency == "" {
+		currency = h.Currency
+	}
+	if !model.ValidCurrency(currency) {
+		http.Error(w, `{"message":"Invalid currency. Use an ISO 4217 code such as USD."}`, http.StatusBadRequest)
+		return
+	}
+	amount, err := model.ParseAmount(body.Amount.String(), currency)
+	if err != nil || amount <= 0 {
+		http.Error(w, jsonMessage(amountError(err)), http.StatusBadRequest)
+		return
+	}
+
+	date, err := model.ParseDate(body.Date, user.DateFormat)
+	if err != nil {
+		http.Error(w, jsonMessage("Invalid date. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.resolveCategory(userId, body.CategoryId, body.Category)
+	if errors.Is(err, model.ErrNotFound) {
 		http.Error(w, `{"message":"Invalid category provided"}`, http.StatusBadRequest)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var tags []string
+	if body.Tags != nil {
+		if tags, err = model.NormalizeTags(*body.Tags); err != nil {
+			http.Error(w, jsonMessage("Invalid tags: "+err.Error()+"."), http.StatusBadRequest)
+			return
+		}
+	}
+
+	newExpense := &model.ExpenseData{
+		Title:       body.Title,
+		Description: body.Description,
+		Amount:      amount,
+		Currency:    currency,
+		Date:        date,
+		CategoryId:  category.ID,
+		Category:    category.Name,
+		Tags:        tags,
+	}
 
 	// append the userId to the new expense data
 	newExpense.UserId = userId
-	expense := newExpense.CreateExpense()
-	res, err := json.Marshal(expense)
+	if err := h.Expenses.Create(newExpense); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// let clients warn straight away when the expense strains a budget
+	if newExpense.Budgets, err = h.budgetStatuses(userId, newExpense.Date, newExpense.CategoryId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(newExpense)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -494,25 +400,18 @@ func CreateExpense(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Expense ID"
 // @Param Expense body Expense true "Expense data"
 // @Success 204 {object} Expense "Successful operation"
+// @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Expense not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/{id} [patch]
-func UpdateExpense(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	// add authorization check
-    userId, err := utils.GetUserIdFromJWTToken(r)
-    if err != nil {
-        http.Error(w, err.Error(), http.StatusUnauthorized)
-        return
-    }
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+	user := currentUser(r)
+	userId := int64(user.ID)
 
 	// parse the data from the body and convert the id parameter from the request
-	updateExpense := &model.ExpenseData{}
+	updateExpense := &Expense{}
 	utils.ParseBody(r, updateExpense)
 	vars := mux.Vars(r)
 	expenseId := vars["id"]
@@ -523,37 +422,85 @@ func UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// take the details of the expense and verify it against the update details
-	expense, db := model.GetExpenseById(ID)
-	if expense.ID == 0 {
+	expense, err := h.Expenses.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
 		http.Error(w, `{"message": "Expense not found"}`, http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	// Check ownership
-    if expense.UserId != userId {
-        http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
-        return
-    }
+	if expense.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
+		return
+	}
 
 	// update the existing expense, not the updateExpense struct
-    if updateExpense.Title != "" {
-        expense.Title = updateExpense.Title
-    }
-    if updateExpense.Description != "" {
-        expense.Description = updateExpense.Description
-    }
-    if updateExpense.Date != "" {
-        expense.Date = updateExpense.Date
-    }
-    if updateExpense.Category != "" {
-        expense.Category = updateExpense.Category
-    }
-    if updateExpense.Amount != 0 {
-        expense.Amount = updateExpense.Amount
-    }
+	if updateExpense.Title != "" {
+		expense.Title = updateExpense.Title
+	}
+	if updateExpense.Description != "" {
+		expense.Description = updateExpense.Description
+	}
+	if updateExpense.Date != "" {
+		date, err := model.ParseDate(updateExpense.Date, user.DateFormat)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid date. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+		expense.Date = date
+	}
+	if updateExpense.CategoryId != 0 || updateExpense.Category != "" {
+		category, err := h.resolveCategory(userId, updateExpense.CategoryId, updateExpense.Category)
+		if errors.Is(err, model.ErrNotFound) {
+			http.Error(w, `{"message":"Invalid category provided"}`, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		expense.CategoryId, expense.Category = category.ID, category.Name
+	}
+	if updateExpense.Tags != nil {
+		tags, err := model.NormalizeTags(*updateExpense.Tags)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid tags: "+err.Error()+"."), http.StatusBadRequest)
+			return
+		}
+		expense.Tags = tags
+	}
+
+	// a new currency keeps the decimal amount unless a new one is given
+	currency := expense.Currency
+	if updateExpense.Currency != "" {
+		currency = strings.ToUpper(updateExpense.Currency)
+		if !model.ValidCurrency(currency) {
+			http.Error(w, `{"message":"Invalid currency. Use an ISO 4217 code such as USD."}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if updateExpense.Amount != "" || currency != expense.Currency {
+		value := updateExpense.Amount.String()
+		if value == "" {
+			value = model.FormatAmount(expense.Amount, expense.Currency)
+		}
+		amount, err := model.ParseAmount(value, currency)
+		if err != nil || amount <= 0 {
+			http.Error(w, jsonMessage(amountError(err)), http.StatusBadRequest)
+			return
+		}
+		expense.Amount, expense.Currency = amount, currency
+	}
 
 	// save the updated details to the database and marshal the details for a response
-	db.Save(&expense)
+	if err := h.Expenses.Update(expense); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	res, err := json.Marshal(expense)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -576,18 +523,9 @@ func UpdateExpense(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {string} string "Expense not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /expenses/{id} [delete]
-func DeleteExpenseById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteExpenseById(w http.ResponseWriter, r *http.Request) {
 	// add authorization check
-    userId, err := utils.GetUserIdFromJWTToken(r)
-    if err != nil {
-        http.Error(w, err.Error(), http.StatusUnauthorized)
-        return
-    }
-	user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
-        return
-    }
+	userId := currentUserId(r)
 
 	// get the ID from the paramter and convert to integer
 	vars := mux.Vars(r)
@@ -599,23 +537,34 @@ func DeleteExpenseById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check if expense exists and user owns it before deleting
-    expense, _ := model.GetExpenseById(ID)
-    if expense.ID == 0 {
-        http.Error(w, `{"message": "Expense not found"}`, http.StatusNotFound)
-        return
-    }
-
-    if expense.UserId != userId {
-        http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
-        return
-    }
-
-	deletedExpense := model.DeleteExpenseById(ID)
-    if deletedExpense.ID == 0 {
-        http.Error(w, `{"message": "Failed to delete expense"}`, http.StatusInternalServerError)
-        return
-    }
+	expense, err := h.Expenses.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Expense not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if expense.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to expense"}`, http.StatusForbidden)
+		return
+	}
+
+	if err := h.Expenses.Delete(ID); err != nil {
+		http.Error(w, `{"message": "Failed to delete expense"}`, http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// amountError explains why an amount was rejected
+func amountError(err error) string {
+	if err == nil {
+		return "Amount must be greater than zero."
+	}
+	return "Invalid amount: " + err.Error() + "."
+}
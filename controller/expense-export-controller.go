@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"expense-tracker/model"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportContentTypes are the media types of the expense export formats
+var exportContentTypes = map[string]string{
+	model.ExportCSV:       "text/csv; charset=utf-8",
+	model.ExportJSONL:     "application/x-ndjson",
+	model.ExportXLSX:      "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	model.ExportLedger:    "text/plain; charset=utf-8",
+	model.ExportHledger:   "text/plain; charset=utf-8",
+	model.ExportBeancount: "text/plain; charset=utf-8",
+}
+
+// @Tags Expense
+// @Summary Export expenses
+// @Description Download the expenses matching the filters of the listing as a CSV, JSON Lines or XLSX file, oldest first unless sorted otherwise.
+// @Description ledger, hledger and beancount journals book every expense from the funding account to the account of its category, always by date and ID.
+// @Description The file is streamed page by page, so exports of any size are fine.
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format: csv, jsonl, xlsx, ledger, hledger or beancount" default(csv)
+// @Param columns query string false "Comma separated columns: id, date, title, description, amount, currency, category, categoryId, tags, convertedAmount, baseCurrency, recurringId, importId, createdAt, updatedAt" default(date,title,description,category,tags,amount,currency)
+// @Param dateFormat query string false "Date format such as DD.MM.YYYY, the user's preferred format by default"
+// @Param decimalSeparator query string false "Decimal separator of CSV amounts: . or ," default(.)
+// @Param delimiter query string false "Field delimiter of CSV files, a semicolon when the decimal separator is a comma" default(,)
+// @Param bom query bool false "Start CSV files with a UTF-8 byte order mark for Excel"
+// @Param fundingAccount query string false "Account journals pay the expenses from" default(Assets:Cash)
+// @Param start_date query string false "Start date in YYYY-MM-DD format"
+// @Param end_date query string false "End date in YYYY-MM-DD format"
+// @Param category query string false "Category of the expense"
+// @Param category_id query int false "Category ID, subcategories included"
+// @Param tags query string false "Comma separated tags, prefixed with any: (default) or all:"
+// @Param recurring_id query int false "Only expenses created by this recurring expense"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search for in the title and description"
+// @Param sort query string false "Sort field: date, amount, title, category or id" default(date)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Success 200 {file} file "The export file"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /expenses/export [get]
+func (h *Handler) ExportExpenses(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	params := r.URL.Query()
+
+	format := params.Get("format")
+	if format == "" {
+		format = model.ExportCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		http.Error(w, `{"message": "Invalid format. Use csv, jsonl, xlsx, ledger, hledger or beancount."}`, http.StatusBadRequest)
+		return
+	}
+	options := model.ExportOptions{
+		DateFormat:       params.Get("dateFormat"),
+		DecimalSeparator: params.Get("decimalSeparator"),
+		Delimiter:        params.Get("delimiter"),
+		FundingAccount:   strings.TrimSpace(params.Get("fundingAccount")),
+	}
+	if options.DateFormat == "" {
+		options.DateFormat = user.DateFormat
+	}
+	if v := params.Get("columns"); v != "" {
+		for _, column := range strings.Split(v, ",") {
+			options.Columns = append(options.Columns, strings.TrimSpace(column))
+		}
+	}
+	if v := params.Get("bom"); v != "" {
+		bom, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, `{"message": "Invalid bom. Use true or false."}`, http.StatusBadRequest)
+			return
+		}
+		options.BOM = bom
+	}
+	if err := options.Normalize(); err != nil {
+		http.Error(w, jsonMessage(capitalize(err.Error())), http.StatusBadRequest)
+		return
+	}
+
+	query, err := h.parseExpenseQuery(r, user)
+	if err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+	// the whole result is exported, oldest first unless asked otherwise
+	query.Limit, query.Offset, query.Cursor = maxPageSize, 0, ""
+	if !params.Has("order") {
+		query.Desc = false
+	}
+	if model.JournalFormat(format) {
+		// journals list transactions by date, in the same order every time
+		// so that exports can be compared
+		query.Sort, query.Desc = model.SortByDate, false
+		categories, err := h.Categories.List(int64(user.ID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		options.Accounts = model.CategoryAccounts(categories)
+	}
+	convert := false
+	for _, column := range options.Columns {
+		convert = convert || column == "convertedAmount" || column == "baseCurrency"
+	}
+
+	// the first page is read before answering, so that a failing query is
+	// still reported with its status
+	page, err := h.Expenses.List(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="expenses-`+time.Now().Format("2006-01-02")+"."+model.ExportExtension(format)+`"`)
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	writer, err := model.NewExpenseWriter(w, format, options)
+	if err == nil {
+		err = h.streamExpenses(w, writer, user, query, page, convert)
+	}
+	if err != nil {
+		// the status is sent, the client sees a truncated file
+		log.Printf("Expense export of user %d: %v", user.ID, err)
+	}
+}
+
+// streamExpenses writes the page and those following it, sending each page
+// to the client before reading the next one
+func (h *Handler) streamExpenses(w http.ResponseWriter, writer model.ExpenseWriter, user *model.UserData,
+	query model.ExpenseQuery, page *model.ExpensePage, convert bool) error {
+	flusher, _ := w.(http.Flusher)
+	for {
+		if convert {
+			if err := h.convertExpenses(user, page.Expenses); err != nil {
+				return err
+			}
+		}
+		for i := range page.Expenses {
+			if err := writer.Write(&page.Expenses[i]); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return writer.Close()
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		query.Cursor = page.NextCursor
+		var err error
+		if page, err = h.Expenses.List(query); err != nil {
+			return err
+		}
+	}
+}
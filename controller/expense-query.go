@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var sortFields = []string{model.SortByDate, model.SortByAmount, model.SortByTitle, model.SortByCategory, model.SortById}
+
+// parseExpenseQuery builds the listing query for a user from the request
+// query string: start_date, end_date, category, category_id, tags,
+// recurring_id, min_amount, max_amount, q, sort, order, limit, offset and cursor. Dates are
+// read as ISO 8601 or in the user's preferred format, category_id also
+// matches the subcategories of the category.
+func (h *Handler) parseExpenseQuery(r *http.Request, user *model.UserData) (model.ExpenseQuery, error) {
+	params := r.URL.Query()
+	query := model.ExpenseQuery{
+		ExpenseFilter: model.ExpenseFilter{
+			UserId:   int64(user.ID),
+			Category: params.Get("category"),
+			Search:   params.Get("q"),
+		},
+		Sort:   model.SortByDate,
+		Desc:   true,
+		Limit:  defaultPageSize,
+		Cursor: params.Get("cursor"),
+	}
+
+	var err error
+	if v := params.Get("start_date"); v != "" {
+		if query.StartDate, err = model.ParseDate(v, user.DateFormat); err != nil {
+			return query, fmt.Errorf("Invalid start_date format. Please use %s.", model.DateFormatHint(user.DateFormat))
+		}
+	}
+	if v := params.Get("end_date"); v != "" {
+		if query.EndDate, err = model.ParseDate(v, user.DateFormat); err != nil {
+			return query, fmt.Errorf("Invalid end_date format. Please use %s.", model.DateFormatHint(user.DateFormat))
+		}
+	}
+	if v := params.Get("category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return query, errors.New("Invalid category_id.")
+		}
+		categories, err := h.Categories.List(int64(user.ID))
+		if err != nil {
+			return query, err
+		}
+		if !slices.ContainsFunc(categories, func(c model.Category) bool { return c.ID == uint(id) }) {
+			return query, errors.New("Invalid category_id.")
+		}
+		query.CategoryIds = model.Descendants(categories, uint(id))
+	}
+	if v := params.Get("tags"); v != "" {
+		if query.Tags, query.AllTags, err = parseTagsParam(v); err != nil {
+			return query, err
+		}
+	}
+	if v := params.Get("recurring_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return query, errors.New("Invalid recurring_id.")
+		}
+		query.RecurringId = uint(id)
+	}
+	if query.MinAmount, err = parseAmountParam(params, "min_amount"); err != nil {
+		return query, err
+	}
+	if query.MaxAmount, err = parseAmountParam(params, "max_amount"); err != nil {
+		return query, err
+	}
+
+	if v := params.Get("sort"); v != "" {
+		if !slices.Contains(sortFields, v) {
+			return query, fmt.Errorf("Invalid sort field. Use one of %s.", strings.Join(sortFields, ", "))
+		}
+		query.Sort = v
+	}
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Desc = false
+	default:
+		return query, errors.New("Invalid order. Use asc or desc.")
+	}
+
+	if v := params.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 || query.Limit > maxPageSize {
+			return query, fmt.Errorf("Invalid limit. Use a number between 1 and %d.", maxPageSize)
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		if query.Offset, err = strconv.Atoi(v); err != nil || query.Offset < 0 {
+			return query, errors.New("Invalid offset. Use a positive number.")
+		}
+		if query.Cursor != "" {
+			return query, errors.New("Use either offset or cursor, not both.")
+		}
+	}
+	return query, nil
+}
+
+// parseTagsParam reads a tag filter such as "any:trip-lagos,client-x" or
+// "all:trip-lagos,client-x", without a prefix any tag matches
+func parseTagsParam(v string) ([]string, bool, error) {
+	all := false
+	if rest, ok := strings.CutPrefix(v, "all:"); ok {
+		v, all = rest, true
+	} else if rest, ok := strings.CutPrefix(v, "any:"); ok {
+		v = rest
+	}
+	tags, err := model.NormalizeTags(strings.Split(v, ","))
+	if err != nil {
+		return nil, false, errors.New("Invalid tags. Use any: or all: followed by comma separated tags.")
+	}
+	return tags, all, nil
+}
+
+func parseAmountParam(params url.Values, name string) (*int64, error) {
+	v := params.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := model.ParseScaledAmount(v)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s. Use a number with at most %d decimal places.", name, model.AmountScale)
+	}
+	return &amount, nil
+}
+
+// since returns the first day strictly after t, so that a listing starting
+// on it contains the expenses dated after t
+func since(t time.Time) model.Date {
+	return model.NewDate(t.AddDate(0, 0, 1))
+}
+
+// jsonMessage formats a message the way the API reports errors
+func jsonMessage(message string) string {
+	res, _ := json.Marshal(map[string]string{"message": message})
+	return string(res)
+}
+
+// writeMessage writes a JSON message as a successful response
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(jsonMessage(message)))
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+// writeExpensePage runs the listing query and writes the page as a JSON
+// array, with amounts also given in the user's base currency. The total number of matches is sent in the X-Total-Count header and
+// the following page, if any, is linked in the Link header.
+func (h *Handler) writeExpensePage(w http.ResponseWriter, r *http.Request, user *model.UserData, query model.ExpenseQuery, emptyMessage string) {
+	page, err := h.Expenses.List(query)
+	if errors.Is(err, model.ErrInvalidCursor) {
+		http.Error(w, `{"message": "Invalid cursor."}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if page.Total == 0 {
+		http.Error(w, jsonMessage(emptyMessage), http.StatusNotFound)
+		return
+	}
+	if err := h.convertExpenses(user, page.Expenses); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(page.Expenses)
+	if err != nil {
+		http.Error(w, `{"message": "Failed to marshal expenses."}`, http.StatusInternalServerError)
+		return
+	}
+
+	// offset pagination is kept for clients asking for it, everyone else
+	// follows the cursor
+	next := r.URL.Query()
+	if next.Has("offset") {
+		if offset := query.Offset + len(page.Expenses); int64(offset) < page.Total {
+			next.Set("offset", strconv.Itoa(offset))
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+		}
+	} else if page.NextCursor != "" {
+		next.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTagsParam(t *testing.T) {
+	tests := []struct {
+		value string
+		tags  []string
+		all   bool
+		ok    bool
+	}{
+		{"trip-lagos", []string{"trip-lagos"}, false, true},
+		{"any:trip-lagos,client-x", []string{"client-x", "trip-lagos"}, false, true},
+		{"all:trip-lagos,client-x", []string{"client-x", "trip-lagos"}, true, true},
+		// tags are normalized and repeated ones dropped
+		{"all: Trip-Lagos ,trip-lagos", []string{"trip-lagos"}, true, true},
+		// only the first prefix is one
+		{"any:all:x", []string{"all:x"}, false, true},
+		{"all:", nil, false, false},
+		{"any:a,,b", nil, false, false},
+	}
+	for _, tt := range tests {
+		tags, all, err := parseTagsParam(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q: got error %v, want ok %t", tt.value, err, tt.ok)
+			continue
+		}
+		if tt.ok && (!slices.Equal(tags, tt.tags) || all != tt.all) {
+			t.Errorf("%q: got %q all %t, want %q all %t", tt.value, tags, all, tt.tags, tt.all)
+		}
+	}
+}
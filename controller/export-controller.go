@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"expense-tracker/model"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Export struct to represent a data export in the API
+type Export struct {
+	ID uint `json:"id"`
+	// Status is pending, ready or failed
+	Status string `json:"status" example:"ready"`
+	// Error tells why a failed export could not be built
+	Error string `json:"error,omitempty"`
+	// Size is the size of the ZIP archive in bytes
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	// ExpiresAt is when the archive is deleted
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// DownloadURL is where a ready archive is downloaded
+	DownloadURL string `json:"downloadUrl,omitempty" example:"/api/v1/users/me/export/1/download"`
+}
+
+// @Tags User
+// @Summary Export my data
+// @Description Request a ZIP archive of my data: the profile, expenses, categories, tags, recurring expenses and budgets as JSON, and the profile, expenses and categories as CSV. The archive is built in the background, the user is emailed once it is ready and can download it for 7 days. A request made while another export is pending returns that export.
+// @Accept  json
+// @Produce json
+// @Success 202 {object} Export "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/export [post]
+func (h *Handler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	exports, err := h.Exports.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range exports {
+		if exports[i].Status == model.ExportPending {
+			w.Header().Set("Location", exportURL(&exports[i]))
+			writeJSON(w, http.StatusAccepted, export(&exports[i]))
+			return
+		}
+	}
+
+	pending := &model.DataExport{UserId: userId, Status: model.ExportPending}
+	if err := h.Exports.Create(pending); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// wake the worker up, a wake-up already queued covers this export too
+	select {
+	case h.exportRequests <- struct{}{}:
+	default:
+	}
+	w.Header().Set("Location", exportURL(pending))
+	writeJSON(w, http.StatusAccepted, export(pending))
+}
+
+// @Tags User
+// @Summary Get my data exports
+// @Description List my data exports, newest first
+// @Accept  json
+// @Produce json
+// @Success 200 {array} Export "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/export [get]
+func (h *Handler) GetExports(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.Exports.List(currentUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := make([]Export, len(exports))
+	for i := range exports {
+		res[i] = export(&exports[i])
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// @Tags User
+// @Summary Get a data export
+// @Description Get the status of one of my data exports
+// @Accept  json
+// @Produce json
+// @Param id path int true "Export ID"
+// @Success 200 {object} Export "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Export not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/export/{id} [get]
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	found, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, export(found))
+}
+
+// @Tags User
+// @Summary Download a data export
+// @Description Download the ZIP archive of one of my ready data exports
+// @Accept  json
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Export not found"
+// @Failure 409 {string} string "Export not ready"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/export/{id}/download [get]
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	found, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+	switch found.Status {
+	case model.ExportPending:
+		http.Error(w, `{"message": "The export is not ready yet"}`, http.StatusConflict)
+		return
+	case model.ExportFailed:
+		http.Error(w, `{"message": "The export failed, please request a new one"}`, http.StatusConflict)
+		return
+	}
+
+	archive, err := h.Exports.Archive(found.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Export not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="expense-tracker-export-%s.zip"`,
+		found.CreatedAt.UTC().Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+// RunExports builds the pending data exports one at a time, first those left
+// over from before a restart and then each one as it is requested
+func (h *Handler) RunExports() {
+	for {
+		pending, err := h.Exports.Pending()
+		if err != nil {
+			log.Printf("Data exports: %v", err)
+		}
+		for i := range pending {
+			h.buildExport(&pending[i])
+		}
+		<-h.exportRequests
+	}
+}
+
+// buildExport collects the data of the export's user into a ZIP archive,
+// stores it and tells the user whether it worked
+func (h *Handler) buildExport(pending *model.DataExport) {
+	user, err := h.Users.FindById(pending.UserId)
+	if err != nil {
+		log.Printf("Data export %d: %v", pending.ID, err)
+		return
+	}
+
+	var archive bytes.Buffer
+	takeout, err := h.collectTakeout(user)
+	if err == nil {
+		err = takeout.WriteZip(&archive)
+	}
+	now := time.Now().UTC()
+	pending.CompletedAt = &now
+	if err != nil {
+		log.Printf("Data export %d: %v", pending.ID, err)
+		pending.Status, pending.Error = model.ExportFailed, "The data could not be collected"
+		if err := h.Exports.Complete(pending, nil); err != nil {
+			log.Printf("Data export %d: %v", pending.ID, err)
+			return
+		}
+		h.notify(user, "Your data export failed", "We could not put together the copy of your data you asked for. Please request a new one.")
+		return
+	}
+
+	expires := now.Add(model.ExportTTL)
+	pending.Status, pending.Size, pending.ExpiresAt = model.ExportReady, int64(archive.Len()), &expires
+	if err := h.Exports.Complete(pending, archive.Bytes()); err != nil {
+		log.Printf("Data export %d: %v", pending.ID, err)
+		return
+	}
+	h.notify(user, "Your data export is ready", fmt.Sprintf("The copy of your data you asked for is ready. Download it from your account "+
+		"before %s, when it is deleted.", expires.Format("2 January 2006")))
+}
+
+// collectTakeout gathers everything the user stores
+func (h *Handler) collectTakeout(user *model.UserData) (*model.Takeout, error) {
+	userId := int64(user.ID)
+	takeout := &model.Takeout{Profile: *user, Expenses: []model.ExpenseData{}}
+
+	query := model.ExpenseQuery{ExpenseFilter: model.ExpenseFilter{UserId: userId}, Sort: model.SortById, Limit: maxPageSize}
+	for {
+		page, err := h.Expenses.List(query)
+		if err != nil {
+			return nil, err
+		}
+		takeout.Expenses = append(takeout.Expenses, page.Expenses...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	var err error
+	if takeout.Categories, err = h.Categories.List(userId); err != nil {
+		return nil, err
+	}
+	if takeout.Tags, err = h.Tags.List(userId); err != nil {
+		return nil, err
+	}
+	if takeout.Recurring, err = h.Recurring.List(userId); err != nil {
+		return nil, err
+	}
+	if takeout.Budgets, err = h.Budgets.List(userId); err != nil {
+		return nil, err
+	}
+	return takeout, nil
+}
+
+// findExport loads the export of the path for the current user and answers
+// the request itself when it cannot
+func (h *Handler) findExport(w http.ResponseWriter, r *http.Request) (*model.DataExport, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, `{"message": "Invalid export ID"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	found, err := h.Exports.FindById(ID)
+	// expired exports count as deleted until the scheduler gets to them
+	if errors.Is(err, model.ErrNotFound) || (err == nil && (found.UserId != currentUserId(r) ||
+		found.ExpiresAt != nil && found.ExpiresAt.Before(time.Now()))) {
+		http.Error(w, `{"message": "Export not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return found, true
+}
+
+// exportURL is the path of an export
+func exportURL(e *model.DataExport) string {
+	return fmt.Sprintf("/api/v1/users/me/export/%d", e.ID)
+}
+
+// export converts a data export into its API representation
+func export(e *model.DataExport) Export {
+	res := Export{
+		ID:          e.ID,
+		Status:      e.Status,
+		Error:       e.Error,
+		Size:        e.Size,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+	if e.Status == model.ExportReady {
+		res.DownloadURL = exportURL(e) + "/download"
+	}
+	return res
+}
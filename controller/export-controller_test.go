@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"expense-tracker/model"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDataExport(t *testing.T) {
+	h, store := newTestHandler()
+	mailer := &recordingMailer{}
+	h.Mailer = mailer
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/users/me/export", h.RequestExport).Methods("POST")
+	router.HandleFunc("/users/me/export/{id}/download", h.DownloadExport).Methods("GET")
+	user := createUser(t, store, model.UserData{Email: "jane@example.com", FirstName: "Jane"})
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	for _, expense := range []model.ExpenseData{
+		{UserId: int64(user.ID), Title: "Dinner", Amount: 4550, Currency: "USD", Date: model.NewDate(user.CreatedAt)},
+		{UserId: int64(other.ID), Title: "Rent of John", Amount: 90000, Currency: "USD", Date: model.NewDate(user.CreatedAt)},
+	} {
+		if err := store.Expenses.Create(&expense); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := login(t, h, user).Token
+
+	w := serve(router, "POST", "/users/me/export", session, "")
+	var requested Export
+	if err := json.Unmarshal(w.Body.Bytes(), &requested); err != nil || w.Code != http.StatusAccepted || requested.Status != model.ExportPending {
+		t.Fatalf("request: got %d %s", w.Code, w.Body)
+	}
+	// a second request while the first is pending returns the same export
+	if w := serve(router, "POST", "/users/me/export", session, ""); !bytes.Contains(w.Body.Bytes(), []byte(fmt.Sprintf(`"id":%d`, requested.ID))) {
+		t.Errorf("second request: got %d %s, want export %d", w.Code, w.Body, requested.ID)
+	}
+	download := fmt.Sprintf("/users/me/export/%d/download", requested.ID)
+	if code := serve(router, "GET", download, session, "").Code; code != http.StatusConflict {
+		t.Errorf("pending download: got %d, want 409", code)
+	}
+
+	pending, err := store.Exports.Pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("pending: got %+v, %v", pending, err)
+	}
+	h.buildExport(&pending[0])
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "jane@example.com" || mailer.sent[0].Subject != "Your data export is ready" {
+		t.Errorf("emails: got %+v", mailer.sent)
+	}
+	built, err := store.Exports.FindById(int64(requested.ID))
+	if err != nil || built.Status != model.ExportReady || built.ExpiresAt == nil || built.Size == 0 {
+		t.Fatalf("built export: got %+v, %v", built, err)
+	}
+
+	// only the user may download it, and it holds only their data
+	if code := serve(router, "GET", download, login(t, h, other).Token, "").Code; code != http.StatusNotFound {
+		t.Errorf("download by another user: got %d, want 404", code)
+	}
+	w = serve(router, "GET", download, session, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" || int64(w.Body.Len()) != built.Size {
+		t.Fatalf("download: got %d %s, %d bytes", w.Code, w.Header().Get("Content-Type"), w.Body.Len())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(files) != 9 || !bytes.Contains(files["profile.csv"], []byte("jane@example.com")) {
+		t.Errorf("archive: got files %d and profile %s", len(files), files["profile.csv"])
+	}
+	var expenses []model.ExpenseData
+	if err := json.Unmarshal(files["expenses.json"], &expenses); err != nil || len(expenses) != 1 || expenses[0].Title != "Dinner" {
+		t.Errorf("expenses.json: got %+v, %v, want only Dinner", expenses, err)
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler groups the HTTP handlers together with the repositories they use
+type Handler struct {
+	Users          model.UserRepository
+	Expenses       model.ExpenseRepository
+	Categories     model.CategoryRepository
+	Tags           model.TagRepository
+	Recurring      model.RecurringRepository
+	Budgets        model.BudgetRepository
+	Tokens         model.TokenRepository
+	EmailTokens    model.EmailTokenRepository
+	MFA            model.MFARepository
+	APITokens      model.APITokenRepository
+	Rates          model.RateRepository
+	Exports        model.ExportRepository
+	ImportMappings model.ImportMappingRepository
+	Imports        model.ImportRepository
+	// Logins counts the failed logins, Audit records the lockouts they cause
+	Logins model.LoginLimiter
+	Audit  model.AuditRepository
+	// Currency is used for expenses created without a currency
+	Currency string
+	// Mailer sends the verification and password reset emails, which link
+	// to pages under AppURL
+	Mailer utils.Mailer
+	AppURL string
+	// RequireVerified refuses sessions to users who did not verify their email
+	RequireVerified bool
+	// Keys are the keys access tokens are signed and verified with
+	Keys *utils.KeySet
+	// TrustProxy takes the client address from the X-Forwarded-For header
+	// set by the proxy in front of the API
+	TrustProxy bool
+	// DeletionGrace is how long a deleted account can be restored before it
+	// is deleted for good
+	DeletionGrace time.Duration
+	// exportRequests wakes RunExports up when an export is requested
+	exportRequests chan struct{}
+	// access holds what the routes declared through Public and RequireScopes
+	access map[*mux.Route]Access
+}
+
+// NewHandler returns a Handler backed by the given store
+func NewHandler(store *model.Store, currency string) *Handler {
+	return &Handler{
+		Users:          store.Users,
+		Expenses:       store.Expenses,
+		Categories:     store.Categories,
+		Tags:           store.Tags,
+		Recurring:      store.Recurring,
+		Budgets:        store.Budgets,
+		Tokens:         store.Tokens,
+		EmailTokens:    store.EmailTokens,
+		MFA:            store.MFA,
+		APITokens:      store.APITokens,
+		Rates:          store.Rates,
+		Exports:        store.Exports,
+		ImportMappings: store.ImportMappings,
+		Imports:        store.Imports,
+		Logins:         store.Logins,
+		Audit:          store.Audit,
+		Currency:       currency,
+		// one pending wake-up is enough for any number of requests
+		exportRequests: make(chan struct{}, 1),
+	}
+}
+
+// baseCurrency returns the currency amounts are converted into for a user
+func (h *Handler) baseCurrency(user *model.UserData) string {
+	if user.BaseCurrency != "" {
+		return user.BaseCurrency
+	}
+	return h.Currency
+}
+
+// convertExpenses fills in the amount of each expense in the user's base
+// currency, expenses without a known rate are left unconverted
+func (h *Handler) convertExpenses(user *model.UserData, expenses []model.ExpenseData) error {
+	converter := model.NewConverter(h.Rates)
+	for i := range expenses {
+		converted, err := converter.Convert(expenses[i].Amount, expenses[i].Currency, h.baseCurrency(user), expenses[i].Date)
+		if errors.Is(err, model.ErrNoRate) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		expenses[i].Converted = converted
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestHandler returns a handler backed by a memory store, access tokens
+// are signed with an HMAC secret
+func newTestHandler() (*Handler, *model.Store) {
+	store := model.NewMemoryStore()
+	utils.SetKeySet(&utils.KeySet{Keys: map[string]*utils.JWTKey{}, Legacy: []byte("secret")})
+	utils.SetRevocationList(store.Tokens)
+	return NewHandler(store, "USD"), store
+}
+
+// createUser stores an active user
+func createUser(t *testing.T, store *model.Store, user model.UserData) *model.UserData {
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
+	if err := store.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
+
+// login starts a session of the user and returns its tokens
+func login(t *testing.T, h *Handler, user *model.UserData) TokenResponse {
+	w := httptest.NewRecorder()
+	h.issueTokens(w, user, model.NewTokenId(), nil, "Login successful")
+	var tokens TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	return tokens
+}
+
+// serve sends a request with the token as Bearer token, if any
+func serve(handler http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,483 @@
+package controller
+
+import (
+	"bufio"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ImportDetails struct to represent an import and its rows in the API
+type ImportDetails struct {
+	model.Import
+	Rows []model.ImportRow `json:"rows"`
+}
+
+// @Tags Import
+// @Summary Get all import mappings
+// @Description Retrieve the saved CSV column mappings of the current user, by name
+// @Accept  json
+// @Produce json
+// @Success 200 {array} model.ImportMapping "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/mappings [get]
+func (h *Handler) GetImportMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.ImportMappings.List(currentUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, mappings)
+}
+
+// @Tags Import
+// @Summary Get an import mapping
+// @Description Retrieve a saved CSV column mapping by its ID
+// @Accept  json
+// @Produce json
+// @Param id path string true "Mapping ID"
+// @Success 200 {object} model.ImportMapping "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Mapping not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/mappings/{id} [get]
+func (h *Handler) GetImportMapping(w http.ResponseWriter, r *http.Request) {
+	mapping, ok := h.ownedMapping(w, mux.Vars(r)["id"], currentUserId(r))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, mapping)
+}
+
+// @Tags Import
+// @Summary Create an import mapping
+// @Description Save how the columns of a bank's CSV statements map onto expenses. Columns are named by their header, or by their position from 1 in files without one. Amounts are negative for expenses with the negative sign convention, positive with the positive one, or split in debit and credit columns with debit-credit. Credits are skipped on import.
+// @Accept  json
+// @Produce json
+// @Param Mapping body model.ImportMapping true "Mapping data"
+// @Success 201 {object} model.ImportMapping "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/mappings [post]
+func (h *Handler) CreateImportMapping(w http.ResponseWriter, r *http.Request) {
+	mapping := &model.ImportMapping{}
+	utils.ParseBody(r, mapping)
+	mapping.ID, mapping.UserId = 0, currentUserId(r)
+	if err := mapping.Normalize(); err != nil {
+		http.Error(w, jsonMessage(capitalize(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if err := h.ImportMappings.Create(mapping); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mapping)
+}
+
+// @Tags Import
+// @Summary Replace an import mapping
+// @Description Replace all the settings of a saved CSV column mapping, imports already made with it are not touched
+// @Accept  json
+// @Produce json
+// @Param id path string true "Mapping ID"
+// @Param Mapping body model.ImportMapping true "Mapping data"
+// @Success 202 {object} model.ImportMapping "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Mapping not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/mappings/{id} [put]
+func (h *Handler) UpdateImportMapping(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.ownedMapping(w, mux.Vars(r)["id"], currentUserId(r))
+	if !ok {
+		return
+	}
+	mapping := &model.ImportMapping{}
+	utils.ParseBody(r, mapping)
+	mapping.ID, mapping.UserId, mapping.CreatedAt = existing.ID, existing.UserId, existing.CreatedAt
+	if err := mapping.Normalize(); err != nil {
+		http.Error(w, jsonMessage(capitalize(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if err := h.ImportMappings.Update(mapping); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, mapping)
+}
+
+// @Tags Import
+// @Summary Delete an import mapping
+// @Description Delete a saved CSV column mapping, imports already made with it are not touched
+// @Accept  json
+// @Produce json
+// @Param id path string true "Mapping ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Mapping not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/mappings/{id} [delete]
+func (h *Handler) DeleteImportMapping(w http.ResponseWriter, r *http.Request) {
+	mapping, ok := h.ownedMapping(w, mux.Vars(r)["id"], currentUserId(r))
+	if !ok {
+		return
+	}
+	if err := h.ImportMappings.Delete(int64(mapping.ID)); err != nil {
+		http.Error(w, `{"message": "Failed to delete mapping"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Import
+// @Summary Upload a statement
+// @Description Read a CSV statement with a saved mapping, an OFX 1.x or 2.x statement or a QIF file into a preview of the expenses it holds. Each row is valid, skipped (credits, zero amounts and OFX transactions already imported) or invalid with a message telling why. Nothing is created until the preview is committed; with dryRun the preview is not even stored. Files are limited to 5 MB and 10000 rows.
+// @Accept  text/csv
+// @Accept  application/x-ofx
+// @Accept  application/qif
+// @Produce json
+// @Param file body string true "Statement file"
+// @Param format query string false "Format of the file, detected from its contents by default" Enums(csv, ofx, qif)
+// @Param mapping query int false "Mapping ID, required for CSV files"
+// @Param category query string false "Name of the category of the expenses, the category of the mapping or Others by default"
+// @Param currency query string false "Currency of the amounts when the file does not give it, the currency of the mapping or the user's base currency by default"
+// @Param dateFormat query string false "Date format of a QIF file, MM/DD/YYYY by default"
+// @Param dryRun query bool false "Only show the rows, without storing the preview"
+// @Success 200 {object} ImportDetails "Dry run"
+// @Success 201 {object} ImportDetails "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Mapping not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports [post]
+func (h *Handler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+	params := r.URL.Query()
+	body := bufio.NewReader(r.Body)
+
+	format := strings.ToLower(params.Get("format"))
+	if format == "" {
+		start, _ := body.Peek(512)
+		format = model.DetectImportFormat(start)
+	}
+	var mapping *model.ImportMapping
+	categoryName, currency := "Others", h.baseCurrency(user)
+	switch format {
+	case model.ImportCSV:
+		if params.Get("mapping") == "" {
+			http.Error(w, `{"message": "The mapping query parameter is required for CSV files"}`, http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		if mapping, ok = h.ownedMapping(w, params.Get("mapping"), userId); !ok {
+			return
+		}
+		categoryName = mapping.Category
+		if mapping.Currency != "" {
+			currency = mapping.Currency
+		}
+	case model.ImportOFX, model.ImportQIF:
+	default:
+		http.Error(w, `{"message": "The format must be csv, ofx or qif"}`, http.StatusBadRequest)
+		return
+	}
+	if code := strings.ToUpper(params.Get("currency")); code != "" {
+		if !model.ValidCurrency(code) {
+			http.Error(w, `{"message": "The currency must be an ISO 4217 code such as USD"}`, http.StatusBadRequest)
+			return
+		}
+		currency = code
+	}
+	dateFormat := params.Get("dateFormat")
+	if !model.ValidDateFormat(dateFormat) {
+		http.Error(w, jsonMessage("The date format must be one of "+strings.Join(model.DateFormatNames(), ", ")), http.StatusBadRequest)
+		return
+	}
+	dryRun, err := strconv.ParseBool(params.Get("dryRun"))
+	if err != nil && params.Get("dryRun") != "" {
+		http.Error(w, `{"message": "dryRun must be true or false"}`, http.StatusBadRequest)
+		return
+	}
+	if params.Get("category") != "" {
+		categoryName = params.Get("category")
+	}
+	category, err := h.resolveCategory(userId, 0, categoryName)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, jsonMessage(fmt.Sprintf("Category %q not found", categoryName)), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var rows []model.ImportRow
+	switch format {
+	case model.ImportOFX:
+		rows, err = model.ParseOFX(body, currency)
+	case model.ImportQIF:
+		rows, err = model.ParseQIF(body, dateFormat, currency)
+	default:
+		rows, err = model.ParseCSV(body, mapping, currency)
+	}
+	if err != nil {
+		http.Error(w, jsonMessage(capitalize(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if err := h.skipImported(userId, rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	imp := &model.Import{
+		UserId:     userId,
+		Format:     format,
+		CategoryId: category.ID,
+		Category:   category.Name,
+		Status:     model.ImportPreview,
+	}
+	if mapping != nil {
+		imp.MappingId = &mapping.ID
+	}
+	imp.Count(rows)
+	if dryRun {
+		writeJSON(w, http.StatusOK, ImportDetails{Import: *imp, Rows: rows})
+		return
+	}
+	if err := h.Imports.Create(imp, rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/imports/%d", imp.ID))
+	writeJSON(w, http.StatusCreated, ImportDetails{Import: *imp, Rows: rows})
+}
+
+// @Tags Import
+// @Summary Get all imports
+// @Description Retrieve the imports of the current user, newest first, without their rows
+// @Accept  json
+// @Produce json
+// @Success 200 {array} model.Import "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports [get]
+func (h *Handler) GetImports(w http.ResponseWriter, r *http.Request) {
+	imports, err := h.Imports.List(currentUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, imports)
+}
+
+// @Tags Import
+// @Summary Get an import
+// @Description Retrieve an import with its rows
+// @Accept  json
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} ImportDetails "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Import not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/{id} [get]
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	rows, err := h.Imports.Rows(imp.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, ImportDetails{Import: *imp, Rows: rows})
+}
+
+// @Tags Import
+// @Summary Commit an import
+// @Description Create an expense from every valid row of a preview, all together in one transaction. The expenses carry the ID of the import, which rolls them back. Transactions imported since the preview was made are skipped.
+// @Accept  json
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} model.Import "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Import not found"
+// @Failure 409 {string} string "Import not a preview"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/{id}/commit [post]
+func (h *Handler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	if imp.Status != model.ImportPreview {
+		http.Error(w, `{"message": "Only a preview can be committed"}`, http.StatusConflict)
+		return
+	}
+	rows, err := h.Imports.Rows(imp.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// another import of the same transactions may have been committed since
+	if err := h.skipImported(imp.UserId, rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	expenses := []model.ExpenseData{}
+	for _, row := range rows {
+		if row.Status == model.RowValid {
+			expenses = append(expenses, row.Expense(imp))
+		}
+	}
+	err = h.Imports.Commit(imp, expenses)
+	if errors.Is(err, model.ErrImportState) {
+		http.Error(w, `{"message": "Only a preview can be committed"}`, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, imp)
+}
+
+// @Tags Import
+// @Summary Roll back an import
+// @Description Delete all the expenses a committed import created, including those edited since
+// @Accept  json
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} model.Import "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Import not found"
+// @Failure 409 {string} string "Import not committed"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/{id}/rollback [post]
+func (h *Handler) RollbackImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	err := h.Imports.Rollback(imp)
+	if errors.Is(err, model.ErrImportState) {
+		http.Error(w, `{"message": "Only a committed import can be rolled back"}`, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, imp)
+}
+
+// @Tags Import
+// @Summary Delete an import
+// @Description Delete a preview or a rolled back import with its rows, a committed import has to be rolled back first
+// @Accept  json
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Import not found"
+// @Failure 409 {string} string "Import committed"
+// @Failure 500 {string} string "Internal server error"
+// @Router /imports/{id} [delete]
+func (h *Handler) DeleteImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	err := h.Imports.Delete(int64(imp.ID))
+	if errors.Is(err, model.ErrImportState) {
+		http.Error(w, `{"message": "Roll the import back before deleting it"}`, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"message": "Failed to delete import"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// skipImported skips the valid rows of the transactions the user already
+// imported, as told by their external ID
+func (h *Handler) skipImported(userId int64, rows []model.ImportRow) error {
+	var ids []string
+	for _, row := range rows {
+		if row.Status == model.RowValid && row.ExternalId != "" {
+			ids = append(ids, row.ExternalId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	imported, err := h.Imports.Imported(userId, ids)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if rows[i].Status == model.RowValid && imported[rows[i].ExternalId] {
+			rows[i].Status, rows[i].Message = model.RowSkipped, "Already imported"
+		}
+	}
+	return nil
+}
+
+// ownedMapping loads the user's mapping and writes a JSON error when it
+// cannot
+func (h *Handler) ownedMapping(w http.ResponseWriter, id string, userId int64) (*model.ImportMapping, bool) {
+	ID, err := strconv.ParseInt(id, 0, 0)
+	if err != nil {
+		http.Error(w, `{"message": "Invalid mapping ID"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	mapping, err := h.ImportMappings.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) || (err == nil && mapping.UserId != userId) {
+		http.Error(w, `{"message": "Mapping not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return mapping, true
+}
+
+// ownedImport loads the current user's import of the path and writes a JSON
+// error when it cannot
+func (h *Handler) ownedImport(w http.ResponseWriter, r *http.Request) (*model.Import, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, `{"message": "Invalid import ID"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	imp, err := h.Imports.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) || (err == nil && imp.UserId != currentUserId(r)) {
+		http.Error(w, `{"message": "Import not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return imp, true
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// importResponse is an import as the API returns it, with the status of
+// each row
+type importResponse struct {
+	model.Import
+	Rows []struct {
+		Line    int    `json:"line"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"rows"`
+}
+
+func importRouter(h *Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/imports/mappings", h.CreateImportMapping).Methods("POST")
+	router.HandleFunc("/imports/mappings/{id}", h.GetImportMapping).Methods("GET")
+	router.HandleFunc("/imports/mappings/{id}", h.UpdateImportMapping).Methods("PUT")
+	router.HandleFunc("/imports/mappings/{id}", h.DeleteImportMapping).Methods("DELETE")
+	router.HandleFunc("/imports", h.CreateImport).Methods("POST")
+	router.HandleFunc("/imports", h.GetImports).Methods("GET")
+	router.HandleFunc("/imports/{id}", h.GetImport).Methods("GET")
+	router.HandleFunc("/imports/{id}", h.DeleteImport).Methods("DELETE")
+	router.HandleFunc("/imports/{id}/commit", h.CommitImport).Methods("POST")
+	router.HandleFunc("/imports/{id}/rollback", h.RollbackImport).Methods("POST")
+	return router
+}
+
+// postImport uploads a statement and returns the import it gives
+func postImport(t *testing.T, router *mux.Router, token, query, file string, want int) importResponse {
+	t.Helper()
+	w := serve(router, "POST", "/imports"+query, token, file)
+	var imp importResponse
+	if w.Code != want {
+		t.Fatalf("POST /imports%s: got %d %s, want %d", query, w.Code, w.Body, want)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &imp); err != nil {
+		t.Fatal(err)
+	}
+	return imp
+}
+
+// changeImport commits or rolls back an import and returns it
+func changeImport(t *testing.T, router *mux.Router, token string, id uint, action string, want int) model.Import {
+	t.Helper()
+	w := serve(router, "POST", fmt.Sprintf("/imports/%d/%s", id, action), token, "")
+	var imp model.Import
+	if w.Code != want {
+		t.Fatalf("%s import %d: got %d %s, want %d", action, id, w.Code, w.Body, want)
+	}
+	if want == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &imp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return imp
+}
+
+// userExpenses returns the expenses of the user
+func userExpenses(t *testing.T, store *model.Store, user *model.UserData) []model.ExpenseData {
+	page, err := store.Expenses.List(model.ExpenseQuery{ExpenseFilter: model.ExpenseFilter{UserId: int64(user.ID)}, Sort: model.SortById, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Expenses
+}
+
+func TestCSVImport(t *testing.T) {
+	h, store := newTestHandler()
+	router := importRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	for _, name := range []string{"Others", "Groceries"} {
+		if err := store.Categories.Create(&model.Category{UserId: int64(user.ID), Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session, otherSession := login(t, h, user).Token, login(t, h, other).Token
+
+	if w := serve(router, "POST", "/imports/mappings", session, `{"name": "Bank", "dateColumn": "Date"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid mapping: got %d %s, want 400", w.Code, w.Body)
+	}
+	w := serve(router, "POST", "/imports/mappings", session, `{"name": "Bank", "delimiter": ";", "dateColumn": "Date",
+		"descriptionColumn": "Description", "amountColumn": "Amount", "decimalSeparator": ",", "dateFormat": "DD.MM.YYYY",
+		"category": "Groceries", "currency": "eur"}`)
+	var mapping model.ImportMapping
+	if err := json.Unmarshal(w.Body.Bytes(), &mapping); err != nil || w.Code != http.StatusCreated || mapping.Currency != "EUR" {
+		t.Fatalf("create mapping: got %d %s", w.Code, w.Body)
+	}
+	// the mapping is saved for the user only
+	path := fmt.Sprintf("/imports/mappings/%d", mapping.ID)
+	if w := serve(router, "GET", path, session, ""); w.Code != http.StatusOK {
+		t.Errorf("get mapping: got %d %s", w.Code, w.Body)
+	}
+	if w := serve(router, "GET", path, otherSession, ""); w.Code != http.StatusNotFound {
+		t.Errorf("mapping of another user: got %d, want 404", w.Code)
+	}
+
+	file := "Date;Description;Amount\n" +
+		"02.09.2026;Bakery;-4,50\n" +
+		"05.09.2026;Salary;2.500,00\n" +
+		"10.09.2026;Market;-32,10\n" +
+		"31.09.2026;Bad date;-1,00\n"
+	query := fmt.Sprintf("?mapping=%d", mapping.ID)
+	if w := serve(router, "POST", "/imports", session, file); w.Code != http.StatusBadRequest {
+		t.Errorf("CSV without a mapping: got %d %s, want 400", w.Code, w.Body)
+	}
+	if w := serve(router, "POST", "/imports"+query, otherSession, file); w.Code != http.StatusNotFound {
+		t.Errorf("mapping of another user: got %d, want 404", w.Code)
+	}
+
+	// a dry run only shows the rows
+	dryRun := postImport(t, router, session, query+"&dryRun=true", file, http.StatusOK)
+	if dryRun.ID != 0 || dryRun.Valid != 2 || dryRun.Skipped != 1 || dryRun.Invalid != 1 || len(dryRun.Rows) != 4 {
+		t.Errorf("dry run: got %+v", dryRun)
+	}
+	if imports, _ := store.Imports.List(int64(user.ID)); len(imports) != 0 {
+		t.Errorf("imports after a dry run: got %+v", imports)
+	}
+
+	preview := postImport(t, router, session, query, file, http.StatusCreated)
+	if preview.Status != model.ImportPreview || preview.Category != "Groceries" || *preview.MappingId != mapping.ID || preview.Valid != 2 {
+		t.Errorf("preview: got %+v", preview.Import)
+	}
+	if len(userExpenses(t, store, user)) != 0 {
+		t.Error("the preview created expenses")
+	}
+	w = serve(router, "GET", fmt.Sprintf("/imports/%d", preview.ID), session, "")
+	var stored importResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &stored); err != nil || len(stored.Rows) != 4 || stored.Rows[3].Message == "" {
+		t.Errorf("stored preview: got %d %s", w.Code, w.Body)
+	}
+	if w := serve(router, "GET", fmt.Sprintf("/imports/%d", preview.ID), otherSession, ""); w.Code != http.StatusNotFound {
+		t.Errorf("import of another user: got %d, want 404", w.Code)
+	}
+	changeImport(t, router, otherSession, preview.ID, "commit", http.StatusNotFound)
+
+	committed := changeImport(t, router, session, preview.ID, "commit", http.StatusOK)
+	if committed.Status != model.ImportCommitted || committed.Created != 2 || committed.CommittedAt == nil {
+		t.Errorf("commit: got %+v", committed)
+	}
+	expenses := userExpenses(t, store, user)
+	if len(expenses) != 2 {
+		t.Fatalf("expenses after the commit: got %+v", expenses)
+	}
+	for _, e := range expenses {
+		if e.Category != "Groceries" || e.Currency != "EUR" || e.ImportId == nil || *e.ImportId != preview.ID {
+			t.Errorf("imported expense: got %+v", e)
+		}
+	}
+	if expenses[0].Title != "Bakery" || expenses[0].Amount != 450 || expenses[1].Amount != 3210 {
+		t.Errorf("imported expenses: got %+v", expenses)
+	}
+	changeImport(t, router, session, preview.ID, "commit", http.StatusConflict)
+	if w := serve(router, "DELETE", fmt.Sprintf("/imports/%d", preview.ID), session, ""); w.Code != http.StatusConflict {
+		t.Errorf("deleting a committed import: got %d, want 409", w.Code)
+	}
+
+	// rolling back deletes the expenses, edited ones included
+	expenses[0].Title = "Bakery and coffee"
+	if err := store.Expenses.Update(&expenses[0]); err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack := changeImport(t, router, session, preview.ID, "rollback", http.StatusOK); rolledBack.Status != model.ImportRolledBack {
+		t.Errorf("rollback: got %+v", rolledBack)
+	}
+	if expenses := userExpenses(t, store, user); len(expenses) != 0 {
+		t.Errorf("expenses after the rollback: got %+v", expenses)
+	}
+	changeImport(t, router, session, preview.ID, "rollback", http.StatusConflict)
+	if w := serve(router, "DELETE", fmt.Sprintf("/imports/%d", preview.ID), session, ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: got %d %s, want 204", w.Code, w.Body)
+	}
+
+	// changing the mapping changes the next imports
+	w = serve(router, "PUT", path, session, `{"name": "Bank", "delimiter": ";", "dateColumn": "Date",
+		"descriptionColumn": "Description", "amountColumn": "Amount", "decimalSeparator": ",", "dateFormat": "DD.MM.YYYY"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("update mapping: got %d %s", w.Code, w.Body)
+	}
+	if imp := postImport(t, router, session, query+"&dryRun=true&currency=chf", file, http.StatusOK); imp.Category != "Others" {
+		t.Errorf("import with the updated mapping: got category %q, want Others", imp.Category)
+	}
+	if w := serve(router, "DELETE", path, session, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete mapping: got %d %s", w.Code, w.Body)
+	}
+	if w := serve(router, "POST", "/imports"+query, session, file); w.Code != http.StatusNotFound {
+		t.Errorf("import with a deleted mapping: got %d, want 404", w.Code)
+	}
+}
+
+func TestImportDuplicates(t *testing.T) {
+	h, store := newTestHandler()
+	router := importRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	if err := store.Categories.Create(&model.Category{UserId: int64(user.ID), Name: "Others"}); err != nil {
+		t.Fatal(err)
+	}
+	session := login(t, h, user).Token
+	data, err := os.ReadFile("../model/testdata/checking-v1.ofx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := string(data)
+
+	// the statement repeats a transaction, which is skipped
+	first := postImport(t, router, session, "", file, http.StatusCreated)
+	if first.Format != model.ImportOFX || first.Valid != 3 || first.Skipped != 2 || first.Invalid != 1 {
+		t.Fatalf("first preview: got %+v", first.Import)
+	}
+	second := postImport(t, router, session, "", file, http.StatusCreated)
+	if second.Valid != 3 {
+		t.Fatalf("second preview: got %+v", second.Import)
+	}
+	if imp := changeImport(t, router, session, first.ID, "commit", http.StatusOK); imp.Created != 3 {
+		t.Errorf("first commit: got %d expenses, want 3", imp.Created)
+	}
+
+	// the transactions are not imported again, by a new preview nor by a
+	// preview made before the commit
+	third := postImport(t, router, session, "", file, http.StatusCreated)
+	if third.Valid != 0 || third.Skipped != 5 {
+		t.Errorf("preview after the commit: got %+v", third.Import)
+	}
+	already := 0
+	for _, row := range third.Rows {
+		if row.Message == "Already imported" {
+			already++
+		}
+	}
+	if already != 3 {
+		t.Errorf("preview after the commit: got %d rows already imported, want 3: %+v", already, third.Rows)
+	}
+	if imp := changeImport(t, router, session, second.ID, "commit", http.StatusOK); imp.Created != 0 {
+		t.Errorf("commit of the earlier preview: got %d expenses, want 0", imp.Created)
+	}
+	if expenses := userExpenses(t, store, user); len(expenses) != 3 {
+		t.Errorf("expenses: got %d, want 3", len(expenses))
+	}
+
+	// once rolled back they can be imported again
+	changeImport(t, router, session, first.ID, "rollback", http.StatusOK)
+	if fourth := postImport(t, router, session, "", file, http.StatusCreated); fourth.Valid != 3 {
+		t.Errorf("preview after the rollback: got %+v", fourth.Import)
+	}
+}
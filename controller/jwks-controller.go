@@ -0,0 +1,15 @@
+package controller
+
+import (
+	"net/http"
+)
+
+// GetJWKS serves the public keys access tokens are verified with as a JSON
+// Web Key Set, for other services to verify the tokens themselves. It is
+// served at /.well-known/jwks.json, outside of the API base path.
+func (h *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	// verifiers may cache the keys, a new key is published well before it
+	// signs anything
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.Keys.PublicKeys())
+}
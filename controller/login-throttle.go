@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"expense-tracker/model"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+)
+
+// dummyPasswordHash is checked against the password of logins with an
+// unknown email, so they take as long as logins with a wrong password
+var dummyPasswordHash, _ = argon2id.CreateHash("no account has this password", argon2id.DefaultParams)
+
+// loginAttempt identifies who tries to log in, failures are counted against
+// both the account and the address
+type loginAttempt struct {
+	ip      string
+	account string
+	address string
+}
+
+func (h *Handler) newLoginAttempt(r *http.Request, email string) loginAttempt {
+	ip := h.clientIP(r)
+	return loginAttempt{ip: ip, account: model.AccountLoginKey(email), address: model.AddressLoginKey(ip)}
+}
+
+// clientIP returns the address of the client, the last address of the
+// X-Forwarded-For header when the API runs behind a trusted proxy
+func (h *Handler) clientIP(r *http.Request) string {
+	if h.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addresses := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addresses[len(addresses)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// throttled answers the attempt with 429 when its account or address must
+// wait before trying again, and tells whether it did
+func (h *Handler) throttled(w http.ResponseWriter, attempt loginAttempt) bool {
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, key := range []string{attempt.account, attempt.address} {
+		d, err := h.Logins.Wait(key, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return true
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	if wait == 0 {
+		return false
+	}
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	after := fmt.Sprintf("%d seconds", seconds)
+	if seconds == 1 {
+		after = "1 second"
+	}
+	http.Error(w, jsonMessage("Too many failed login attempts, please try again in "+after), http.StatusTooManyRequests)
+	return true
+}
+
+// loginFailed counts a failed attempt against its account and address and
+// audits the lockouts it causes, user is nil when the email is unknown
+func (h *Handler) loginFailed(attempt loginAttempt, user *model.UserData) error {
+	now := time.Now().UTC()
+	for _, limit := range []struct {
+		key    string
+		policy model.LoginPolicy
+		userId *int64
+		name   string
+	}{
+		{attempt.account, model.AccountLoginPolicy, userIdOf(user), "Account"},
+		{attempt.address, model.AddressLoginPolicy, nil, "Address"},
+	} {
+		throttle, locked, err := h.Logins.Fail(limit.key, limit.policy, now)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			continue
+		}
+		detail := fmt.Sprintf("%s %s locked for %s after %d failed logins", limit.name, strings.SplitN(limit.key, ":", 2)[1], limit.policy.LockoutDuration, throttle.Failures)
+		log.Print(detail)
+		entry := &model.AuditEntry{UserId: limit.userId, Action: model.AuditLoginLockout, IP: attempt.ip, Detail: detail, CreatedAt: now}
+		if err := h.Audit.Record(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loginSucceeded forgets the failures of the account, those of the address
+// expire on their own so one valid account cannot clear them
+func (h *Handler) loginSucceeded(attempt loginAttempt) {
+	if err := h.Logins.Reset(attempt.account); err != nil {
+		log.Printf("Login throttle of %s: %v", attempt.account, err)
+	}
+}
+
+func userIdOf(user *model.UserData) *int64 {
+	if user == nil {
+		return nil
+	}
+	id := int64(user.ID)
+	return &id
+}
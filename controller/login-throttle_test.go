@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/gorilla/mux"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	h, store := newTestHandler()
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	h.Public(router.HandleFunc("/auth/login", h.LoginUser).Methods("POST"))
+	hash, err := argon2id.CreateHash("secret password", argon2id.DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createUser(t, store, model.UserData{Email: "jane@example.com", Password: hash})
+
+	loginFrom := func(ip, email, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(Login{Email: email, Password: password})
+		r := httptest.NewRequest("POST", "/auth/login", strings.NewReader(string(body)))
+		r.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// the free attempts of an account, a successful login forgets them
+	for i := 0; i < model.AccountLoginPolicy.FreeAttempts; i++ {
+		if code := loginFrom("192.0.2.1", "jane@example.com", "wrong").Code; code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := loginFrom("192.0.2.1", "jane@example.com", "secret password").Code; code != http.StatusOK {
+		t.Fatalf("login: got %d, want 200", code)
+	}
+	for i := 0; i <= model.AccountLoginPolicy.FreeAttempts; i++ {
+		// from another address each time, to count against the account only
+		if code := loginFrom(fmt.Sprintf("192.0.2.%d", 10+i), "jane@example.com", "wrong").Code; code != http.StatusUnauthorized {
+			t.Fatalf("failure %d after the login: got %d, want 401", i+1, code)
+		}
+	}
+	// one failure past the free attempts makes the account wait, even with
+	// the right password and from a new address
+	w := loginFrom("192.0.2.99", "jane@example.com", "secret password")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("account throttled: got %d, Retry-After %q, want 429 after 1 second", w.Code, w.Header().Get("Retry-After"))
+	}
+	// other accounts are not affected
+	if code := loginFrom("192.0.2.99", "john@example.com", "wrong").Code; code != http.StatusUnauthorized {
+		t.Errorf("other account: got %d, want 401", code)
+	}
+
+	// an address is counted across every email it tries, registered or not
+	for i := 0; i <= model.AddressLoginPolicy.FreeAttempts; i++ {
+		if code := loginFrom("198.51.100.1", fmt.Sprintf("user%d@example.com", i), "wrong").Code; code != http.StatusUnauthorized {
+			t.Fatalf("address failure %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := loginFrom("198.51.100.1", "someone@example.com", "wrong").Code; code != http.StatusTooManyRequests {
+		t.Errorf("address throttled: got %d, want 429", code)
+	}
+	if code := loginFrom("198.51.100.2", "someone@example.com", "wrong").Code; code != http.StatusUnauthorized {
+		t.Errorf("other address: got %d, want 401", code)
+	}
+
+	// the failure reaching the lockout is audited against the account
+	key := model.AccountLoginKey("jane@example.com")
+	if err := store.Logins.Reset(key); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < model.AccountLoginPolicy.LockoutAfter; i++ {
+		if _, _, err := store.Logins.Fail(key, model.AccountLoginPolicy, time.Now().UTC().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := loginFrom("203.0.113.1", "jane@example.com", "wrong").Code; code != http.StatusUnauthorized {
+		t.Fatalf("locking failure: got %d, want 401", code)
+	}
+	w = loginFrom("203.0.113.2", "jane@example.com", "secret password")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != fmt.Sprint(int(model.AccountLoginPolicy.LockoutDuration.Seconds())) {
+		t.Errorf("locked account: got %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	page, err := store.Audit.List(model.AuditQuery{Action: model.AuditLoginLockout, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Entries[0].UserId == nil || *page.Entries[0].UserId != int64(user.ID) || page.Entries[0].IP != "203.0.113.1" {
+		t.Errorf("lockout audit: got %+v", page.Entries)
+	}
+}
@@ -0,0 +1,415 @@
+package controller
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// mfaIssuer names the account in authenticator apps
+const mfaIssuer = "Expense Tracker"
+
+// MFAStatus struct to represent whether two-factor authentication is enabled
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// RecoveryCodesLeft is the number of unused recovery codes
+	RecoveryCodesLeft int `json:"recoveryCodesLeft"`
+}
+
+// MFAEnrollment struct to represent the secret to add to an authenticator app
+type MFAEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	// OtpauthURI is shown as a QR code for authenticator apps to scan
+	OtpauthURI string `json:"otpauthUri" example:"otpauth://totp/Expense%20Tracker:jane@example.com?algorithm=SHA1&digits=6&issuer=Expense%20Tracker&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// MFACode struct to represent a code from the authenticator app or a recovery code
+type MFACode struct {
+	Code string `json:"code" example:"123456"`
+}
+
+// RecoveryCodes struct to represent the recovery codes, shown only once
+type RecoveryCodes struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recoveryCodes" example:"abcde-fghij"`
+}
+
+// MFAChallengeResponse struct to represent a login waiting for its second factor
+type MFAChallengeResponse struct {
+	Message     string `json:"message" example:"Two-factor authentication required"`
+	MFARequired bool   `json:"mfaRequired" example:"true"`
+	// Challenge is sent to /auth/mfa/verify with the code
+	Challenge string `json:"challenge"`
+	// ExpiresIn is the lifetime of the challenge in seconds
+	ExpiresIn int64 `json:"expiresIn" example:"300"`
+}
+
+// MFAVerifyRequest struct to represent the second factor of a login
+type MFAVerifyRequest struct {
+	Challenge string `json:"challenge"`
+	// Code is a code from the authenticator app or a recovery code
+	Code string `json:"code" example:"123456"`
+}
+
+// @Tags Auth
+// @Summary Get the two-factor authentication status
+// @Description Tell whether two-factor authentication is enabled for the current user and how many recovery codes are left
+// @Accept  json
+// @Produce json
+// @Success 200 {object} MFAStatus "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa [get]
+func (h *Handler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	status := MFAStatus{}
+	settings, err := h.MFA.FindSettings(userId)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil && settings.Enabled() {
+		status.Enabled = true
+		if status.RecoveryCodesLeft, err = h.MFA.CountRecoveryCodes(userId); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// @Tags Auth
+// @Summary Start enrolling an authenticator
+// @Description Generate a TOTP secret for the current user. Two-factor authentication is enabled once a code of the secret is sent to /auth/mfa/confirm, starting over replaces the secret.
+// @Accept  json
+// @Produce json
+// @Success 200 {object} MFAEnrollment "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Already enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa/enroll [post]
+func (h *Handler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	settings, err := h.MFA.FindSettings(userId)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil && settings.Enabled() {
+		http.Error(w, `{"message": "Two-factor authentication is already enabled"}`, http.StatusConflict)
+		return
+	}
+
+	settings = &model.MFASettings{UserId: userId, Secret: model.NewTOTPSecret()}
+	if err := h.MFA.SaveSettings(settings); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, MFAEnrollment{
+		Secret:     settings.Secret,
+		OtpauthURI: model.TOTPURI(mfaIssuer, user.Email, settings.Secret),
+	})
+}
+
+// @Tags Auth
+// @Summary Confirm the authenticator
+// @Description Enable two-factor authentication with a code from the authenticator app just enrolled. The response holds the recovery codes, which are not shown again.
+// @Accept  json
+// @Produce json
+// @Param code body MFACode true "Code from the authenticator app"
+// @Success 200 {object} RecoveryCodes "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Already enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa/confirm [post]
+func (h *Handler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+	body := &MFACode{}
+	utils.ParseBody(r, body)
+
+	settings, err := h.MFA.FindSettings(userId)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Start the enrollment at /auth/mfa/enroll first"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if settings.Enabled() {
+		http.Error(w, `{"message": "Two-factor authentication is already enabled"}`, http.StatusConflict)
+		return
+	}
+
+	// only the authenticator proves the secret was added to it
+	step := model.MatchTOTP(settings.Secret, normalizeCode(body.Code), time.Now())
+	if step == 0 {
+		http.Error(w, `{"message": "Invalid code"}`, http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	settings.ConfirmedAt = &now
+	settings.LastStep = step
+	if err := h.MFA.SaveSettings(settings); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeRecoveryCodes(w, userId, "Two-factor authentication enabled, keep the recovery codes in a safe place")
+}
+
+// @Tags Auth
+// @Summary Regenerate the recovery codes
+// @Description Replace the recovery codes of the current user with new ones, given a current code or recovery code. The new codes are not shown again. Wrong codes count as failed logins.
+// @Accept  json
+// @Produce json
+// @Param code body MFACode true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} RecoveryCodes "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {string} string "Too many failed attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.requireMFACode(w, r)
+	if !ok {
+		return
+	}
+	h.writeRecoveryCodes(w, userId, "Recovery codes replaced, keep them in a safe place")
+}
+
+// @Tags Auth
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication for the current user, given a current code or recovery code. Wrong codes count as failed logins.
+// @Accept  json
+// @Produce json
+// @Param code body MFACode true "Code from the authenticator app or a recovery code"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {string} string "Too many failed attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa/disable [post]
+func (h *Handler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.requireMFACode(w, r)
+	if !ok {
+		return
+	}
+	if err := h.MFA.DeleteSettings(userId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Auth
+// @Summary Complete a login with the second factor
+// @Description Exchange the challenge returned by /auth/login and a code from the authenticator app, or a recovery code, for the tokens. A challenge expires after 5 minutes or 5 wrong codes, wrong codes also count as failed logins.
+// @Accept  json
+// @Produce json
+// @Param verify body MFAVerifyRequest true "Challenge and code"
+// @Success 200 {object} TokenResponse "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Email not verified, account disabled or scheduled for deletion"
+// @Failure 429 {string} string "Too many failed attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /auth/mfa/verify [post]
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	body := &MFAVerifyRequest{}
+	utils.ParseBody(r, body)
+	if body.Challenge == "" || body.Code == "" {
+		http.Error(w, `{"message": "The challenge and code are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := h.MFA.FindChallenge(model.HashToken(body.Challenge))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The login has expired, please log in again"}`, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	settings, err := h.MFA.FindSettings(challenge.UserId)
+	if errors.Is(err, model.ErrNotFound) || (err == nil && !settings.Enabled()) {
+		http.Error(w, `{"message": "The login has expired, please log in again"}`, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.Users.FindById(challenge.UserId)
+	if err != nil {
+		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	// wrong codes count as failed logins, otherwise every new challenge
+	// would allow another round of guesses
+	attempt := h.newLoginAttempt(r, user.Email)
+	if h.throttled(w, attempt) {
+		return
+	}
+
+	valid, err := h.checkMFACode(settings, body.Code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		if err := h.MFA.FailChallenge(challenge.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := h.loginFailed(attempt, user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"message": "Invalid code"}`, http.StatusUnauthorized)
+		return
+	}
+	if err := h.MFA.CompleteChallenge(challenge.ID); errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The login has expired, please log in again"}`, http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user.Disabled() {
+		http.Error(w, `{"message": "This account is disabled"}`, http.StatusForbidden)
+		return
+	}
+	if user.DeletionPending() {
+		http.Error(w, `{"message": "This account is scheduled for deletion, open the link sent by email to restore it"}`, http.StatusForbidden)
+		return
+	}
+	if !h.verified(user) {
+		http.Error(w, `{"message": "Please verify your email before logging in"}`, http.StatusForbidden)
+		return
+	}
+	h.loginSucceeded(attempt)
+	h.issueTokens(w, user, model.NewTokenId(), nil, "Login successful")
+}
+
+// startMFAChallenge answers a login with a valid password by a challenge when
+// the user enabled two-factor authentication, and tells whether it did
+func (h *Handler) startMFAChallenge(w http.ResponseWriter, user *model.UserData) bool {
+	settings, err := h.MFA.FindSettings(int64(user.ID))
+	if errors.Is(err, model.ErrNotFound) || (err == nil && !settings.Enabled()) {
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+
+	raw, challenge := model.NewMFAChallenge(int64(user.ID))
+	if err := h.MFA.CreateChallenge(challenge); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	writeJSON(w, http.StatusAccepted, MFAChallengeResponse{
+		Message:     "Two-factor authentication required",
+		MFARequired: true,
+		Challenge:   raw,
+		ExpiresIn:   int64(model.MFAChallengeTTL / time.Second),
+	})
+	return true
+}
+
+// requireMFACode checks the code in the body against the current user's
+// enabled two-factor authentication and writes the error response if it
+// does not match
+func (h *Handler) requireMFACode(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+	body := &MFACode{}
+	utils.ParseBody(r, body)
+	if body.Code == "" {
+		http.Error(w, `{"message": "The code is required"}`, http.StatusBadRequest)
+		return 0, false
+	}
+
+	// a stolen session must not be a way to guess the codes
+	attempt := h.newLoginAttempt(r, user.Email)
+	if h.throttled(w, attempt) {
+		return 0, false
+	}
+
+	settings, err := h.MFA.FindSettings(userId)
+	if errors.Is(err, model.ErrNotFound) || (err == nil && !settings.Enabled()) {
+		http.Error(w, `{"message": "Two-factor authentication is not enabled"}`, http.StatusBadRequest)
+		return 0, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+	valid, err := h.checkMFACode(settings, body.Code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+	if !valid {
+		if err := h.loginFailed(attempt, user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return 0, false
+		}
+		http.Error(w, `{"message": "Invalid code"}`, http.StatusBadRequest)
+		return 0, false
+	}
+	return userId, true
+}
+
+// checkMFACode tells whether the code is a current code of the authenticator
+// or an unused recovery code, either of which is used up by the check
+func (h *Handler) checkMFACode(settings *model.MFASettings, code string) (bool, error) {
+	code = normalizeCode(code)
+	if step := model.MatchTOTP(settings.Secret, code, time.Now()); step != 0 {
+		err := h.MFA.UseStep(settings.UserId, step)
+		if errors.Is(err, model.ErrNotFound) {
+			// the code was already used
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if len(code) == model.TOTPDigits {
+		return false, nil
+	}
+	err := h.MFA.UseRecoveryCode(settings.UserId, model.HashRecoveryCode(code))
+	if errors.Is(err, model.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// writeRecoveryCodes replaces the recovery codes of the user and writes the
+// new ones as the response
+func (h *Handler) writeRecoveryCodes(w http.ResponseWriter, userId int64, message string) {
+	codes := model.NewRecoveryCodes()
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = model.HashRecoveryCode(code)
+	}
+	if err := h.MFA.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, RecoveryCodes{Message: message, RecoveryCodes: codes})
+}
+
+// normalizeCode removes the spaces users may type in a code
+func normalizeCode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
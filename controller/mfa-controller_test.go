@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"expense-tracker/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// enableMFA turns two-factor authentication on for the user and returns the
+// secret
+func enableMFA(t *testing.T, store *model.Store, user *model.UserData) string {
+	now := time.Now()
+	settings := &model.MFASettings{UserId: int64(user.ID), Secret: model.NewTOTPSecret(), ConfirmedAt: &now}
+	if err := store.MFA.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	return settings.Secret
+}
+
+func currentCode(secret string) string {
+	code, _ := model.TOTPCode(secret, model.TOTPStep(time.Now()))
+	return `{"code": "` + code + `"}`
+}
+
+func mfaRouter(h *Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/auth/mfa/recovery-codes", h.RegenerateRecoveryCodes).Methods("POST")
+	router.HandleFunc("/auth/mfa/disable", h.DisableMFA).Methods("POST")
+	return router
+}
+
+func TestRequireMFACode(t *testing.T) {
+	h, store := newTestHandler()
+	router := mfaRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	secret := enableMFA(t, store, user)
+	token := login(t, h, user).Token
+
+	if code := serve(router, "POST", "/auth/mfa/recovery-codes", token, currentCode(secret)).Code; code != http.StatusOK {
+		t.Fatalf("current code: got %d, want 200", code)
+	}
+	// a code is accepted only once
+	if code := serve(router, "POST", "/auth/mfa/recovery-codes", token, currentCode(secret)).Code; code != http.StatusBadRequest {
+		t.Errorf("replayed code: got %d, want 400", code)
+	}
+	if code := serve(router, "POST", "/auth/mfa/disable", token, `{}`).Code; code != http.StatusBadRequest {
+		t.Errorf("missing code: got %d, want 400", code)
+	}
+
+	other := createUser(t, store, model.UserData{Email: "john@example.com"})
+	if code := serve(router, "POST", "/auth/mfa/disable", login(t, h, other).Token, `{"code": "123456"}`).Code; code != http.StatusBadRequest {
+		t.Errorf("not enabled: got %d, want 400", code)
+	}
+}
+
+func TestRequireMFACodeThrottled(t *testing.T) {
+	h, store := newTestHandler()
+	router := mfaRouter(h)
+	user := createUser(t, store, model.UserData{Email: "jane@example.com"})
+	secret := enableMFA(t, store, user)
+	token := login(t, h, user).Token
+
+	// wrong codes count as failed logins of the account, once the free
+	// attempts are used up even a right code has to wait
+	failures := model.AccountLoginPolicy.FreeAttempts + 1
+	for i := 0; i < failures; i++ {
+		path := []string{"/auth/mfa/disable", "/auth/mfa/recovery-codes"}[i%2]
+		if code := serve(router, "POST", path, token, `{"code": "aaaaa-bbbbb"}`).Code; code != http.StatusBadRequest {
+			t.Fatalf("wrong code %d: got %d, want 400", i+1, code)
+		}
+	}
+	w := serve(router, "POST", "/auth/mfa/disable", token, currentCode(secret))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("after %d wrong codes: got %d, want 429 with Retry-After", failures, w.Code)
+	}
+	if _, err := store.MFA.FindSettings(int64(user.ID)); err != nil {
+		t.Errorf("two-factor authentication was disabled: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RateUpload struct to represent a JSON exchange-rate upload in the API
+type RateUpload struct {
+	Base  string            `json:"base" example:"EUR"`
+	Date  string            `json:"date" example:"2026-10-16"`
+	Rates map[string]string `json:"rates"`
+}
+
+// Rate struct to represent an exchange rate lookup in the API
+type Rate struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Date     string `json:"date"`
+	Rate     string `json:"rate"`
+	RateDate string `json:"rateDate"`
+}
+
+// @Tags Rate
+// @Summary Upload exchange rates
+// @Description Store exchange rates, replacing rates already stored for the same day and currencies. Send JSON, a single object or an array of objects, or an ECB reference rate CSV file with Content-Type text/csv. Only admins can upload rates.
+// @Accept  json
+// @Accept  text/csv
+// @Produce json
+// @Param rates body RateUpload true "Exchange rates"
+// @Param base query string false "Base currency of a CSV upload" default(EUR)
+// @Success 201 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal server error"
+// @Router /rates [post]
+func (h *Handler) UploadRates(w http.ResponseWriter, r *http.Request) {
+	var rates []model.ExchangeRate
+	var skipped []string
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		base := strings.ToUpper(r.URL.Query().Get("base"))
+		if base == "" {
+			base = "EUR"
+		}
+		rates, skipped, err = model.ParseRatesCSV(r.Body, base)
+	} else {
+		rates, err = model.ParseRatesJSON(r.Body)
+	}
+	if err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+	if len(rates) == 0 {
+		http.Error(w, `{"message": "No rates found in the upload."}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Rates.Save(rates); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := map[string]interface{}{"message": "Rates saved", "imported": len(rates)}
+	if len(skipped) > 0 {
+		result["skipped"] = skipped
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(res)
+}
+
+// @Tags Rate
+// @Summary Look up an exchange rate
+// @Description Get the rate that applied on a date, which is the latest rate published on or before it. Rates are also derived from their inverse or through a common base currency.
+// @Accept  json
+// @Produce json
+// @Param from query string true "Currency to convert from"
+// @Param to query string false "Currency to convert to, the user's base currency by default"
+// @Param date query string false "Date in YYYY-MM-DD format, today by default"
+// @Success 200 {object} Rate "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /rates [get]
+func (h *Handler) GetRate(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	params := r.URL.Query()
+	from, to := strings.ToUpper(params.Get("from")), strings.ToUpper(params.Get("to"))
+	if to == "" {
+		to = h.baseCurrency(user)
+	}
+	if !model.ValidCurrency(from) || !model.ValidCurrency(to) {
+		http.Error(w, `{"message": "Both from and to must be ISO 4217 currency codes."}`, http.StatusBadRequest)
+		return
+	}
+	date := model.NewDate(time.Now())
+	if v := params.Get("date"); v != "" {
+		parsed, err := model.ParseDate(v, user.DateFormat)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid date format. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	rate, rateDate, err := model.NewConverter(h.Rates).Rate(from, to, date)
+	if errors.Is(err, model.ErrNoRate) {
+		http.Error(w, `{"message": "No exchange rate found for this date."}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(Rate{From: from, To: to, Date: date.String(), Rate: rate, RateDate: rateDate.String()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
@@ -0,0 +1,643 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultOccurrences = 12
+	maxOccurrences     = 100
+)
+
+// Occurrence statuses
+const (
+	OccurrenceScheduled = "scheduled"
+	OccurrenceCreated   = "created"
+	OccurrenceSkipped   = "skipped"
+)
+
+// RecurringExpense struct to represent a recurring expense in the API, dates
+// are given as YYYY-MM-DD or in the user's preferred date format
+type RecurringExpense struct {
+	Title       string      `json:"title" example:"Rent"`
+	Description string      `json:"description"`
+	Amount      json.Number `json:"amount" swaggertype:"number" example:"1200.00"`
+	Currency    string      `json:"currency" example:"USD"`
+	CategoryId  uint        `json:"categoryId"`
+	Category    string      `json:"category" example:"Utilities"`
+	// Rule is an RRULE using FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL
+	Rule string `json:"rule" example:"FREQ=MONTHLY;BYMONTHDAY=1"`
+	// StartDate is the first occurrence, today when omitted
+	StartDate string `json:"startDate" example:"2026-01-01"`
+	// EndDate is the last day occurrences may fall on, an empty string
+	// removes it
+	EndDate *string `json:"endDate" example:"2026-12-31"`
+	// Timezone is an IANA time zone, the user's time zone or UTC when omitted
+	Timezone string `json:"timezone" example:"Africa/Lagos"`
+}
+
+// OccurrenceChange struct to represent the change of a single occurrence in the API
+type OccurrenceChange struct {
+	// Skip drops the occurrence, deleting its expense if already created
+	Skip        bool        `json:"skip"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Amount      json.Number `json:"amount" swaggertype:"number" example:"1250.00"`
+	// Date moves the expense of the occurrence to another day
+	Date string `json:"date" example:"2026-03-02"`
+}
+
+// Occurrence struct to represent an occurrence of a recurring expense in the API
+type Occurrence struct {
+	// Occurrence is the date the rule gives the occurrence
+	Occurrence  model.Date      `json:"occurrence" swaggertype:"string" example:"2026-03-01"`
+	Date        model.Date      `json:"date" swaggertype:"string" example:"2026-03-01"`
+	Status      string          `json:"status" example:"scheduled"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Amount      json.RawMessage `json:"amount" swaggertype:"number"`
+	Currency    string          `json:"currency"`
+	// ExpenseId is the expense created for the occurrence
+	ExpenseId uint `json:"expenseId,omitempty"`
+}
+
+// @Tags Recurring
+// @Summary Get all recurring expenses
+// @Description Retrieve the recurring expenses of the current user
+// @Accept  json
+// @Produce json
+// @Success 200 {array} RecurringExpense "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring [get]
+func (h *Handler) GetRecurringExpenses(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	recurring, err := h.Recurring.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(recurring)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Get a recurring expense
+// @Description Retrieve a recurring expense by its ID
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Success 200 {object} RecurringExpense "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id} [get]
+func (h *Handler) GetRecurringExpenseById(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+	res, err := json.Marshal(recurring)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Create a recurring expense
+// @Description Create a recurring expense, the occurrences due up to today are created straight away and the following ones by the scheduler as their day comes
+// @Accept  json
+// @Produce json
+// @Param RecurringExpense body RecurringExpense true "Recurring expense data"
+// @Success 201 {object} RecurringExpense "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring [post]
+func (h *Handler) CreateRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	body := &RecurringExpense{}
+	utils.ParseBody(r, body)
+	if body.Title == "" || body.Amount == "" || body.Rule == "" {
+		http.Error(w, `{"message":"Title, amount and rule are required."}`, http.StatusBadRequest)
+		return
+	}
+	if body.Currency == "" {
+		body.Currency = h.Currency
+	}
+	if body.Timezone == "" {
+		body.Timezone = user.Timezone
+	}
+	if body.Timezone == "" {
+		body.Timezone = "UTC"
+	}
+
+	recurring := &model.RecurringExpense{UserId: userId, Description: body.Description}
+	if !h.applyRecurring(w, user, recurring, body) {
+		return
+	}
+	recurring.NextDate = recurring.StartDate
+
+	if err := h.Recurring.Create(recurring); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := model.MaterializeRecurring(h.Recurring, recurring, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(recurring)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Update a recurring expense
+// @Description Update a recurring expense, the changes apply to occurrences created from now on
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Param RecurringExpense body RecurringExpense true "Recurring expense data"
+// @Success 202 {object} RecurringExpense "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id} [patch]
+func (h *Handler) UpdateRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	body := &RecurringExpense{}
+	utils.ParseBody(r, body)
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+	if body.Description != "" {
+		recurring.Description = body.Description
+	}
+	if !h.applyRecurring(w, user, recurring, body) {
+		return
+	}
+	// occurrences before a later start date are never created
+	if recurring.StartDate.After(recurring.NextDate.Time) {
+		recurring.NextDate = recurring.StartDate
+	}
+
+	if err := h.Recurring.Update(recurring); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := model.MaterializeRecurring(h.Recurring, recurring, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(recurring)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Delete a recurring expense
+// @Description Delete a recurring expense, the expenses already created from it are kept
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id} [delete]
+func (h *Handler) DeleteRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+	if err := h.Recurring.Delete(int64(recurring.ID)); err != nil {
+		http.Error(w, `{"message": "Failed to delete recurring expense"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Recurring
+// @Summary Get the occurrences of a recurring expense
+// @Description List the occurrences of a recurring expense in a date range, with the changes made to single occurrences and the expenses already created
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Param start_date query string false "Start date in YYYY-MM-DD format, today by default"
+// @Param end_date query string false "End date in YYYY-MM-DD format"
+// @Param limit query int false "Number of occurrences, at most 100" default(12)
+// @Success 200 {array} Occurrence "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id}/occurrences [get]
+func (h *Handler) GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	from, err := recurring.Today(time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if v := params.Get("start_date"); v != "" {
+		if from, err = model.ParseDate(v, user.DateFormat); err != nil {
+			http.Error(w, jsonMessage("Invalid start_date format. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+	}
+	to := model.Date{Time: from.AddDate(10, 0, 0)}
+	if v := params.Get("end_date"); v != "" {
+		if to, err = model.ParseDate(v, user.DateFormat); err != nil {
+			http.Error(w, jsonMessage("Invalid end_date format. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultOccurrences
+	if v := params.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxOccurrences {
+			http.Error(w, jsonMessage("Invalid limit. Use a number between 1 and "+strconv.Itoa(maxOccurrences)+"."), http.StatusBadRequest)
+			return
+		}
+	}
+
+	dates, err := recurring.Occurrences(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(dates) > limit {
+		dates = dates[:limit]
+	}
+	overrides, err := h.Recurring.Overrides(recurring.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	occurrences := []Occurrence{}
+	for _, date := range dates {
+		occurrence, err := h.occurrence(recurring, date, overrides)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	res, err := json.Marshal(occurrences)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Skip or change a single occurrence
+// @Description Skip or change one occurrence of a recurring expense. An occurrence not created yet is created with the change, the expense of an occurrence already created is changed or, when skipped, deleted.
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Param date path string true "Date of the occurrence in YYYY-MM-DD format"
+// @Param OccurrenceChange body OccurrenceChange true "Change of the occurrence"
+// @Success 200 {object} Occurrence "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense or occurrence not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id}/occurrences/{date} [put]
+func (h *Handler) UpdateOccurrence(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	userId := int64(user.ID)
+
+	body := &OccurrenceChange{}
+	utils.ParseBody(r, body)
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+	date, ok := h.occurrenceDate(w, r, recurring)
+	if !ok {
+		return
+	}
+
+	override := &model.RecurringOverride{
+		RecurringId: recurring.ID,
+		Occurrence:  date,
+		Skip:        body.Skip,
+		Title:       body.Title,
+		Description: body.Description,
+	}
+	if body.Amount != "" {
+		amount, err := model.ParseAmount(body.Amount.String(), recurring.Currency)
+		if err != nil || amount <= 0 {
+			http.Error(w, jsonMessage(amountError(err)), http.StatusBadRequest)
+			return
+		}
+		override.Amount = &amount
+	}
+	if body.Date != "" {
+		moved, err := model.ParseDate(body.Date, user.DateFormat)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid date. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+		override.Date = &moved
+	}
+
+	// an occurrence already created is changed through its expense
+	expense, err := h.Recurring.FindOccurrence(recurring.ID, date)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if expense != nil {
+		if override.Skip {
+			err = h.Expenses.Delete(int64(expense.ID))
+		} else {
+			override.Apply(expense)
+			err = h.Expenses.Update(expense)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.Recurring.SaveOverride(override); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	occurrence, err := h.occurrence(recurring, date, []model.RecurringOverride{*override})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(occurrence)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Recurring
+// @Summary Restore a single occurrence
+// @Description Remove the change made to an occurrence not created yet so it follows the recurring expense again, expenses already created are not touched
+// @Accept  json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Param date path string true "Date of the occurrence in YYYY-MM-DD format"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Recurring expense or change not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /recurring/{id}/occurrences/{date} [delete]
+func (h *Handler) DeleteOccurrenceChange(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	recurring, ok := h.ownedRecurring(w, r, userId)
+	if !ok {
+		return
+	}
+	date, ok := h.occurrenceDate(w, r, recurring)
+	if !ok {
+		return
+	}
+	err := h.Recurring.DeleteOverride(recurring.ID, date)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "The occurrence has not been changed"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyRecurring validates the fields given in body and copies them onto the
+// recurring expense, writing a JSON error when one is invalid
+func (h *Handler) applyRecurring(w http.ResponseWriter, user *model.UserData, recurring *model.RecurringExpense, body *RecurringExpense) bool {
+	if body.Title != "" {
+		recurring.Title = body.Title
+	}
+	if body.Timezone != "" {
+		if _, err := time.LoadLocation(body.Timezone); err != nil {
+			http.Error(w, `{"message":"Invalid timezone. Use an IANA time zone such as Africa/Lagos."}`, http.StatusBadRequest)
+			return false
+		}
+		recurring.Timezone = body.Timezone
+	}
+	if body.Rule != "" {
+		rule, err := model.ParseRecurrence(body.Rule)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid rule: "+err.Error()+"."), http.StatusBadRequest)
+			return false
+		}
+		recurring.Rule = rule.String()
+	}
+
+	// a new currency keeps the decimal amount unless a new one is given
+	currency := recurring.Currency
+	if body.Currency != "" {
+		currency = strings.ToUpper(body.Currency)
+		if !model.ValidCurrency(currency) {
+			http.Error(w, `{"message":"Invalid currency. Use an ISO 4217 code such as USD."}`, http.StatusBadRequest)
+			return false
+		}
+	}
+	if body.Amount != "" || currency != recurring.Currency {
+		value := body.Amount.String()
+		if value == "" {
+			value = model.FormatAmount(recurring.Amount, recurring.Currency)
+		}
+		amount, err := model.ParseAmount(value, currency)
+		if err != nil || amount <= 0 {
+			http.Error(w, jsonMessage(amountError(err)), http.StatusBadRequest)
+			return false
+		}
+		recurring.Amount, recurring.Currency = amount, currency
+	}
+
+	if body.CategoryId != 0 || body.Category != "" || recurring.CategoryId == 0 {
+		category, err := h.resolveCategory(recurring.UserId, body.CategoryId, body.Category)
+		if errors.Is(err, model.ErrNotFound) {
+			http.Error(w, `{"message":"Invalid category provided"}`, http.StatusBadRequest)
+			return false
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
+		recurring.CategoryId, recurring.Category = category.ID, category.Name
+	}
+
+	if body.StartDate != "" {
+		date, err := model.ParseDate(body.StartDate, user.DateFormat)
+		if err != nil {
+			http.Error(w, jsonMessage("Invalid startDate. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return false
+		}
+		recurring.StartDate = date
+	}
+	if recurring.StartDate.IsZero() {
+		today, err := recurring.Today(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
+		recurring.StartDate = today
+	}
+	if body.EndDate != nil {
+		recurring.EndDate = model.Date{}
+		if *body.EndDate != "" {
+			date, err := model.ParseDate(*body.EndDate, user.DateFormat)
+			if err != nil {
+				http.Error(w, jsonMessage("Invalid endDate. Please use "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+				return false
+			}
+			recurring.EndDate = date
+		}
+	}
+	if !recurring.EndDate.IsZero() && recurring.EndDate.Before(recurring.StartDate.Time) {
+		http.Error(w, `{"message":"The endDate cannot be before the startDate."}`, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// occurrence describes the occurrence of a recurring expense on a date
+func (h *Handler) occurrence(recurring *model.RecurringExpense, date model.Date, overrides []model.RecurringOverride) (Occurrence, error) {
+	expense := recurring.Expense(date)
+	status := OccurrenceScheduled
+	for _, o := range overrides {
+		if o.Occurrence.Equal(date.Time) {
+			o.Apply(&expense)
+			if o.Skip {
+				status = OccurrenceSkipped
+			}
+		}
+	}
+
+	created, err := h.Recurring.FindOccurrence(recurring.ID, date)
+	if err == nil {
+		expense, status = *created, OccurrenceCreated
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return Occurrence{}, err
+	} else if date.Before(recurring.NextDate.Time) {
+		// the expense of a past occurrence was deleted
+		status = OccurrenceSkipped
+	}
+
+	return Occurrence{
+		Occurrence:  date,
+		Date:        expense.Date,
+		Status:      status,
+		Title:       expense.Title,
+		Description: expense.Description,
+		Amount:      json.RawMessage(model.FormatAmount(expense.Amount, expense.Currency)),
+		Currency:    expense.Currency,
+		ExpenseId:   expense.ID,
+	}, nil
+}
+
+// ownedRecurring loads the recurring expense named by the id path parameter
+// and writes a JSON error unless it belongs to the user
+func (h *Handler) ownedRecurring(w http.ResponseWriter, r *http.Request, userId int64) (*model.RecurringExpense, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	recurring, err := h.Recurring.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Recurring expense not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if recurring.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to recurring expense"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return recurring, true
+}
+
+// occurrenceDate reads the date path parameter and writes a JSON error
+// unless the recurring expense has an occurrence on it
+func (h *Handler) occurrenceDate(w http.ResponseWriter, r *http.Request, recurring *model.RecurringExpense) (model.Date, bool) {
+	date, err := model.ParseDate(mux.Vars(r)["date"], "")
+	if err != nil {
+		http.Error(w, `{"message": "Invalid occurrence date. Please use YYYY-MM-DD."}`, http.StatusBadRequest)
+		return date, false
+	}
+	dates, err := recurring.Occurrences(date, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return date, false
+	}
+	if len(dates) == 0 {
+		http.Error(w, `{"message": "The recurring expense has no occurrence on this date"}`, http.StatusNotFound)
+		return date, false
+	}
+	return date, true
+}
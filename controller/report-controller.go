@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"expense-tracker/model"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// @Tags Report
+// @Summary Get a summary of expenses
+// @Description Total, count, average, minimum and maximum of the expenses in a date range, per currency and per group, compared with the previous period and the same period a year earlier. Totals are also converted into the user's base currency at the rate of each expense's date, spending in currencies with no known rate is listed as unconverted. A range of whole months is compared with the same number of months before it, any other range with the range of the same length before it.
+// @Accept  json
+// @Produce json
+// @Param start_date query string false "Start date in YYYY-MM-DD format, the current month by default"
+// @Param end_date query string false "End date in YYYY-MM-DD format, the current month by default"
+// @Param group_by query string false "Grouping of the expenses" Enums(category, tag, day, week, month, year) default(category)
+// @Param category query string false "Category of the expense"
+// @Param category_id query int false "Category ID, subcategories included"
+// @Param tags query string false "Comma separated tags, prefixed with any: (default) or all:"
+// @Param recurring_id query int false "Only expenses created by this recurring expense"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search for in the title and description"
+// @Success 200 {object} model.SummaryReport "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/summary [get]
+func (h *Handler) GetSummaryReport(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	query, err := h.parseExpenseQuery(r, user)
+	if err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+	filter := query.ExpenseFilter
+	switch {
+	case filter.StartDate.IsZero() && filter.EndDate.IsZero():
+		filter.StartDate, filter.EndDate = monthRange(model.NewDate(time.Now()))
+	case filter.StartDate.IsZero() || filter.EndDate.IsZero():
+		http.Error(w, `{"message": "Give both start_date and end_date, or neither for the current month."}`, http.StatusBadRequest)
+		return
+	case filter.EndDate.Before(filter.StartDate.Time):
+		http.Error(w, `{"message": "The end_date cannot be before the start_date."}`, http.StatusBadRequest)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = model.GroupByCategory
+	}
+	if !slices.Contains(model.SummaryGroupings, groupBy) {
+		http.Error(w, jsonMessage("Invalid group_by. Use one of "+strings.Join(model.SummaryGroupings, ", ")+"."), http.StatusBadRequest)
+		return
+	}
+
+	report, err := model.Summarize(h.Expenses, model.NewConverter(h.Rates), h.baseCurrency(user), filter, groupBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Report
+// @Summary Get totals by tag
+// @Description Sum the expenses matching the listing filters per tag and currency, an expense with several tags counts towards each of them. Each total is also converted into the user's base currency at the rate of each expense's date, the part with no known rate is given as unconverted
+// @Accept  json
+// @Produce json
+// @Param start_date query string false "Start date in YYYY-MM-DD format"
+// @Param end_date query string false "End date in YYYY-MM-DD format"
+// @Param category query string false "Category of the expense"
+// @Param category_id query int false "Category ID, subcategories included"
+// @Param tags query string false "Comma separated tags, prefixed with any: (default) or all:"
+// @Param recurring_id query int false "Only expenses created by this recurring expense"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search for in the title and description"
+// @Success 200 {array} model.TagTotal "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/tags [get]
+func (h *Handler) GetTagReport(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	query, err := h.parseExpenseQuery(r, user)
+	if err != nil {
+		http.Error(w, jsonMessage(err.Error()), http.StatusBadRequest)
+		return
+	}
+	totals, err := model.TagTotals(h.Expenses, model.NewConverter(h.Rates), h.baseCurrency(user), query.ExpenseFilter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(totals)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// monthRange returns the first and the last day of the month of the date
+func monthRange(date model.Date) (model.Date, model.Date) {
+	start := model.Date{Time: date.AddDate(0, 0, 1-date.Day())}
+	return start, model.Date{Time: start.AddDate(0, 1, -1)}
+}
+
+// @Tags Report
+// @Summary Get a statement
+// @Description Printable statement of the expenses of a month or a date range: the totals by category compared with the previous period and the year before, the status of the budgets on the last day and the list of the expenses, at most 2000 of them.
+// @Description Rendered as an HTML page or a PDF document.
+// @Produce html
+// @Produce application/pdf
+// @Param month query string false "Month of the statement as YYYY-MM, the current month by default"
+// @Param start_date query string false "Start date of a custom range in YYYY-MM-DD format"
+// @Param end_date query string false "End date of a custom range in YYYY-MM-DD format"
+// @Param format query string false "Rendering of the statement" Enums(html, pdf) default(html)
+// @Success 200 {file} file "The statement"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/statement [get]
+func (h *Handler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	params := r.URL.Query()
+
+	format := params.Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" {
+		http.Error(w, `{"message": "Invalid format. Use html or pdf."}`, http.StatusBadRequest)
+		return
+	}
+
+	start, end := monthRange(model.NewDate(time.Now()))
+	month, startDate, endDate := params.Get("month"), params.Get("start_date"), params.Get("end_date")
+	switch {
+	case month != "" && (startDate != "" || endDate != ""):
+		http.Error(w, `{"message": "Give either month or start_date and end_date."}`, http.StatusBadRequest)
+		return
+	case month != "":
+		first, err := time.Parse("2006-01", month)
+		if err != nil {
+			http.Error(w, `{"message": "Invalid month. Please use YYYY-MM."}`, http.StatusBadRequest)
+			return
+		}
+		start, end = monthRange(model.NewDate(first))
+	case startDate != "" || endDate != "":
+		var err1, err2 error
+		start, err1 = model.ParseDate(startDate, user.DateFormat)
+		end, err2 = model.ParseDate(endDate, user.DateFormat)
+		if err1 != nil || err2 != nil {
+			http.Error(w, jsonMessage("Give both start_date and end_date, in "+model.DateFormatHint(user.DateFormat)+"."), http.StatusBadRequest)
+			return
+		}
+		if end.Before(start.Time) {
+			http.Error(w, `{"message": "The end_date cannot be before the start_date."}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	statement, err := h.buildStatement(user, start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var out bytes.Buffer
+	if format == "pdf" {
+		err = statement.RenderPDF(&out)
+	} else {
+		err = statement.RenderHTML(&out)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="statement-`+start.String()+"-"+end.String()+`.pdf"`)
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	out.WriteTo(w)
+}
+
+// buildStatement gathers the statement of the user's expenses from start to
+// end
+func (h *Handler) buildStatement(user *model.UserData, start, end model.Date) (*model.Statement, error) {
+	userId := int64(user.ID)
+	statement := &model.Statement{User: *user, StartDate: start, EndDate: end, GeneratedAt: time.Now()}
+
+	filter := model.ExpenseFilter{UserId: userId, StartDate: start, EndDate: end}
+	var err error
+	if statement.Report, err = model.Summarize(h.Expenses, model.NewConverter(h.Rates), h.baseCurrency(user), filter, model.GroupByCategory); err != nil {
+		return nil, err
+	}
+	if statement.Budgets, err = h.budgetStatuses(userId, end, 0); err != nil {
+		return nil, err
+	}
+
+	query := model.ExpenseQuery{ExpenseFilter: filter, Sort: model.SortByDate, Limit: maxPageSize}
+	for {
+		page, err := h.Expenses.List(query)
+		if err != nil {
+			return nil, err
+		}
+		statement.Expenses = append(statement.Expenses, page.Expenses...)
+		if len(statement.Expenses) >= model.MaxStatementExpenses || page.NextCursor == "" {
+			statement.Expenses = statement.Expenses[:min(len(statement.Expenses), model.MaxStatementExpenses)]
+			statement.Unlisted = page.Total - int64(len(statement.Expenses))
+			return statement, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
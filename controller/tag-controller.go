@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Tag struct to represent a tag rename in the API
+type Tag struct {
+	Name string `json:"name" example:"trip-lagos"`
+}
+
+// TagMerge struct to represent a tag merge in the API
+type TagMerge struct {
+	// Into is the ID of the tag that takes over the expenses
+	Into uint `json:"into"`
+}
+
+// @Tags Tag
+// @Summary Get all tags
+// @Description Retrieve the tags of the current user with the number of expenses carrying each of them
+// @Accept  json
+// @Produce json
+// @Success 200 {array} Tag "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tags [get]
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	tags, err := h.Tags.List(userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Tags Tag
+// @Summary Rename a tag
+// @Description Rename a tag on all the expenses carrying it, use merge to combine it with an existing tag
+// @Accept  json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param Tag body Tag true "Tag data"
+// @Success 202 {object} Tag "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 409 {string} string "Tag already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tags/{id} [patch]
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	body := &Tag{}
+	utils.ParseBody(r, body)
+	tag, ok := h.ownedTag(w, r, userId)
+	if !ok {
+		return
+	}
+
+	name, err := model.NormalizeTag(body.Name)
+	if err != nil {
+		http.Error(w, jsonMessage("Invalid name: "+err.Error()+"."), http.StatusBadRequest)
+		return
+	}
+	if existing, err := h.Tags.FindByName(userId, name); err == nil && existing.ID != tag.ID {
+		http.Error(w, `{"message": "Tag already exists, merge the tags instead"}`, http.StatusConflict)
+		return
+	} else if err != nil && !errors.Is(err, model.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tag.Name = name
+	if err := h.Tags.Update(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res, err := json.Marshal(tag)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(res)
+}
+
+// @Tags Tag
+// @Summary Merge a tag into another
+// @Description Move the expenses of a tag to another tag and delete it
+// @Accept  json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param TagMerge body TagMerge true "Tag taking over the expenses"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tags/{id}/merge [post]
+func (h *Handler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	body := &TagMerge{}
+	utils.ParseBody(r, body)
+	source, ok := h.ownedTag(w, r, userId)
+	if !ok {
+		return
+	}
+
+	target, err := h.Tags.FindById(int64(body.Into))
+	if errors.Is(err, model.ErrNotFound) || (err == nil && (target.UserId != userId || target.ID == source.ID)) {
+		http.Error(w, `{"message": "Invalid tag to merge into"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Tags.Merge(source, target); err != nil {
+		http.Error(w, `{"message": "Failed to merge tags"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags Tag
+// @Summary Delete a tag
+// @Description Remove a tag from all its expenses and delete it
+// @Accept  json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 204 {string} string "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tags/{id} [delete]
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	userId := currentUserId(r)
+
+	tag, ok := h.ownedTag(w, r, userId)
+	if !ok {
+		return
+	}
+	if err := h.Tags.Delete(int64(tag.ID)); err != nil {
+		http.Error(w, `{"message": "Failed to delete tag"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedTag loads the tag named by the id path parameter and writes a JSON
+// error unless it belongs to the user
+func (h *Handler) ownedTag(w http.ResponseWriter, r *http.Request, userId int64) (*model.Tag, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	tag, err := h.Tags.FindById(ID)
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, `{"message": "Tag not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if tag.UserId != userId {
+		http.Error(w, `{"message": "Unauthorized access to tag"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return tag, true
+}
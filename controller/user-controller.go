@@ -1,18 +1,78 @@
 package controller
 
 import (
-	"encoding/json"
+	"errors"
 	"expense-tracker/model"
 	"expense-tracker/utils"
+	"fmt"
+	"log"
 	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
 )
 
-// User struct to represent a user in the API
+// User struct to represent a new user in the API
 type User struct {
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
 	Password  string `json:"password"`
+	// DateFormat is the preferred input format for dates, e.g. DD/MM/YYYY
+	DateFormat string `json:"dateFormat"`
+	// BaseCurrency is the ISO 4217 code amounts are converted into
+	BaseCurrency string `json:"baseCurrency" example:"USD"`
+	// Timezone is an IANA time zone, UTC when omitted
+	Timezone string `json:"timezone" example:"Africa/Lagos"`
+	// Locale is a BCP 47 language tag
+	Locale string `json:"locale" example:"en-NG"`
+}
+
+// Profile struct to represent a user in the API, it never carries the password
+type Profile struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+	// EmailVerified tells whether the user confirmed they own the email
+	EmailVerified bool `json:"emailVerified"`
+	// PendingEmail is the email the account changes to once it is confirmed
+	PendingEmail string `json:"pendingEmail,omitempty"`
+	DateFormat   string `json:"dateFormat"`
+	BaseCurrency string `json:"baseCurrency" example:"USD"`
+	Timezone     string `json:"timezone" example:"Africa/Lagos"`
+	Locale       string `json:"locale" example:"en-NG"`
+	// Role is user, admin or support
+	Role      string    `json:"role" example:"user"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProfileUpdate struct to represent a change of my profile in the API, fields left out are kept
+type ProfileUpdate struct {
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+	// Email changes once the link sent to the new address is opened
+	Email      *string `json:"email"`
+	DateFormat *string `json:"dateFormat"`
+	// BaseCurrency falls back to the default currency when empty
+	BaseCurrency *string `json:"baseCurrency" example:"USD"`
+	Timezone     *string `json:"timezone" example:"Africa/Lagos"`
+	Locale       *string `json:"locale" example:"en-NG"`
+}
+
+// AccountDeletion struct to represent a scheduled account deletion in the API
+type AccountDeletion struct {
+	Message string `json:"message"`
+	// DeleteAt is when the account and everything it stores are deleted for good
+	DeleteAt time.Time `json:"deleteAt"`
+}
+
+// PasswordChange struct to represent a change of my password in the API
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
 }
 
 // @Tags User
@@ -20,64 +80,242 @@ type User struct {
 // @Description Get my profile as a signed in user
 // @Accept  json
 // @Produce json
-// @Success 200 {object} User "Successful operation"
+// @Success 200 {object} Profile "Successful operation"
 // @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "User not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /users/me [get]
-func GetMyAccount(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-    if err != nil {
-        http.Error(w, err.Error(), http.StatusUnauthorized)
-        return
-    }
-
-	user, _ := model.GetUserById(userId)
-	if user.ID == 0 {
-		http.Error(w, `{"message": "User not found"}`, http.StatusNotFound)
+func (h *Handler) GetMyAccount(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, profile(currentUser(r)))
+}
+
+// @Tags User
+// @Summary Update my profile
+// @Description Change the name, email, time zone, locale, base currency or preferred date format of the signed in user. A new email is confirmed first: a link is sent to it and the account keeps its current email until the link is opened.
+// @Accept  json
+// @Produce json
+// @Param profile body ProfileUpdate true "Fields to change"
+// @Success 200 {object} Profile "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Email already registered"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me [patch]
+func (h *Handler) UpdateMyAccount(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	body := &ProfileUpdate{}
+	utils.ParseBody(r, body)
+
+	if body.FirstName != nil {
+		user.FirstName = strings.TrimSpace(*body.FirstName)
+	}
+	if body.LastName != nil {
+		user.LastName = strings.TrimSpace(*body.LastName)
+	}
+	if user.FirstName == "" || user.LastName == "" {
+		http.Error(w, `{"message": "The firstName and lastName cannot be empty"}`, http.StatusBadRequest)
 		return
 	}
+	if body.DateFormat != nil {
+		user.DateFormat = *body.DateFormat
+	}
+	if body.BaseCurrency != nil {
+		user.BaseCurrency = strings.ToUpper(*body.BaseCurrency)
+	}
+	if body.Timezone != nil {
+		user.Timezone = *body.Timezone
+	}
+	if body.Locale != nil {
+		user.Locale = *body.Locale
+	}
+	if message := validatePreferences(user); message != "" {
+		http.Error(w, jsonMessage(message), http.StatusBadRequest)
+		return
+	}
+
+	// the email changes once the new address is confirmed, asking again
+	// sends a new link
+	changing := false
+	if body.Email != nil {
+		email := strings.TrimSpace(*body.Email)
+		if _, err := mail.ParseAddress(email); err != nil {
+			http.Error(w, `{"message": "Invalid email"}`, http.StatusBadRequest)
+			return
+		}
+		user.PendingEmail, changing = email, true
+		if strings.EqualFold(email, user.Email) {
+			user.PendingEmail, changing = "", false
+		}
+	}
+	if changing {
+		if _, err := h.Users.FindByEmail(user.PendingEmail); err == nil {
+			http.Error(w, `{"message": "Email already registered"}`, http.StatusConflict)
+			return
+		} else if !errors.Is(err, model.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
-	res, err := json.Marshal(user)
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if changing {
+		if err := h.sendEmailToken(user, model.TokenChangeEmail); err != nil {
+			log.Printf("Email change confirmation for user %d: %v", user.ID, err)
+		}
+		h.notify(user, "Your email is changing", fmt.Sprintf("A change of the email of your account to %s was requested. "+
+			"It takes effect once the link sent to the new address is opened. If it was not you, change your password.", user.PendingEmail))
+	}
+	writeJSON(w, http.StatusOK, profile(user))
+}
+
+// @Tags User
+// @Summary Change my password
+// @Description Change the password of the signed in user, which requires the current password. Every session is ended and new tokens are returned for this one; API tokens keep working.
+// @Accept  json
+// @Produce json
+// @Param password body PasswordChange true "Current and new password"
+// @Success 200 {object} TokenResponse "Successful operation"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Wrong current password"
+// @Failure 429 {string} string "Too many failed attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/me/password [post]
+func (h *Handler) ChangeMyPassword(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	body := &PasswordChange{}
+	utils.ParseBody(r, body)
+	if body.CurrentPassword == "" || body.NewPassword == "" {
+		http.Error(w, `{"message": "The currentPassword and newPassword are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	// a stolen session must not be a way around the login limits
+	attempt := h.newLoginAttempt(r, user.Email)
+	if h.throttled(w, attempt) {
+		return
+	}
+	match, err := argon2id.ComparePasswordAndHash(body.CurrentPassword, user.Password)
+	if err != nil || !match {
+		if err := h.loginFailed(attempt, user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"message": "The current password is wrong"}`, http.StatusForbidden)
+		return
+	}
+
+	hash, err := argon2id.CreateHash(body.NewPassword, argon2id.DefaultParams)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	user.Password = hash
+	if err := h.Users.Update(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// end the other sessions, this one continues with new tokens
+	if err := h.Tokens.RevokeUser(int64(user.ID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.notify(user, "Your password was changed", "The password of your account was changed and every other session was signed out. "+
+		"If it was not you, reset your password.")
+	h.issueTokens(w, user, model.NewTokenId(), nil, "Password changed")
 }
 
 // @Tags User
 // @Summary Delete my profile
-// @Description Delete my profile as a signed in user
+// @Description Delete my account and everything it stores. Every session ends and the account can no longer be used, it is deleted for good once the grace period is over (30 days by default). Until then, the link emailed to the user restores it.
 // @Accept  json
 // @Produce json
-// @Success 204 {string} string "Successful operation"
-// @Failure 400 {string} string "Bad request"
-// @Failure 404 {string} string "User not found"
+// @Success 202 {object} AccountDeletion "Successful operation"
+// @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal server error"
 // @Router /users/me [delete]
-func DeleteMyAccount(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.GetUserIdFromJWTToken(r)
-    if err != nil {
-        http.Error(w, err.Error(), http.StatusUnauthorized)
-        return
-    }
-
-	// Check if user exists
-    user, _ := model.GetUserById(userId)
-    if user.ID == 0 {
-        http.Error(w, `{"message": "User not found"}`, http.StatusNotFound)
-        return
-    }
-
-	deletedUser := model.DeleteUserById(userId)
-	if deletedUser.ID == 0 {
+func (h *Handler) DeleteMyAccount(w http.ResponseWriter, r *http.Request) {
+	session := currentSession(r)
+	user := session.User
+	userId := int64(user.ID)
+
+	deleteAt := time.Now().UTC().Add(h.DeletionGrace)
+	entry := &model.AuditEntry{UserId: &userId, ActorId: &userId, Action: model.AuditAccountDeletion, IP: h.clientIP(r),
+		Detail: "deleted for good on " + deleteAt.Format(time.RFC3339)}
+	if err := h.Audit.Record(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user.DeleteAt = &deleteAt
+	if err := h.Users.Update(user); err != nil {
 		http.Error(w, `{"message": "Failed to delete user"}`, http.StatusInternalServerError)
-        return
+		return
+	}
+
+	// end every session, API tokens are refused until the account is restored
+	if err := h.Tokens.RevokeUser(userId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.Tokens.RevokeAccess(session.TokenId, session.ExpiresAt); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.sendEmailToken(user, model.TokenRestoreAccount); err != nil {
+		log.Printf("Account restore email for user %d: %v", user.ID, err)
+	}
+	writeJSON(w, http.StatusAccepted, AccountDeletion{
+		Message:  "Your account will be deleted, open the link sent to your email to restore it before then",
+		DeleteAt: deleteAt,
+	})
+}
+
+// validatePreferences checks the preferences of a user and returns the
+// message explaining the first invalid one, or an empty string
+func validatePreferences(user *model.UserData) string {
+	switch {
+	// accounts created before base currencies have none, they use the
+	// default currency like the other empty preferences
+	case user.BaseCurrency != "" && !model.ValidCurrency(user.BaseCurrency):
+		return "Invalid baseCurrency. Use an ISO 4217 code such as USD."
+	case !model.ValidDateFormat(user.DateFormat):
+		return "Invalid dateFormat. Use one of " + strings.Join(model.DateFormatNames(), ", ") + "."
+	case !model.ValidTimezone(user.Timezone):
+		return "Invalid timezone. Use an IANA time zone such as Africa/Lagos."
+	case !model.ValidLocale(user.Locale):
+		return "Invalid locale. Use a language tag such as en-NG."
+	}
+	return ""
+}
+
+// notify emails a security notice to the user, failures are only logged
+func (h *Handler) notify(user *model.UserData, subject, text string) {
+	err := h.Mailer.Send(utils.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hello %s,\n\n%s\n", user.FirstName, text),
+	})
+	if err != nil {
+		log.Printf("Notice to user %d: %v", user.ID, err)
+	}
+}
+
+// profile converts a user into their API representation
+func profile(user *model.UserData) Profile {
+	return Profile{
+		ID:            user.ID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		PendingEmail:  user.PendingEmail,
+		DateFormat:    user.DateFormat,
+		BaseCurrency:  user.BaseCurrency,
+		Timezone:      user.Timezone,
+		Locale:        user.Locale,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNoContent)
 }
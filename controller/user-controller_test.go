@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"encoding/json"
+	"expense-tracker/model"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUpdateMyAccountPreferences(t *testing.T) {
+	h, store := newTestHandler()
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	router.HandleFunc("/users/me", h.UpdateMyAccount).Methods("PATCH")
+	// accounts created before base currencies were introduced have none
+	user := createUser(t, store, model.UserData{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"})
+	token := login(t, h, user).Token
+
+	tests := []struct {
+		body     string
+		want     int
+		currency string
+	}{
+		{`{"firstName": "Janet"}`, http.StatusOK, ""},
+		{`{"baseCurrency": "eur"}`, http.StatusOK, "EUR"},
+		{`{"baseCurrency": "XXX"}`, http.StatusBadRequest, ""},
+		{`{"timezone": "Africa/Lagos"}`, http.StatusOK, "EUR"},
+		{`{"baseCurrency": ""}`, http.StatusOK, ""},
+		{`{"locale": "not a locale"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := serve(router, "PATCH", "/users/me", token, tt.body)
+		var got Profile
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if w.Code != tt.want || (w.Code == http.StatusOK && got.BaseCurrency != tt.currency) {
+			t.Errorf("%s: got %d %s, want %d with baseCurrency %q", tt.body, w.Code, w.Body, tt.want, tt.currency)
+		}
+	}
+}
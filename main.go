@@ -1,10 +1,17 @@
 package main
 
 import (
+	"expense-tracker/config"
+	"expense-tracker/controller"
+	"expense-tracker/model"
 	"expense-tracker/routes"
 	"expense-tracker/utils"
+	"flag"
 	"log"
 	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // recurring expenses may use any IANA time zone
 
 	_ "expense-tracker/docs" // docs is generated by Swag CLI, you have to import it.
 
@@ -29,19 +36,93 @@ import (
 // @description Type "Bearer" and then your JWT token to authorize
 
 func main() {
+	migrateDates := flag.Bool("migrate-dates", false, "convert the stored expense dates to YYYY-MM-DD and exit")
+	migrateAmounts := flag.Bool("migrate-amounts", false, "convert the stored expense amounts to minor units of DEFAULT_CURRENCY and exit")
+	migrateCategories := flag.Bool("migrate-categories", false, "link the stored expenses to per-user categories and exit")
+	loadRates := flag.String("load-rates", "", "comma separated exchange-rate files (ECB CSV or JSON) to load before serving")
+	ratesBase := flag.String("rates-base", "EUR", "base currency of the CSV exchange-rate files")
+	makeAdmin := flag.String("make-admin", "", "give the admin role to the user with this email and exit")
+	generateJWTKey := flag.String("generate-jwt-key", "", "write a new rsa or ed25519 JWT signing key into JWT_KEY_DIR and exit")
+	schedulerInterval := flag.Duration("scheduler-interval", time.Hour, "how often due recurring expenses are created and deleted accounts and expired exports removed, 0 disables the scheduler")
+	flag.Parse()
+
 	env := utils.LoadEnv()
+	if !model.ValidCurrency(env.Currency) {
+		log.Fatalf("DEFAULT_CURRENCY %q is not an ISO 4217 currency code", env.Currency)
+	}
+	if *generateJWTKey != "" {
+		runKeyGeneration(env, *generateJWTKey)
+		return
+	}
+	if *migrateDates {
+		runDateMigration(env)
+		return
+	}
+	if *migrateAmounts {
+		runAmountMigration(env)
+		return
+	}
+	if *migrateCategories {
+		runCategoryMigration(env)
+		return
+	}
+
+	store, err := model.OpenStore(env.DBDriver, env.DBURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Connected to %s database successfully", env.DBDriver)
+	if *makeAdmin != "" {
+		runMakeAdmin(store, *makeAdmin)
+		return
+	}
+	if *loadRates != "" {
+		loadRateFiles(store, *loadRates, *ratesBase)
+	}
+
+	if env.LoginLimiter == "memory" {
+		store.Logins = model.NewMemoryLoginLimiter()
+	}
+
+	if *schedulerInterval > 0 {
+		go runScheduler(store, *schedulerInterval)
+	}
+
+	utils.SetRevocationList(store.Tokens)
+	keys := loadKeySet(env)
+	utils.SetKeySet(keys)
+
+	handler := controller.NewHandler(store, env.Currency)
+	handler.Mailer = utils.NewMailer(env)
+	handler.AppURL = strings.TrimSuffix(env.AppURL, "/")
+	handler.RequireVerified = env.RequireVerified
+	handler.Keys = keys
+	handler.TrustProxy = env.TrustProxy
+	handler.DeletionGrace = env.DeletionGrace
+	go handler.RunExports()
 	router := mux.NewRouter()
 	subRouter := router.PathPrefix("/api/v1").Subrouter()
-	routes.RegisterAuthRoutes(subRouter)
-	routes.RegisterUserRoutes(subRouter)
-	routes.RegisterExpenseRoutes(subRouter)
+	// every route requires a signed in user unless it is declared otherwise
+	subRouter.Use(handler.Authenticate)
+	routes.RegisterAuthRoutes(subRouter, handler)
+	routes.RegisterUserRoutes(subRouter, handler)
+	routes.RegisterExpenseRoutes(subRouter, handler)
+	routes.RegisterCategoryRoutes(subRouter, handler)
+	routes.RegisterTagRoutes(subRouter, handler)
+	routes.RegisterRecurringRoutes(subRouter, handler)
+	routes.RegisterBudgetRoutes(subRouter, handler)
+	routes.RegisterReportRoutes(subRouter, handler)
+	routes.RegisterRateRoutes(subRouter, handler)
+	routes.RegisterImportRoutes(subRouter, handler)
+	routes.RegisterAdminRoutes(subRouter, handler)
+	routes.RegisterWellKnownRoutes(router, handler)
 
 	// setup swagger documentation
-	subRouter.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
+	handler.Public(subRouter.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
 		httpSwagger.DeepLinking(true),
 		httpSwagger.DocExpansion("none"),
 		httpSwagger.DomID("swagger-ui"),
-	)).Methods(http.MethodGet)
+	)).Methods(http.MethodGet))
 
 	// redirect root to swagger docs
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -51,3 +132,172 @@ func main() {
 	log.Println("Server is running on port", env.PORT)
 	log.Fatal(http.ListenAndServe(":"+env.PORT, router))
 }
+
+// loadKeySet reads the JWT keys of JWT_KEY_DIR. The JWT_KEY secret signs
+// access tokens when there is no key directory, otherwise it only verifies
+// the tokens it signed before the switch.
+func loadKeySet(env *utils.EnvData) *utils.KeySet {
+	set := &utils.KeySet{Keys: map[string]*utils.JWTKey{}}
+	if env.JWTKeyDir != "" {
+		var err error
+		if set, err = utils.LoadKeySet(env.JWTKeyDir, env.JWTSigningKeyId); err != nil {
+			log.Fatalf("Failed to load the JWT keys: %v", err)
+		}
+		log.Printf("Signing access tokens with key %s (%s), %d keys accepted", set.Signing.Id, set.Signing.Method.Alg(), len(set.Keys))
+	}
+	if env.JWTKey != "" {
+		set.Legacy = []byte(env.JWTKey)
+	}
+	return set
+}
+
+// runKeyGeneration writes a new JWT signing key into JWT_KEY_DIR
+func runKeyGeneration(env *utils.EnvData, algorithm string) {
+	if env.JWTKeyDir == "" {
+		log.Fatal("JWT_KEY_DIR is not set")
+	}
+	id, err := utils.GenerateJWTKey(env.JWTKeyDir, strings.ToLower(algorithm))
+	if err != nil {
+		log.Fatalf("Key generation failed: %v", err)
+	}
+	log.Printf("Generated JWT key %s in %s", id, env.JWTKeyDir)
+}
+
+// runMakeAdmin gives the admin role to a user, which is how the first admin
+// is made
+func runMakeAdmin(store *model.Store, email string) {
+	user, err := store.Users.FindByEmail(email)
+	if err != nil {
+		log.Fatalf("No user with the email %s: %v", email, err)
+	}
+	id := int64(user.ID)
+	entry := &model.AuditEntry{UserId: &id, Action: model.AuditAdminSetRole, Detail: user.Role + " to " + model.RoleAdmin + " from the command line"}
+	if err := store.Audit.Record(entry); err != nil {
+		log.Fatal(err)
+	}
+	user.Role = model.RoleAdmin
+	if err := store.Users.Update(user); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s is now an admin", email)
+}
+
+// runDateMigration converts the expense dates stored as free-form strings
+// into proper dates and reports the expenses it could not convert
+func runDateMigration(env *utils.EnvData) {
+	db, err := config.Connect(env.DBDriver, env.DBURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	report, err := model.MigrateExpenseDates(db)
+	if err != nil {
+		log.Fatalf("Date migration failed: %v", err)
+	}
+	log.Printf("Date migration finished: %d converted, %d already valid, %d not convertible", report.Converted, report.Unchanged, len(report.Failed))
+	for _, f := range report.Failed {
+		log.Printf("Expense ID %d of user %d has an unreadable date %q, it has been cleared", f.ExpenseId, f.UserId, f.Value)
+	}
+}
+
+// runAmountMigration converts the floating point expense amounts into minor
+// units of the default currency
+func runAmountMigration(env *utils.EnvData) {
+	db, err := config.Connect(env.DBDriver, env.DBURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	converted, err := model.MigrateExpenseAmounts(db, env.Currency)
+	if err != nil {
+		log.Fatalf("Amount migration failed: %v", err)
+	}
+	log.Printf("Amount migration finished: %d expenses converted to %s", converted, env.Currency)
+}
+
+// runCategoryMigration gives every user their own categories and links the
+// stored expenses to them by name
+func runCategoryMigration(env *utils.EnvData) {
+	db, err := config.Connect(env.DBDriver, env.DBURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	report, err := model.MigrateExpenseCategories(db)
+	if err != nil {
+		log.Fatalf("Category migration failed: %v", err)
+	}
+	log.Printf("Category migration finished: %d expenses linked", report.Linked)
+	for _, c := range report.Created {
+		log.Printf("Created category %q for user %d from the names found on their expenses", c.Name, c.UserId)
+	}
+}
+
+// runScheduler creates the expenses of due recurring occurrences at startup,
+// catching up on any missed while the server was down, and then on every tick.
+// It also deletes the accounts whose grace period is over and the expired
+// data exports.
+func runScheduler(store *model.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		created, err := model.MaterializeDue(store.Recurring, time.Now())
+		if err != nil {
+			log.Printf("Recurring expenses: %v", err)
+		}
+		if created > 0 {
+			log.Printf("Recurring expenses: created %d expenses", created)
+		}
+		purgeDeletedAccounts(store)
+		if deleted, err := store.Exports.DeleteExpired(time.Now()); err != nil {
+			log.Printf("Data exports: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Data exports: deleted %d expired exports", deleted)
+		}
+		<-ticker.C
+	}
+}
+
+// purgeDeletedAccounts deletes for good the accounts whose grace period is
+// over, with everything they store
+func purgeDeletedAccounts(store *model.Store) {
+	users, err := store.Users.DueForDeletion(time.Now())
+	if err != nil {
+		log.Printf("Account deletion: %v", err)
+		return
+	}
+	for _, user := range users {
+		id := int64(user.ID)
+		entry := &model.AuditEntry{UserId: &id, Action: model.AuditAccountPurge}
+		if err := store.Audit.Record(entry); err != nil {
+			log.Printf("Account deletion of user %d: %v", id, err)
+			continue
+		}
+		if err := store.Users.Purge(id); err != nil {
+			log.Printf("Account deletion of user %d: %v", id, err)
+			continue
+		}
+		log.Printf("Account deletion: deleted user %d and their data", id)
+	}
+}
+
+// loadRateFiles stores the exchange rates of the given files so conversions
+// work without any network access
+func loadRateFiles(store *model.Store, paths, base string) {
+	for _, path := range strings.Split(paths, ",") {
+		rates, skipped, err := model.ParseRatesFile(strings.TrimSpace(path), strings.ToUpper(base))
+		if err != nil {
+			log.Fatalf("Failed to read rates from %s: %v", path, err)
+		}
+		if err := store.Rates.Save(rates); err != nil {
+			log.Fatalf("Failed to save rates from %s: %v", path, err)
+		}
+		log.Printf("Loaded %d exchange rates from %s", len(rates), path)
+		if len(skipped) > 0 {
+			log.Printf("Skipped the columns of currencies no longer in use: %s", strings.Join(skipped, ", "))
+		}
+	}
+}
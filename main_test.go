@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"expense-tracker/model"
+	"testing"
+	"time"
+)
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	store := model.NewMemoryStore()
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	users := map[string]*model.UserData{
+		"due":    {Email: "due@example.com", DeleteAt: &past},
+		"grace":  {Email: "grace@example.com", DeleteAt: &future},
+		"active": {Email: "active@example.com"},
+	}
+	for _, user := range users {
+		if err := store.Users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+		expense := &model.ExpenseData{UserId: int64(user.ID), Title: "Lunch", Amount: 1250, Currency: "USD"}
+		if err := store.Expenses.Create(expense); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purgeDeletedAccounts(store)
+
+	for name, user := range users {
+		_, err := store.Users.FindById(int64(user.ID))
+		if name == "due" && !errors.Is(err, model.ErrNotFound) {
+			t.Errorf("%s: got %v, want the user purged", name, err)
+		}
+		if name != "due" && err != nil {
+			t.Errorf("%s: got %v, want the user kept", name, err)
+		}
+	}
+	page, err := store.Audit.List(model.AuditQuery{Action: model.AuditAccountPurge, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Entries[0].UserId == nil || *page.Entries[0].UserId != int64(users["due"].ID) {
+		t.Errorf("audit: got %+v, want one purge of user %d", page.Entries, users["due"].ID)
+	}
+}
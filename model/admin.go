@@ -0,0 +1,54 @@
+package model
+
+import "slices"
+
+// Roles of the users. Admins manage the accounts, support staff may look them
+// up and help users sign in again but not disable them or grant roles.
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// Roles lists the valid roles
+var Roles = []string{RoleUser, RoleAdmin, RoleSupport}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// UserQuery searches the users. Zero values leave the corresponding criterion
+// out of the query.
+type UserQuery struct {
+	// Search is matched against the email and the names
+	Search string
+	Role   string
+	// Disabled matches disabled users when true and active users when false
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// UserPage is one page of a user search, sorted by ID
+type UserPage struct {
+	Users []UserData
+	// Total is the number of users matching the query across all pages
+	Total int64
+}
+
+// UserStats counts what a user stores
+type UserStats struct {
+	Expenses   int64
+	Categories int64
+	Tags       int64
+	Recurring  int64
+	Budgets    int64
+	APITokens  int64
+	// Sessions counts the refresh tokens that can still be exchanged
+	Sessions int64
+	// FirstExpense and LastExpense are the dates of the oldest and newest
+	// expenses, nil without expenses
+	FirstExpense *Date
+	LastExpense  *Date
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scopes an API token may be granted
+const (
+	ScopeExpensesRead  = "expenses:read"
+	ScopeExpensesWrite = "expenses:write"
+	ScopeReportsRead   = "reports:read"
+)
+
+// APIScopes lists the scopes an API token may be granted
+var APIScopes = []string{ScopeExpensesRead, ScopeExpensesWrite, ScopeReportsRead}
+
+// APITokenPrefix starts every API token, it tells them apart from session
+// tokens and makes leaked tokens easy to search for
+const APITokenPrefix = "etk_"
+
+// APITokenMaxTTL is the longest lifetime an API token may be given
+const APITokenMaxTTL = 366 * 24 * time.Hour
+
+// APIToken is a long-lived token a user creates for scripts and
+// integrations, limited to a set of scopes. Only the SHA-256 hash of the
+// token is stored.
+type APIToken struct {
+	ID     uint  `gorm:"primary_key"`
+	UserId int64 `gorm:"index"`
+	Name   string
+	// Prefix is the start of the token, shown so users can tell their
+	// tokens apart
+	Prefix string
+	Hash   string `gorm:"unique_index"`
+	// Scopes are separated by spaces
+	Scopes     string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// NewAPIToken creates a token for the user and returns it with the token to
+// show to the user, which is never stored
+func NewAPIToken(userId int64, name string, scopes []string, ttl time.Duration) (string, *APIToken) {
+	token := APITokenPrefix + randomToken()
+	return token, &APIToken{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    token[:len(APITokenPrefix)+6],
+		Hash:      HashToken(token),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+}
+
+// IsAPIToken tells whether a bearer token is an API token rather than a
+// session token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// ScopeList returns the scopes of the token
+func (t *APIToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// HasScope tells whether the token was granted the scope
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
+
+// NormalizeScopes checks the requested scopes and returns them deduplicated
+// in the order of APIScopes, ok is false if any scope is unknown
+func NormalizeScopes(scopes []string) ([]string, bool) {
+	var normalized []string
+	for _, scope := range APIScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(APIScopes, scope) {
+			return nil, false
+		}
+	}
+	return normalized, true
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	// AuditLoginLockout is recorded when failed logins lock out an account
+	// or an address
+	AuditLoginLockout = "login.lockout"
+
+	// Deletion of accounts: requested by the user, cancelled through the
+	// link sent by email, and carried out once the grace period is over
+	AuditAccountDeletion = "account.deletion"
+	AuditAccountRestore  = "account.restore"
+	AuditAccountPurge    = "account.purge"
+
+	// Actions of the staff through the admin API
+	AuditAdminSearchUsers   = "admin.users.search"
+	AuditAdminViewUser      = "admin.user.view"
+	AuditAdminViewStats     = "admin.user.stats"
+	AuditAdminDisable       = "admin.user.disable"
+	AuditAdminEnable        = "admin.user.enable"
+	AuditAdminLogout        = "admin.user.logout"
+	AuditAdminPasswordReset = "admin.user.password-reset"
+	AuditAdminSetRole       = "admin.user.role"
+	AuditAdminViewAudit     = "admin.audit.view"
+)
+
+// AuditEntry is an event of the audit log
+type AuditEntry struct {
+	ID uint `gorm:"primary_key" json:"id"`
+	// UserId is the user the event concerns, nil when it concerns no account
+	UserId *int64 `gorm:"index" json:"userId"`
+	// ActorId is the user who acted, nil for events of the system
+	ActorId *int64 `gorm:"index" json:"actorId"`
+	Action  string `gorm:"index" json:"action"`
+	// IP is the address the request came from
+	IP        string    `json:"ip"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// AuditQuery filters the audit log, zero values leave the corresponding
+// criterion out
+type AuditQuery struct {
+	UserId  int64
+	ActorId int64
+	// Action matches the action or, ending in a dot, every action it prefixes
+	Action string
+	Limit  int
+	Offset int
+}
+
+// AuditPage is one page of the audit log, newest first
+type AuditPage struct {
+	Entries []AuditEntry
+	// Total is the number of entries matching the query across all pages
+	Total int64
+}
+
+// matchAction tells whether an action matches the Action of the query
+func (q AuditQuery) matchAction(action string) bool {
+	if strings.HasSuffix(q.Action, ".") {
+		return strings.HasPrefix(action, q.Action)
+	}
+	return q.Action == "" || action == q.Action
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Budget periods
+const (
+	PeriodMonth  = "month"
+	PeriodWeek   = "week"
+	PeriodCustom = "custom"
+)
+
+// Budget caps the spending of a user, overall or in one category, for every
+// calendar month or week or for a custom date range
+type Budget struct {
+	gorm.Model
+	UserId int64  `json:"userId" gorm:"index"`
+	Name   string `json:"name"`
+	// CategoryId limits the budget to a category and its subcategories, a
+	// budget without a category covers all spending
+	CategoryId *uint `json:"categoryId"`
+	// Amount is in minor units of Currency and is available in every period
+	Amount   int64  `json:"amount" gorm:"column:amount_minor"`
+	Currency string `json:"currency" gorm:"type:char(3)"`
+	Period   string `json:"period"`
+	// StartDate is the first day of the first period, EndDate the last day
+	// of a custom budget or, when set, of the last period of the others
+	StartDate Date `json:"startDate" gorm:"type:date"`
+	EndDate   Date `json:"endDate" gorm:"type:date"`
+	// Rollover carries the amount left unused in a period over to the next
+	Rollover bool `json:"rollover"`
+}
+
+// MarshalJSON writes the amount as an exact decimal number in the currency of
+// the budget
+func (b Budget) MarshalJSON() ([]byte, error) {
+	type budget Budget
+	return json.Marshal(struct {
+		budget
+		Amount json.RawMessage `json:"amount"`
+	}{budget(b), json.RawMessage(FormatAmount(b.Amount, b.Currency))})
+}
+
+// ValidPeriod reports whether period is one of the budget periods
+func ValidPeriod(period string) bool {
+	return period == PeriodMonth || period == PeriodWeek || period == PeriodCustom
+}
+
+// PeriodOf returns the first and last day of the budget period containing
+// date. A custom budget has a single period.
+func (b *Budget) PeriodOf(date Date) (Date, Date) {
+	switch b.Period {
+	case PeriodMonth:
+		first := Date{time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)}
+		return first, Date{first.AddDate(0, 1, -1)}
+	case PeriodWeek:
+		monday := Date{date.AddDate(0, 0, -(int(date.Weekday())+6)%7)}
+		return monday, Date{monday.AddDate(0, 0, 6)}
+	}
+	return b.StartDate, b.EndDate
+}
+
+// ActiveOn reports whether date falls within one of the budget's periods
+func (b *Budget) ActiveOn(date Date) bool {
+	if date.Before(b.StartDate.Time) {
+		return false
+	}
+	return b.EndDate.IsZero() || !date.After(b.EndDate.Time)
+}
+
+// Covers reports whether an expense in the given category counts against the
+// budget, categoryIds being the budget's category and its subcategories
+func (b *Budget) Covers(categoryId uint, categoryIds []uint) bool {
+	if b.CategoryId == nil {
+		return true
+	}
+	for _, id := range categoryIds {
+		if id == categoryId {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrencyTotal is the number and total amount of expenses in one currency
+type CurrencyTotal struct {
+	Currency string `json:"currency" example:"EUR"`
+	Count    int64  `json:"count"`
+	// Total is in minor units of Currency, written as a decimal number
+	Total int64 `json:"total" swaggertype:"number" example:"12.00"`
+}
+
+// MarshalJSON writes the total as an exact decimal number in its currency
+func (t CurrencyTotal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Currency string          `json:"currency"`
+		Count    int64           `json:"count"`
+		Total    json.RawMessage `json:"total"`
+	}{t.Currency, t.Count, json.RawMessage(FormatAmount(t.Total, t.Currency))})
+}
+
+// BudgetStatus compares the amount of a budget period with the spending in
+// it, all amounts being in minor units of the budget currency and written as
+// decimal numbers
+type BudgetStatus struct {
+	BudgetId   uint   `json:"budgetId"`
+	Name       string `json:"name" example:"Groceries"`
+	CategoryId *uint  `json:"categoryId"`
+	Period     string `json:"period" example:"month"`
+	StartDate  Date   `json:"startDate" swaggertype:"string" example:"2026-10-01"`
+	EndDate    Date   `json:"endDate" swaggertype:"string" example:"2026-10-31"`
+	Currency   string `json:"currency" example:"USD"`
+	Amount     int64  `json:"amount" swaggertype:"number" example:"400.00"`
+	// Rollover is the amount carried over from the previous periods
+	Rollover int64 `json:"rollover" swaggertype:"number" example:"25.00"`
+	// Budgeted is Amount plus Rollover
+	Budgeted int64 `json:"budgeted" swaggertype:"number" example:"425.00"`
+	Spent    int64 `json:"spent" swaggertype:"number" example:"318.75"`
+	// Remaining is negative once the budget is overspent
+	Remaining int64 `json:"remaining" swaggertype:"number" example:"106.25"`
+	// Percent is the share of Budgeted spent, rounded to one decimal
+	Percent float64 `json:"percent" example:"75"`
+	// Count is the number of expenses in the period
+	Count int64 `json:"count"`
+	// Unconverted is the spending in currencies with no known rate into the
+	// budget currency, it is left out of Spent
+	Unconverted []CurrencyTotal `json:"unconverted,omitempty"`
+}
+
+// MarshalJSON writes the amounts as exact decimal numbers in the budget
+// currency
+func (s BudgetStatus) MarshalJSON() ([]byte, error) {
+	amount := func(minor int64) json.RawMessage { return json.RawMessage(FormatAmount(minor, s.Currency)) }
+	return json.Marshal(struct {
+		BudgetId    uint            `json:"budgetId"`
+		Name        string          `json:"name"`
+		CategoryId  *uint           `json:"categoryId"`
+		Period      string          `json:"period"`
+		StartDate   Date            `json:"startDate"`
+		EndDate     Date            `json:"endDate"`
+		Currency    string          `json:"currency"`
+		Amount      json.RawMessage `json:"amount"`
+		Rollover    json.RawMessage `json:"rollover"`
+		Budgeted    json.RawMessage `json:"budgeted"`
+		Spent       json.RawMessage `json:"spent"`
+		Remaining   json.RawMessage `json:"remaining"`
+		Percent     float64         `json:"percent"`
+		Count       int64           `json:"count"`
+		Unconverted []CurrencyTotal `json:"unconverted,omitempty"`
+	}{
+		s.BudgetId, s.Name, s.CategoryId, s.Period, s.StartDate, s.EndDate, s.Currency,
+		amount(s.Amount), amount(s.Rollover), amount(s.Budgeted), amount(s.Spent), amount(s.Remaining),
+		s.Percent, s.Count, s.Unconverted,
+	})
+}
+
+// ComputeBudgetStatus computes the status of the budget period containing
+// date from the user's expenses, converted into the budget currency at the
+// rate of their date. categoryIds are the category of a category budget and
+// its subcategories. With rollover, the amounts left unused in the periods
+// since the start of the budget are added to the period, overspending is not
+// carried over.
+func ComputeBudgetStatus(expenses ExpenseRepository, converter *Converter, budget *Budget, categoryIds []uint, date Date) (*BudgetStatus, error) {
+	if budget.CategoryId != nil && len(categoryIds) == 0 {
+		return nil, errors.New("a category budget needs the IDs of its categories")
+	}
+	start, end := budget.PeriodOf(date)
+	from := start
+	if budget.Rollover && budget.Period != PeriodCustom && budget.StartDate.Before(start.Time) {
+		from = budget.StartDate
+	}
+
+	filter := ExpenseFilter{UserId: budget.UserId, StartDate: from, EndDate: end}
+	if budget.CategoryId != nil {
+		filter.CategoryIds = categoryIds
+	}
+	totals, err := expenses.DailyTotals(filter, GroupByNone)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BudgetStatus{
+		BudgetId:   budget.ID,
+		Name:       budget.Name,
+		CategoryId: budget.CategoryId,
+		Period:     budget.Period,
+		StartDate:  start,
+		EndDate:    end,
+		Currency:   budget.Currency,
+		Amount:     budget.Amount,
+	}
+
+	// previous periods only matter for the amount they leave unused
+	_, periodEnd := budget.PeriodOf(from)
+	var spent int64
+	for _, t := range totals {
+		for t.Date.After(periodEnd.Time) && periodEnd.Before(start.Time) {
+			status.Rollover = max(0, status.Rollover+budget.Amount-spent)
+			spent = 0
+			_, periodEnd = budget.PeriodOf(Date{periodEnd.AddDate(0, 0, 1)})
+		}
+
+		converted, err := converter.Convert(t.Total, t.Currency, budget.Currency, t.Date)
+		if errors.Is(err, ErrNoRate) {
+			if !t.Date.Before(start.Time) {
+				status.Unconverted = addCurrencyTotal(status.Unconverted, t.Currency, t.Count, t.Total)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		spent += converted.Amount
+		if !t.Date.Before(start.Time) {
+			status.Count += t.Count
+		}
+	}
+	for periodEnd.Before(start.Time) {
+		status.Rollover = max(0, status.Rollover+budget.Amount-spent)
+		spent = 0
+		_, periodEnd = budget.PeriodOf(Date{periodEnd.AddDate(0, 0, 1)})
+	}
+
+	status.Budgeted = status.Amount + status.Rollover
+	status.Spent = spent
+	status.Remaining = status.Budgeted - spent
+	if status.Budgeted > 0 {
+		status.Percent = math.Round(float64(spent)*1000/float64(status.Budgeted)) / 10
+	}
+	return status, nil
+}
+
+// addCurrencyTotal adds to the total of a currency, keeping totals sorted by
+// currency
+func addCurrencyTotal(totals []CurrencyTotal, currency string, count, total int64) []CurrencyTotal {
+	for i := range totals {
+		if totals[i].Currency == currency {
+			totals[i].Count += count
+			totals[i].Total += total
+			return totals
+		}
+		if totals[i].Currency > currency {
+			return append(totals[:i], append([]CurrencyTotal{{currency, count, total}}, totals[i:]...)...)
+		}
+	}
+	return append(totals, CurrencyTotal{currency, count, total})
+}
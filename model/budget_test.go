@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestComputeBudgetStatus(t *testing.T) {
+	store := NewMemoryStore()
+	rate, _ := NewExchangeRate(testDate("2026-04-01"), "EUR", "USD", "1.10")
+	store.Rates.Save([]ExchangeRate{rate})
+	expenses := []struct {
+		date     string
+		amount   int64
+		currency string
+	}{
+		// before the budget starts, never carried over
+		{"2025-12-31", 50000, "USD"},
+		{"2026-01-10", 3000, "USD"},
+		// overspending February leaves nothing for March but takes nothing
+		// away from it either
+		{"2026-02-03", 10000, "USD"},
+		{"2026-02-20", 5000, "USD"},
+		{"2026-04-02", 2000, "USD"},
+		{"2026-04-03", 1000, "EUR"},
+		{"2026-04-04", 500, "GBP"},
+	}
+	for _, e := range expenses {
+		store.Expenses.Create(&ExpenseData{UserId: 1, Title: "x", Amount: e.amount, Currency: e.currency, Date: testDate(e.date)})
+	}
+
+	tests := []struct {
+		period   string
+		rollover bool
+		date     string
+		start    string
+		want     BudgetStatus
+	}{
+		{PeriodMonth, true, "2026-01-15", "2026-01-01",
+			BudgetStatus{Rollover: 0, Budgeted: 10000, Spent: 3000, Remaining: 7000, Percent: 30, Count: 1}},
+		{PeriodMonth, true, "2026-02-10", "2026-02-01",
+			BudgetStatus{Rollover: 7000, Budgeted: 17000, Spent: 15000, Remaining: 2000, Percent: 88.2, Count: 2}},
+		{PeriodMonth, true, "2026-03-31", "2026-03-01",
+			BudgetStatus{Rollover: 2000, Budgeted: 12000, Spent: 0, Remaining: 12000, Percent: 0, Count: 0}},
+		// the EUR expense is converted, the GBP one has no rate
+		{PeriodMonth, true, "2026-04-15", "2026-04-01",
+			BudgetStatus{Rollover: 12000, Budgeted: 22000, Spent: 3100, Remaining: 18900, Percent: 14.1, Count: 2}},
+		{PeriodMonth, false, "2026-04-15", "2026-04-01",
+			BudgetStatus{Rollover: 0, Budgeted: 10000, Spent: 3100, Remaining: 6900, Percent: 31, Count: 2}},
+		{PeriodMonth, false, "2026-02-10", "2026-02-01",
+			BudgetStatus{Rollover: 0, Budgeted: 10000, Spent: 15000, Remaining: -5000, Percent: 150, Count: 2}},
+		// weeks without spending carry the whole amount over
+		{PeriodWeek, true, "2026-01-20", "2026-01-19",
+			BudgetStatus{Rollover: 7000 + 10000 + 10000, Budgeted: 37000, Spent: 0, Remaining: 37000, Percent: 0, Count: 0}},
+	}
+	for _, tt := range tests {
+		budget := &Budget{UserId: 1, Amount: 10000, Currency: "USD", Period: tt.period, StartDate: testDate("2026-01-01"), Rollover: tt.rollover}
+		got, err := ComputeBudgetStatus(store.Expenses, NewConverter(store.Rates), budget, nil, testDate(tt.date))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.period, tt.date, err)
+		}
+		want := tt.want
+		if got.StartDate.String() != tt.start || got.Rollover != want.Rollover || got.Budgeted != want.Budgeted ||
+			got.Spent != want.Spent || got.Remaining != want.Remaining || got.Percent != want.Percent || got.Count != want.Count {
+			t.Errorf("%s %s, rollover %t: got %+v, want %+v", tt.period, tt.date, tt.rollover, *got, want)
+		}
+	}
+
+	budget := &Budget{UserId: 1, Amount: 10000, Currency: "USD", Period: PeriodMonth, StartDate: testDate("2026-01-01")}
+	got, _ := ComputeBudgetStatus(store.Expenses, NewConverter(store.Rates), budget, nil, testDate("2026-04-15"))
+	if len(got.Unconverted) != 1 || got.Unconverted[0] != (CurrencyTotal{"GBP", 1, 500}) {
+		t.Errorf("got unconverted %v, want 5.00 GBP", got.Unconverted)
+	}
+
+	categoryId := uint(1)
+	budget.CategoryId = &categoryId
+	if _, err := ComputeBudgetStatus(store.Expenses, NewConverter(store.Rates), budget, nil, testDate("2026-04-15")); err == nil {
+		t.Error("a category budget without its categories was computed")
+	}
+}
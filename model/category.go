@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrCategoryCycle is returned when a category would become its own ancestor
+var ErrCategoryCycle = errors.New("a category cannot be nested under itself or one of its subcategories")
+
+// Category is an expense category owned by a user, optionally nested under
+// a parent category of the same user
+type Category struct {
+	gorm.Model
+	UserId   int64  `json:"userId" gorm:"index"`
+	Name     string `json:"name"`
+	ParentId *uint  `json:"parentId"`
+	// Color and Icon are free-form hints for clients, e.g. "#4caf50" and "cart"
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+	// Account is the account of the category in plain text accounting
+	// journals, such as Expenses:Food:Groceries. Without one it is derived
+	// from the names of the category and its parents.
+	Account string `json:"account"`
+}
+
+// DefaultCategories are created for every new user
+var DefaultCategories = []Category{
+	{Name: "Groceries", Color: "#4caf50", Icon: "cart"},
+	{Name: "Leisure", Color: "#ff9800", Icon: "ticket"},
+	{Name: "Electronics", Color: "#2196f3", Icon: "laptop"},
+	{Name: "Utilities", Color: "#9c27b0", Icon: "bolt"},
+	{Name: "Clothing", Color: "#e91e63", Icon: "shirt"},
+	{Name: "Health", Color: "#f44336", Icon: "heart"},
+	{Name: "Others", Color: "#607d8b", Icon: "tag"},
+}
+
+// Descendants returns the IDs of a category and of every category nested
+// below it, categories is the full list of the owner's categories
+func Descendants(categories []Category, id uint) []uint {
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range categories {
+			if c.ParentId != nil && *c.ParentId == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// CheckParent verifies that nesting the category id under parentId keeps the
+// hierarchy a tree, id is 0 for a category not created yet
+func CheckParent(categories []Category, id uint, parentId uint) error {
+	if id == 0 {
+		return nil
+	}
+	for _, descendant := range Descendants(categories, id) {
+		if descendant == parentId {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+// testCategories returns the tree
+//
+//	1 food
+//	├── 2 groceries
+//	│   └── 4 fruit
+//	└── 3 restaurants
+//	5 rent
+func testCategories() []Category {
+	parent := func(id uint) *uint { return &id }
+	return []Category{
+		{Model: gorm.Model{ID: 1}, Name: "food"},
+		{Model: gorm.Model{ID: 2}, Name: "groceries", ParentId: parent(1)},
+		{Model: gorm.Model{ID: 3}, Name: "restaurants", ParentId: parent(1)},
+		{Model: gorm.Model{ID: 4}, Name: "fruit", ParentId: parent(2)},
+		{Model: gorm.Model{ID: 5}, Name: "rent"},
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	tests := []struct {
+		id   uint
+		want []uint
+	}{
+		{1, []uint{1, 2, 3, 4}},
+		{2, []uint{2, 4}},
+		{4, []uint{4}},
+		{5, []uint{5}},
+		// an unknown category has no subcategories
+		{9, []uint{9}},
+	}
+	for _, tt := range tests {
+		got := Descendants(testCategories(), tt.id)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%d: got %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	tests := []struct {
+		id, parentId uint
+		want         error
+	}{
+		{2, 5, nil},
+		{5, 4, nil},
+		{4, 3, nil},
+		// a new category can go anywhere
+		{0, 4, nil},
+		{1, 1, ErrCategoryCycle},
+		{1, 2, ErrCategoryCycle},
+		{1, 4, ErrCategoryCycle},
+		{2, 4, ErrCategoryCycle},
+	}
+	for _, tt := range tests {
+		if err := CheckParent(testCategories(), tt.id, tt.parentId); !errors.Is(err, tt.want) {
+			t.Errorf("%d under %d: got %v, want %v", tt.id, tt.parentId, err, tt.want)
+		}
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		categories := map[string]*Category{}
+		for _, c := range []struct{ name, parent string }{{"food", ""}, {"groceries", "food"}, {"fruit", "groceries"}, {"rent", ""}} {
+			category := &Category{UserId: 1, Name: c.name}
+			if c.parent != "" {
+				category.ParentId = &categories[c.parent].ID
+			}
+			if err := store.Categories.Create(category); err != nil {
+				t.Fatal(err)
+			}
+			categories[c.name] = category
+		}
+		expenses := createExpenses(t, store, 1,
+			ExpenseData{Title: "apples", CategoryId: categories["groceries"].ID, Category: "groceries", Amount: 300},
+			ExpenseData{Title: "bananas", CategoryId: categories["fruit"].ID, Category: "fruit", Amount: 200},
+			ExpenseData{Title: "march", CategoryId: categories["rent"].ID, Category: "rent", Amount: 90000},
+		)
+
+		if err := store.Categories.Delete(int64(categories["groceries"].ID), categories["rent"]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Categories.FindById(int64(categories["groceries"].ID)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted category: got %v, want ErrNotFound", err)
+		}
+
+		// the expenses of the category move to the replacement, those of
+		// its subcategories stay where they are
+		want := []struct {
+			categoryId uint
+			category   string
+		}{
+			{categories["rent"].ID, "rent"},
+			{categories["fruit"].ID, "fruit"},
+			{categories["rent"].ID, "rent"},
+		}
+		for i, e := range expenses {
+			got, err := store.Expenses.FindById(int64(e.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.CategoryId != want[i].categoryId || got.Category != want[i].category {
+				t.Errorf("%s: got category %d %q, want %d %q", e.Title, got.CategoryId, got.Category, want[i].categoryId, want[i].category)
+			}
+		}
+
+		// the subcategories move up to the parent of the deleted category
+		fruit, err := store.Categories.FindById(int64(categories["fruit"].ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fruit.ParentId == nil || *fruit.ParentId != categories["food"].ID {
+			t.Errorf("fruit: got parent %v, want %d", fruit.ParentId, categories["food"].ID)
+		}
+
+		// deleting a top level category moves its subcategories to the top
+		if err := store.Categories.Delete(int64(categories["food"].ID), categories["rent"]); err != nil {
+			t.Fatal(err)
+		}
+		if fruit, err = store.Categories.FindById(int64(categories["fruit"].ID)); err != nil || fruit.ParentId != nil {
+			t.Errorf("fruit: got %+v, %v, want a top level category", fruit, err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of a data export
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// ExportTTL is how long a built export can be downloaded
+const ExportTTL = 7 * 24 * time.Hour
+
+// DataExport is a user's request for a copy of their data, built in the
+// background into a ZIP archive stored as a DataExportFile
+type DataExport struct {
+	ID     uint   `gorm:"primary_key" json:"id"`
+	UserId int64  `gorm:"index" json:"userId"`
+	Status string `gorm:"index" json:"status"`
+	// Error tells why a failed export could not be built
+	Error string `json:"error,omitempty"`
+	// Size is the size of the archive in bytes
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	// ExpiresAt is when a ready export is deleted
+	ExpiresAt *time.Time `gorm:"index" json:"expiresAt"`
+}
+
+// DataExportFile holds the archive of a ready export, apart from the export
+// so listing the exports does not load the archives
+type DataExportFile struct {
+	ExportId uint   `gorm:"primary_key;auto_increment:false"`
+	Data     []byte `gorm:"type:longblob"`
+}
+
+// Takeout is everything a user stores, as written into their data export
+type Takeout struct {
+	Profile    UserData
+	Expenses   []ExpenseData
+	Categories []Category
+	Tags       []Tag
+	Recurring  []RecurringExpense
+	Budgets    []Budget
+}
+
+// WriteZip writes the takeout as a ZIP archive holding a JSON file of each
+// kind of data and CSV files of the profile, expenses and categories
+func (t *Takeout) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"profile.json", t.Profile},
+		{"expenses.json", t.Expenses},
+		{"categories.json", t.Categories},
+		{"tags.json", t.Tags},
+		{"recurring.json", t.Recurring},
+		{"budgets.json", t.Budgets},
+	}
+	for _, f := range files {
+		file, err := archive.Create(f.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(f.value); err != nil {
+			return err
+		}
+	}
+
+	tables := []struct {
+		name string
+		rows [][]string
+	}{
+		{"profile.csv", t.profileRows()},
+		{"expenses.csv", t.expenseRows()},
+		{"categories.csv", t.categoryRows()},
+	}
+	for _, table := range tables {
+		file, err := archive.Create(table.name)
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(file)
+		if err := writer.WriteAll(table.rows); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (t *Takeout) profileRows() [][]string {
+	u := t.Profile
+	return [][]string{
+		{"id", "firstName", "lastName", "email", "emailVerifiedAt", "dateFormat", "baseCurrency", "timezone", "locale", "createdAt"},
+		{formatId(u.ID), u.FirstName, u.LastName, u.Email, formatTime(u.EmailVerifiedAt), u.DateFormat, u.BaseCurrency,
+			u.Timezone, u.Locale, formatTime(&u.CreatedAt)},
+	}
+}
+
+func (t *Takeout) expenseRows() [][]string {
+	rows := [][]string{{"id", "date", "title", "description", "amount", "currency", "category", "tags", "recurringId", "createdAt"}}
+	for _, e := range t.Expenses {
+		recurringId := ""
+		if e.RecurringId != nil {
+			recurringId = formatId(*e.RecurringId)
+		}
+		rows = append(rows, []string{formatId(e.ID), e.Date.String(), e.Title, e.Description,
+			FormatAmount(e.Amount, e.Currency), e.Currency, e.Category, strings.Join(e.Tags, ";"), recurringId,
+			formatTime(&e.CreatedAt)})
+	}
+	return rows
+}
+
+func (t *Takeout) categoryRows() [][]string {
+	rows := [][]string{{"id", "name", "parentId", "color", "icon"}}
+	for _, c := range t.Categories {
+		parentId := ""
+		if c.ParentId != nil {
+			parentId = formatId(*c.ParentId)
+		}
+		rows = append(rows, []string{formatId(c.ID), c.Name, parentId, c.Color, c.Icon})
+	}
+	return rows
+}
+
+func formatId(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// formatTime writes a time in RFC 3339 and nil as an empty string
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
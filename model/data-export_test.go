@@ -0,0 +1,115 @@
+package model
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// readZip returns the names of the files of a ZIP archive in order and their
+// contents
+func readZip(t *testing.T, archive []byte) ([]string, map[string][]byte) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := []string{}, map[string][]byte{}
+	for _, f := range reader.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = data
+	}
+	return names, files
+}
+
+func TestTakeoutWriteZip(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	recurringId, parentId := uint(4), uint(2)
+	takeout := &Takeout{
+		Profile: UserData{Model: gorm.Model{ID: 7, CreatedAt: created}, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Password: "argon2id hash",
+			BaseCurrency: "EUR"},
+		Expenses: []ExpenseData{
+			{Model: gorm.Model{ID: 1, CreatedAt: created}, Date: testDate("2026-10-02"), Title: "Dinner, with friends", Amount: 4550, Currency: "EUR", Category: "Food",
+				Tags: []string{"trip", "friends"}},
+			{Model: gorm.Model{ID: 2, CreatedAt: created}, Date: testDate("2026-10-03"), Title: "Rent", Amount: 90000, Currency: "JPY", RecurringId: &recurringId},
+		},
+		Categories: []Category{{Name: "Food"}, {Name: "Restaurants", ParentId: &parentId, Color: "#ff9800"}},
+		Tags:       []Tag{{Name: "trip"}, {Name: "friends"}},
+		Recurring:  []RecurringExpense{{Title: "Rent", Rule: "FREQ=MONTHLY"}},
+		Budgets:    []Budget{},
+	}
+	takeout.Categories[0].ID, takeout.Categories[1].ID = 2, 3
+
+	var archive bytes.Buffer
+	if err := takeout.WriteZip(&archive); err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, archive.Bytes())
+	want := []string{"profile.json", "expenses.json", "categories.json", "tags.json", "recurring.json", "budgets.json",
+		"profile.csv", "expenses.csv", "categories.csv"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("files: got %v, want %v", names, want)
+	}
+
+	// the password hash is never exported
+	for name, data := range files {
+		if bytes.Contains(data, []byte("argon2id hash")) {
+			t.Errorf("%s holds the password hash", name)
+		}
+	}
+	var expenses []ExpenseData
+	if err := json.Unmarshal(files["expenses.json"], &expenses); err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 2 || expenses[0].Amount != 4550 || expenses[1].Amount != 90000 || !slices.Equal(expenses[0].Tags, []string{"trip", "friends"}) {
+		t.Errorf("expenses.json: got %+v", expenses)
+	}
+	if strings.TrimSpace(string(files["budgets.json"])) != "[]" {
+		t.Errorf("budgets.json: got %s, want []", files["budgets.json"])
+	}
+
+	tables := []struct {
+		name string
+		want [][]string
+	}{
+		{"profile.csv", [][]string{
+			{"id", "firstName", "lastName", "email", "emailVerifiedAt", "dateFormat", "baseCurrency", "timezone", "locale", "createdAt"},
+			{"7", "Jane", "Doe", "jane@example.com", "", "", "EUR", "", "", "2026-10-01T09:30:00Z"},
+		}},
+		{"expenses.csv", [][]string{
+			{"id", "date", "title", "description", "amount", "currency", "category", "tags", "recurringId", "createdAt"},
+			{"1", "2026-10-02", "Dinner, with friends", "", "45.50", "EUR", "Food", "trip;friends", "", "2026-10-01T09:30:00Z"},
+			{"2", "2026-10-03", "Rent", "", "90000", "JPY", "", "", "4", "2026-10-01T09:30:00Z"},
+		}},
+		{"categories.csv", [][]string{
+			{"id", "name", "parentId", "color", "icon"},
+			{"2", "Food", "", "", ""},
+			{"3", "Restaurants", "2", "#ff9800", ""},
+		}},
+	}
+	for _, table := range tables {
+		rows, err := csv.NewReader(bytes.NewReader(files[table.name])).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", table.name, err)
+		}
+		if !slices.EqualFunc(rows, table.want, slices.Equal) {
+			t.Errorf("%s: got %q, want %q", table.name, rows, table.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ISODate is the layout dates are stored, returned and always accepted in
+const ISODate = "2006-01-02"
+
+// DateFormats maps the input formats a user may prefer onto their Go layout
+var DateFormats = map[string]string{
+	"YYYY-MM-DD": ISODate,
+	"YYYY/MM/DD": "2006/01/02",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"DD.MM.YYYY": "02.01.2006",
+	"DD-MM-YYYY": "02-01-2006",
+}
+
+// Date is a calendar date without a time of day. It is stored in a DATE
+// column and encoded as YYYY-MM-DD, the zero value is stored as NULL.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the date of t, discarding the time of day
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses an ISO 8601 date, or a full ISO 8601 timestamp whose date
+// is kept, falling back to the user's preferred format when one is set
+func ParseDate(value, format string) (Date, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(ISODate, value); err == nil {
+		return NewDate(t), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return NewDate(t), nil
+	}
+	if layout, ok := DateFormats[format]; ok {
+		if t, err := time.Parse(layout, value); err == nil {
+			return NewDate(t), nil
+		}
+	}
+	return Date{}, fmt.Errorf("invalid date %q, use %s", value, DateFormatHint(format))
+}
+
+// DateFormatHint describes the formats ParseDate accepts for a user
+func DateFormatHint(format string) string {
+	if _, ok := DateFormats[format]; ok && format != "YYYY-MM-DD" {
+		return "YYYY-MM-DD or " + format
+	}
+	return "YYYY-MM-DD"
+}
+
+// ValidDateFormat reports whether format may be used as a preferred input
+// format, the empty string leaves ISO 8601 as the only format
+func ValidDateFormat(format string) bool {
+	_, ok := DateFormats[format]
+	return ok || format == ""
+}
+
+// DateFormatNames lists the supported preferred input formats in a stable order
+func DateFormatNames() []string {
+	names := make([]string, 0, len(DateFormats))
+	for name := range DateFormats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(ISODate)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format(ISODate))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(*value, "")
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value stores the date as YYYY-MM-DD text, which MySQL converts into its
+// DATE type and which keeps SQLite comparisons chronological
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.Format(ISODate), nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v)
+	case []byte:
+		return d.Scan(string(v))
+	case string:
+		if v == "" {
+			*d = Date{}
+			return nil
+		}
+		parsed, err := ParseDate(v, "")
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value, format, want string
+	}{
+		{"2026-09-05", "", "2026-09-05"},
+		{" 2026-09-05 ", "", "2026-09-05"},
+		{"2026-09-05T23:30:00+01:00", "", "2026-09-05"},
+		{"2026-09-05T23:30:00Z", "DD.MM.YYYY", "2026-09-05"},
+		// ISO 8601 is always accepted, whatever the preferred format
+		{"2026-09-05", "MM/DD/YYYY", "2026-09-05"},
+		{"05.09.2026", "DD.MM.YYYY", "2026-09-05"},
+		{"05/09/2026", "DD/MM/YYYY", "2026-09-05"},
+		{"09/05/2026", "MM/DD/YYYY", "2026-09-05"},
+		{"05-09-2026", "DD-MM-YYYY", "2026-09-05"},
+		{"2026/09/05", "YYYY/MM/DD", "2026-09-05"},
+		{"29.02.2028", "DD.MM.YYYY", "2028-02-29"},
+		// the preferred format is the only other one tried
+		{"05.09.2026", "", ""},
+		{"05/09/2026", "DD.MM.YYYY", ""},
+		{"29.02.2026", "DD.MM.YYYY", ""},
+		{"2026-13-01", "", ""},
+		{"5 September 2026", "", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		date, err := ParseDate(test.value, test.format)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%q with %q: got %s, want an error", test.value, test.format, date)
+			}
+			continue
+		}
+		if err != nil || date.String() != test.want {
+			t.Errorf("%q with %q: got %s, %v, want %s", test.value, test.format, date, err, test.want)
+		}
+		if date.Location() != time.UTC || date.Hour() != 0 {
+			t.Errorf("%q: got %v, want midnight UTC", test.value, date.Time)
+		}
+	}
+}
+
+func TestDateFormatHint(t *testing.T) {
+	tests := map[string]string{
+		"":           "YYYY-MM-DD",
+		"YYYY-MM-DD": "YYYY-MM-DD",
+		"DD.MM.YYYY": "YYYY-MM-DD or DD.MM.YYYY",
+		"unknown":    "YYYY-MM-DD",
+	}
+	for format, want := range tests {
+		if got := DateFormatHint(format); got != want {
+			t.Errorf("%q: got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	var v struct {
+		Date Date `json:"date"`
+	}
+	for _, data := range []string{`{"date":null}`, `{"date":""}`} {
+		if err := json.Unmarshal([]byte(data), &v); err != nil || !v.Date.IsZero() {
+			t.Errorf("%s: got %v, %v, want the zero date", data, v.Date, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"date":"05.09.2026"}`), &v); err == nil {
+		t.Errorf("got %v, want an error for a date in a preferred format", v.Date)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2026-09-05"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(v)
+	if string(data) != `{"date":"2026-09-05"}` {
+		t.Errorf("got %s", data)
+	}
+	data, _ = json.Marshal(struct{ Date Date }{})
+	if string(data) != `{"Date":null}` {
+		t.Errorf("got %s, want a null date", data)
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{nil, ""},
+		{"", ""},
+		{"2026-09-05", "2026-09-05"},
+		{[]byte("2026-09-05"), "2026-09-05"},
+		{time.Date(2026, 9, 5, 0, 0, 0, 0, time.UTC), "2026-09-05"},
+		// MySQL DATETIME columns read back with a time of day
+		{"2026-09-05T00:00:00Z", "2026-09-05"},
+	}
+	for _, test := range tests {
+		var d Date
+		if err := d.Scan(test.src); err != nil || d.String() != test.want {
+			t.Errorf("%v: got %s, %v, want %q", test.src, d, err, test.want)
+		}
+	}
+	var d Date
+	if err := d.Scan(42); err == nil {
+		t.Error("got no error scanning an int")
+	}
+}
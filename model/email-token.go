@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Purposes of the tokens sent by email
+const (
+	TokenVerifyEmail   = "verify-email"
+	TokenResetPassword = "reset-password"
+	TokenChangeEmail   = "change-email"
+	// TokenRestoreAccount cancels the deletion of an account, it is valid
+	// for the grace period of the deletion
+	TokenRestoreAccount = "restore-account"
+)
+
+// Lifetimes of the tokens sent by email
+const (
+	VerifyEmailTTL   = 48 * time.Hour
+	ResetPasswordTTL = time.Hour
+	ChangeEmailTTL   = 48 * time.Hour
+)
+
+// EmailToken is a single-use token sent by email to verify an address or
+// reset a password. Only the SHA-256 hash of the token is stored.
+type EmailToken struct {
+	ID      uint   `gorm:"primary_key"`
+	UserId  int64  `gorm:"index"`
+	Purpose string `gorm:"index"`
+	Hash    string `gorm:"unique_index"`
+	// Email is the address the token was sent to, a verification token only
+	// verifies the address it was sent to
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewEmailToken creates a token for the user and returns it with the token to
+// send, which is never stored. Email change tokens go to the PendingEmail of
+// the user, the other tokens to their Email.
+func NewEmailToken(user *UserData, purpose string, ttl time.Duration) (string, *EmailToken) {
+	email := user.Email
+	if purpose == TokenChangeEmail {
+		email = user.PendingEmail
+	}
+	token := randomToken()
+	return token, &EmailToken{
+		UserId:    int64(user.ID),
+		Purpose:   purpose,
+		Hash:      HashToken(token),
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+}
@@ -0,0 +1,368 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNoRate is returned when no exchange rate is known for a conversion
+var ErrNoRate = errors.New("no exchange rate available")
+
+// ExchangeRate states that on Date one unit of Base was worth Rate units of
+// Quote, the way central banks such as the ECB publish reference rates
+type ExchangeRate struct {
+	ID    uint   `json:"-" gorm:"primary_key"`
+	Date  Date   `json:"date" gorm:"type:date;unique_index:idx_exchange_rate"`
+	Base  string `json:"base" gorm:"type:char(3);unique_index:idx_exchange_rate"`
+	Quote string `json:"quote" gorm:"type:char(3);unique_index:idx_exchange_rate"`
+	// Rate is kept as decimal text so it is used exactly as published
+	Rate string `json:"rate" gorm:"type:varchar(32)"`
+}
+
+// ConvertedAmount is an amount converted into another currency together with
+// the rate used for it
+type ConvertedAmount struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+	RateDate Date   `json:"rateDate"`
+}
+
+// MarshalJSON writes the amount as a decimal number like ExpenseData does
+func (c ConvertedAmount) MarshalJSON() ([]byte, error) {
+	type converted ConvertedAmount
+	return json.Marshal(struct {
+		converted
+		Amount json.RawMessage `json:"amount"`
+	}{converted(c), json.RawMessage(FormatAmount(c.Amount, c.Currency))})
+}
+
+// rateFormat is a plain decimal that fits the rate column, exponents are
+// refused so a rate cannot make big.Rat build a huge number
+var rateFormat = regexp.MustCompile(`^[0-9]{1,20}(\.[0-9]{1,20})?$`)
+
+// parseRate parses a positive decimal rate
+func parseRate(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if !rateFormat.MatchString(value) {
+		return nil, fmt.Errorf("invalid rate %q", value)
+	}
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q", value)
+	}
+	return rate, nil
+}
+
+// NewExchangeRate validates and builds an exchange rate
+func NewExchangeRate(date Date, base, quote, rate string) (ExchangeRate, error) {
+	base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+	if date.IsZero() {
+		return ExchangeRate{}, errors.New("missing rate date")
+	}
+	if !ValidCurrency(base) {
+		return ExchangeRate{}, fmt.Errorf("invalid base currency %q", base)
+	}
+	if !ValidCurrency(quote) || quote == base {
+		return ExchangeRate{}, fmt.Errorf("invalid quote currency %q", quote)
+	}
+	parsed, err := parseRate(rate)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	// rates are stored with ten decimal places, smaller ones would be
+	// stored as zero
+	stored := parsed.FloatString(10)
+	if strings.Trim(stored, "0.") == "" {
+		return ExchangeRate{}, fmt.Errorf("rate %q is below 0.0000000001, the smallest rate that can be stored", rate)
+	}
+	return ExchangeRate{Date: date, Base: base, Quote: quote, Rate: stored}, nil
+}
+
+// Converter converts amounts between currencies with the rates of a
+// RateRepository. Rates are looked up once per currency pair and day, so a
+// Converter should live for a single request.
+type Converter struct {
+	rates RateRepository
+	cache map[string]*conversion
+}
+
+type conversion struct {
+	rate *big.Rat
+	date Date
+}
+
+func NewConverter(rates RateRepository) *Converter {
+	return &Converter{rates: rates, cache: map[string]*conversion{}}
+}
+
+// Convert converts minor units of one currency into minor units of another,
+// using the latest rate published on or before date, rounded half away from
+// zero. It returns ErrNoRate when no rate, direct, inverse or through a
+// common base currency, is known.
+func (c *Converter) Convert(minor int64, from, to string, date Date) (*ConvertedAmount, error) {
+	conv, err := c.lookup(from, to, date)
+	if err != nil {
+		return nil, err
+	}
+
+	// minor / 10^from digits * rate * 10^to digits
+	value := new(big.Rat).SetInt64(minor)
+	value.Mul(value, conv.rate)
+	value.Mul(value, new(big.Rat).SetFrac(pow10(MinorUnits(to)), pow10(MinorUnits(from))))
+	return &ConvertedAmount{
+		Amount:   roundRat(value),
+		Currency: to,
+		Rate:     trimRate(conv.rate),
+		RateDate: conv.date,
+	}, nil
+}
+
+// Rate returns the rate that applied on date to convert from one currency into
+// another, and the date it was published on
+func (c *Converter) Rate(from, to string, date Date) (string, Date, error) {
+	conv, err := c.lookup(from, to, date)
+	if err != nil {
+		return "", Date{}, err
+	}
+	return trimRate(conv.rate), conv.date, nil
+}
+
+func (c *Converter) lookup(from, to string, date Date) (*conversion, error) {
+	if from == to {
+		return &conversion{rate: big.NewRat(1, 1), date: date}, nil
+	}
+	key := from + to + date.String()
+	if conv, ok := c.cache[key]; ok {
+		if conv == nil {
+			return nil, ErrNoRate
+		}
+		return conv, nil
+	}
+
+	conv, err := c.find(from, to, date)
+	if errors.Is(err, ErrNoRate) {
+		c.cache[key] = nil
+	} else if err == nil {
+		c.cache[key] = conv
+	}
+	return conv, err
+}
+
+func (c *Converter) find(from, to string, date Date) (*conversion, error) {
+	if conv, err := c.hop(from, to, date); !errors.Is(err, ErrNoRate) {
+		return conv, err
+	}
+
+	// through a common base, e.g. USD to NGN with both published against EUR
+	bases, err := c.rates.Bases()
+	if err != nil {
+		return nil, err
+	}
+	for _, base := range bases {
+		if base == from || base == to {
+			continue
+		}
+		first, err := c.hop(from, base, date)
+		if errors.Is(err, ErrNoRate) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		second, err := c.hop(base, to, date)
+		if errors.Is(err, ErrNoRate) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// the conversion is as old as the oldest of the two rates
+		rateDate := first.date
+		if second.date.Before(rateDate.Time) {
+			rateDate = second.date
+		}
+		return &conversion{rate: new(big.Rat).Mul(first.rate, second.rate), date: rateDate}, nil
+	}
+	return nil, ErrNoRate
+}
+
+// hop converts with a single stored rate, published either the way round it
+// is needed or the other way round
+func (c *Converter) hop(from, to string, date Date) (*conversion, error) {
+	inverse := false
+	rate, err := c.rates.FindLatest(from, to, date)
+	if errors.Is(err, ErrNotFound) {
+		inverse = true
+		rate, err = c.rates.FindLatest(to, from, date)
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNoRate
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := parseRate(rate.Rate)
+	if err != nil {
+		return nil, err
+	}
+	if inverse {
+		r.Inv(r)
+	}
+	return &conversion{rate: r, date: rate.Date}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundRat rounds to the nearest integer, halves away from zero
+func roundRat(r *big.Rat) int64 {
+	num, den := new(big.Int).Abs(r.Num()), r.Denom()
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Mul(m, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+// trimRate formats a rate with up to ten decimal places and no trailing zeros
+func trimRate(r *big.Rat) string {
+	s := r.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// ratesUpload is one entry of a JSON rates upload
+type ratesUpload struct {
+	Base  string                 `json:"base"`
+	Date  string                 `json:"date"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// ParseRatesJSON reads rates as {"base": "EUR", "date": "2026-10-16",
+// "rates": {"USD": 1.0823}}, or an array of such objects
+func ParseRatesJSON(r io.Reader) ([]ExchangeRate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var uploads []ratesUpload
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &uploads)
+	} else {
+		uploads = make([]ratesUpload, 1)
+		err = json.Unmarshal(data, &uploads[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates JSON: %w", err)
+	}
+
+	var rates []ExchangeRate
+	for _, upload := range uploads {
+		date, err := ParseDate(upload.Date, "")
+		if err != nil {
+			return nil, err
+		}
+		for quote, value := range upload.Rates {
+			rate, err := NewExchangeRate(date, upload.Base, quote, value.String())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", upload.Date, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// ParseRatesFile reads a rates file, CSV files use the ECB layout with the
+// given base currency and any other file is read as JSON
+func ParseRatesFile(path, base string) ([]ExchangeRate, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ParseRatesCSV(file, base)
+	}
+	rates, err := ParseRatesJSON(file)
+	return rates, nil, err
+}
+
+// ecbDateLayouts covers the daily (16 October 2026) and historical
+// (2026-10-16) reference rate files published by the ECB
+var ecbDateLayouts = []string{ISODate, "2 January 2006", "02 January 2006"}
+
+// ParseRatesCSV reads rates in the layout of the ECB reference rate files: a
+// Date column followed by one column per quote currency, each row holding
+// the value of one unit of base on that date. Empty and N/A cells are
+// skipped, as are columns of currencies that are no longer in use, which are
+// returned so callers can report them.
+func ParseRatesCSV(r io.Reader, base string) ([]ExchangeRate, []string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid rates CSV: %w", err)
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, nil, errors.New("invalid rates CSV: the first column must be Date")
+	}
+	var skipped []string
+	for i := range header {
+		header[i] = strings.ToUpper(strings.TrimSpace(header[i]))
+		if i > 0 && header[i] != "" && !ValidCurrency(header[i]) {
+			skipped = append(skipped, header[i])
+			header[i] = ""
+		}
+	}
+
+	var rates []ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid rates CSV: %w", err)
+		}
+
+		var date time.Time
+		for _, layout := range ecbDateLayouts {
+			if date, err = time.Parse(layout, strings.TrimSpace(record[0])); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: invalid date %q", line, record[0])
+		}
+		for i := 1; i < len(record) && i < len(header); i++ {
+			quote, value := header[i], strings.TrimSpace(record[i])
+			if quote == "" || value == "" || strings.EqualFold(value, "N/A") {
+				continue
+			}
+			rate, err := NewExchangeRate(NewDate(date), base, quote, value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, skipped, nil
+}
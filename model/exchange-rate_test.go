@@ -0,0 +1,53 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewExchangeRate(t *testing.T) {
+	date := Date{time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		rate, want string
+		err        string
+	}{
+		{"1.08", "1.0800000000", ""},
+		{" 1650.5 ", "1650.5000000000", ""},
+		{"0.0000000001", "0.0000000001", ""},
+		// rates are rounded to ten decimal places
+		{"0.00000000005", "0.0000000001", ""},
+		{"0.00000000004", "", "smallest rate that can be stored"},
+		{"0.00000000000000000001", "", "smallest rate that can be stored"},
+		{"0", "", "invalid rate"},
+		{"-1.08", "", "invalid rate"},
+		{"abc", "", "invalid rate"},
+		{"1/3", "", "invalid rate"},
+		// exponents and long numbers are refused before they are parsed
+		{"1e-3", "", "invalid rate"},
+		{"1e1000000000", "", "invalid rate"},
+		{strings.Repeat("9", 21), "", "invalid rate"},
+		{"1." + strings.Repeat("0", 21), "", "invalid rate"},
+	}
+	for _, tt := range tests {
+		got, err := NewExchangeRate(date, "eur", "usd", tt.rate)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got %s, %v, want error %q", tt.rate, got.Rate, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.Rate != tt.want || got.Base != "EUR" || got.Quote != "USD" {
+			t.Errorf("%q: got %s %s %s, %v, want EUR USD %s", tt.rate, got.Base, got.Quote, got.Rate, err, tt.want)
+		}
+	}
+
+	for _, currencies := range [][2]string{{"EUR", "EUR"}, {"EUR", "XXX"}, {"", "USD"}} {
+		if _, err := NewExchangeRate(date, currencies[0], currencies[1], "1.08"); err == nil {
+			t.Errorf("%v: got no error", currencies)
+		}
+	}
+	if _, err := NewExchangeRate(Date{}, "EUR", "USD", "1.08"); err == nil {
+		t.Error("a rate without a date was accepted")
+	}
+}
@@ -0,0 +1,308 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formats of an expense export
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+	ExportXLSX  = "xlsx"
+	// plain text accounting journals
+	ExportLedger    = "ledger"
+	ExportHledger   = "hledger"
+	ExportBeancount = "beancount"
+)
+
+// ExportColumns are the columns an expense export may hold. convertedAmount
+// and baseCurrency give the amount in the user's base currency, empty when no
+// rate is known.
+var ExportColumns = []string{
+	"id", "date", "title", "description", "amount", "currency", "category", "categoryId", "tags",
+	"convertedAmount", "baseCurrency", "recurringId", "importId", "createdAt", "updatedAt",
+}
+
+// DefaultExportColumns are the columns of an export that does not choose
+var DefaultExportColumns = []string{"date", "title", "description", "category", "tags", "amount", "currency"}
+
+// ExportOptions tells how an expense export is laid out
+type ExportOptions struct {
+	Columns []string
+	// DateFormat is one of DateFormats, dates are written as YYYY-MM-DD
+	// without it. XLSX files hold real dates shown in the format.
+	DateFormat string
+	// DecimalSeparator is "." (default) or "," for the amounts of CSV
+	// files, JSON Lines and XLSX files hold numbers
+	DecimalSeparator string
+	// Delimiter separates the fields of CSV files, a comma by default
+	Delimiter string
+	// BOM starts CSV files with a UTF-8 byte order mark, which Excel needs
+	// to read them as UTF-8
+	BOM bool
+	// Accounts are the accounts of the categories by ID, and FundingAccount
+	// the account expenses are paid from, in journals
+	Accounts       map[uint]string
+	FundingAccount string
+}
+
+// Normalize fills in the defaults of the options and returns the first
+// problem found with them, or nil
+func (o *ExportOptions) Normalize() error {
+	if len(o.Columns) == 0 {
+		o.Columns = DefaultExportColumns
+	}
+	if o.DecimalSeparator == "" {
+		o.DecimalSeparator = "."
+	}
+	if o.Delimiter == "" {
+		o.Delimiter = ","
+		if o.DecimalSeparator == "," {
+			o.Delimiter = ";"
+		}
+	}
+	if o.FundingAccount == "" {
+		o.FundingAccount = DefaultFundingAccount
+	}
+	if err := ValidateAccount(o.FundingAccount); err != nil {
+		return errors.New("invalid funding account, " + err.Error())
+	}
+	for _, column := range o.Columns {
+		if !slices.Contains(ExportColumns, column) {
+			return fmt.Errorf("unknown column %q, the columns are %s", column, strings.Join(ExportColumns, ", "))
+		}
+	}
+	delimiter, size := utf8.DecodeRuneInString(o.Delimiter)
+	switch {
+	case o.DateFormat != "" && !ValidDateFormat(o.DateFormat):
+		return errors.New("the date format must be one of " + strings.Join(DateFormatNames(), ", "))
+	case o.DecimalSeparator != "." && o.DecimalSeparator != ",":
+		return errors.New(`the decimal separator must be "." or ","`)
+	case size != len(o.Delimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n':
+		return errors.New("the delimiter must be a single character other than a quote or a line break")
+	case o.Delimiter == o.DecimalSeparator:
+		return errors.New("the decimal separator and the delimiter must differ")
+	}
+	return nil
+}
+
+// ExpenseWriter writes expenses one at a time into an export file, so the
+// export never holds more than the expenses it is given
+type ExpenseWriter interface {
+	Write(expense *ExpenseData) error
+	// Flush writes out what is buffered
+	Flush() error
+	// Close completes the file, it does not close the underlying writer
+	Close() error
+}
+
+// NewExpenseWriter starts an export file in the format, options must have
+// been normalized
+func NewExpenseWriter(w io.Writer, format string, options ExportOptions) (ExpenseWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCSVExpenseWriter(w, options)
+	case ExportJSONL:
+		return &jsonlExpenseWriter{out: bufio.NewWriter(w), options: options}, nil
+	case ExportXLSX:
+		return newXLSXExpenseWriter(w, options)
+	case ExportLedger, ExportHledger, ExportBeancount:
+		return newLedgerExpenseWriter(w, format, options), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// exportMoney is an amount in minor units of its currency
+type exportMoney struct {
+	amount   int64
+	currency string
+}
+
+// exportValue returns the value of a column of the expense: a string, Date,
+// time.Time, exportMoney, uint, []string or nil
+func exportValue(e *ExpenseData, column string) interface{} {
+	switch column {
+	case "id":
+		return e.ID
+	case "date":
+		return e.Date
+	case "title":
+		return e.Title
+	case "description":
+		return e.Description
+	case "amount":
+		return exportMoney{e.Amount, e.Currency}
+	case "currency":
+		return e.Currency
+	case "category":
+		return e.Category
+	case "categoryId":
+		return e.CategoryId
+	case "tags":
+		if e.Tags == nil {
+			return []string{}
+		}
+		return e.Tags
+	case "convertedAmount":
+		if e.Converted != nil {
+			return exportMoney{e.Converted.Amount, e.Converted.Currency}
+		}
+	case "baseCurrency":
+		if e.Converted != nil {
+			return e.Converted.Currency
+		}
+	case "recurringId":
+		if e.RecurringId != nil {
+			return *e.RecurringId
+		}
+	case "importId":
+		if e.ImportId != nil {
+			return *e.ImportId
+		}
+	case "createdAt":
+		return e.CreatedAt
+	case "updatedAt":
+		return e.UpdatedAt
+	}
+	return nil
+}
+
+// formatDate writes a date in the format of the options
+func (o *ExportOptions) formatDate(d Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	if layout, ok := DateFormats[o.DateFormat]; ok {
+		return d.Format(layout)
+	}
+	return d.String()
+}
+
+// text writes a value as the text of a CSV field
+func (o *ExportOptions) text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case Date:
+		return o.formatDate(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case exportMoney:
+		return strings.Replace(FormatAmount(v.amount, v.currency), ".", o.DecimalSeparator, 1)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case []string:
+		return strings.Join(v, ";")
+	}
+	return ""
+}
+
+type csvExpenseWriter struct {
+	out     *csv.Writer
+	options ExportOptions
+}
+
+func newCSVExpenseWriter(w io.Writer, options ExportOptions) (*csvExpenseWriter, error) {
+	if options.BOM {
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return nil, err
+		}
+	}
+	out := csv.NewWriter(w)
+	out.Comma, _ = utf8.DecodeRuneInString(options.Delimiter)
+	if err := out.Write(options.Columns); err != nil {
+		return nil, err
+	}
+	return &csvExpenseWriter{out: out, options: options}, nil
+}
+
+func (c *csvExpenseWriter) Write(e *ExpenseData) error {
+	record := make([]string, len(c.options.Columns))
+	for i, column := range c.options.Columns {
+		value := exportValue(e, column)
+		record[i] = c.options.text(value)
+		switch value.(type) {
+		case string, []string:
+			record[i] = csvText(record[i])
+		}
+	}
+	return c.out.Write(record)
+}
+
+// csvFormulaStart holds the characters spreadsheets take as the start of a
+// formula
+const csvFormulaStart = "=+-@\t\r"
+
+// csvText prefixes text starting like a formula with a quote, so that
+// spreadsheets show it instead of running it. Amounts are not text, negative
+// amounts stay numbers.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune(csvFormulaStart, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func (c *csvExpenseWriter) Flush() error {
+	c.out.Flush()
+	return c.out.Error()
+}
+
+func (c *csvExpenseWriter) Close() error {
+	return c.Flush()
+}
+
+type jsonlExpenseWriter struct {
+	out     *bufio.Writer
+	options ExportOptions
+}
+
+func (j *jsonlExpenseWriter) Write(e *ExpenseData) error {
+	var line strings.Builder
+	line.WriteByte('{')
+	for i, column := range j.options.Columns {
+		var value interface{}
+		switch v := exportValue(e, column).(type) {
+		case Date:
+			if !v.IsZero() {
+				value = j.options.formatDate(v)
+			}
+		case exportMoney:
+			// exact decimal numbers, not floats
+			value = json.RawMessage(FormatAmount(v.amount, v.currency))
+		default:
+			value = v
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		line.WriteString(strconv.Quote(column))
+		line.WriteByte(':')
+		line.Write(encoded)
+	}
+	line.WriteString("}\n")
+	_, err := j.out.WriteString(line.String())
+	return err
+}
+
+func (j *jsonlExpenseWriter) Flush() error {
+	return j.out.Flush()
+}
+
+func (j *jsonlExpenseWriter) Close() error {
+	return j.out.Flush()
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestCSVExportFormulas(t *testing.T) {
+	expenses := []ExpenseData{
+		{Title: "=HYPERLINK(\"http://example.com\")", Description: "+1 for lunch", Category: "@Food", Tags: []string{"-x", "y"}, Amount: -320, Currency: "USD"},
+		{Title: "\tTaxi", Description: "\rnote", Category: "Travel", Amount: 1250, Currency: "USD"},
+		{Title: "Rent", Description: "a = b + c", Category: "Housing", Amount: 100000, Currency: "USD"},
+	}
+	want := [][]string{
+		{"date", "title", "description", "category", "tags", "amount", "currency"},
+		// text starting like a formula is quoted, negative amounts are not
+		{"", "'=HYPERLINK(\"http://example.com\")", "'+1 for lunch", "'@Food", "'-x;y", "-3.20", "USD"},
+		{"", "'\tTaxi", "'\rnote", "Travel", "", "12.50", "USD"},
+		{"", "Rent", "a = b + c", "Housing", "", "1000.00", "USD"},
+	}
+
+	options := ExportOptions{}
+	if err := options.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w, err := NewExpenseWriter(&out, ExportCSV, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range expenses {
+		if err := w.Write(&expenses[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if strings.Join(got[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Sort fields accepted by ExpenseRepository.List
+const (
+	SortByDate     = "date"
+	SortByAmount   = "amount"
+	SortByTitle    = "title"
+	SortByCategory = "category"
+	SortById       = "id"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// does not match the requested sort field
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ExpenseFilter narrows down the expenses of a single user. Zero values leave
+// the corresponding criterion out of the query.
+type ExpenseFilter struct {
+	UserId    int64
+	StartDate Date // inclusive
+	EndDate   Date // inclusive
+	Category  string
+	// CategoryIds matches expenses linked to any of the categories
+	CategoryIds []uint
+	// MinAmount and MaxAmount are inclusive bounds with AmountScale decimal
+	// places, compared with the amount of each expense in its own currency
+	MinAmount *int64
+	MaxAmount *int64
+	Search    string // matched against the title and description
+	// Tags matches expenses carrying any of the tags, or all of them when
+	// AllTags is set
+	Tags    []string
+	AllTags bool
+	// RecurringId matches the expenses generated from a recurring expense
+	RecurringId uint
+}
+
+// ExpenseQuery is an ExpenseFilter together with its ordering and page. When
+// Cursor is set it takes precedence over Offset.
+type ExpenseQuery struct {
+	ExpenseFilter
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// ExpensePage is one page of an expense listing
+type ExpensePage struct {
+	Expenses []ExpenseData
+	// Total is the number of expenses matching the filter across all pages
+	Total int64
+	// NextCursor resumes the listing after the last expense of this page, it
+	// is empty when there are no more results
+	NextCursor string
+}
+
+// DailyTotal is the number and total amount of a user's expenses of a group
+// on one day in one currency
+type DailyTotal struct {
+	Date Date
+	// Key is the key of the group, as in GroupTotal
+	Key      string
+	Currency string
+	Count    int64
+	// Total is in minor units of Currency
+	Total int64
+}
+
+// expenseCursor is the decoded form of ExpensePage.NextCursor
+type expenseCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v,omitempty"`
+	Id    uint        `json:"id"`
+}
+
+func encodeCursor(c expenseCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s, sort string) (*expenseCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c expenseCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	// JSON numbers decode as float64, amounts are compared as integers
+	if v, ok := c.Value.(float64); ok {
+		c.Value = int64(v)
+	}
+	return &c, nil
+}
+
+// sortValue returns the value of the sort field for the given expense, it is
+// what a cursor records to resume a listing
+func sortValue(e ExpenseData, sort string) interface{} {
+	switch sort {
+	case SortByDate:
+		return e.Date.Format(ISODate)
+	case SortByAmount:
+		return scaledAmount(e.Amount, e.Currency)
+	case SortByTitle:
+		return e.Title
+	case SortByCategory:
+		return e.Category
+	}
+	return nil
+}
+
+// nextCursor returns the cursor following the last expense of a page, or an
+// empty string when the page is the last one
+func nextCursor(expenses []ExpenseData, q ExpenseQuery, hasMore bool) string {
+	if !hasMore || len(expenses) == 0 {
+		return ""
+	}
+	last := expenses[len(expenses)-1]
+	return encodeCursor(expenseCursor{Sort: q.Sort, Value: sortValue(last, q.Sort), Id: last.ID})
+}
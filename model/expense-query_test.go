@@ -0,0 +1,120 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []expenseCursor{
+		{Sort: SortByDate, Value: "2026-10-15", Id: 3},
+		{Sort: SortByAmount, Value: int64(-12345678), Id: 4},
+		{Sort: SortByTitle, Value: "Café, \"quoted\"", Id: 5},
+		{Sort: SortById, Id: 6},
+	}
+	for _, want := range tests {
+		got, err := decodeCursor(encodeCursor(want), want.Sort)
+		if err != nil || *got != want {
+			t.Errorf("%+v: got %+v, %v", want, got, err)
+		}
+	}
+
+	bad := map[string]string{
+		"not base64":    "!!!",
+		"not JSON":      base64.RawURLEncoding.EncodeToString([]byte("{")),
+		"padded base64": base64.URLEncoding.EncodeToString([]byte(`{"s":"id","id":1}`)),
+		"other sort":    encodeCursor(expenseCursor{Sort: SortByTitle, Value: "a", Id: 1}),
+		"empty JSON":    base64.RawURLEncoding.EncodeToString([]byte("{}")),
+		"JSON array":    base64.RawURLEncoding.EncodeToString([]byte("[1]")),
+	}
+	for name, cursor := range bad {
+		if _, err := decodeCursor(cursor, SortByDate); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestListPages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		expenses := createExpenses(t, store, 1,
+			ExpenseData{Title: "b", Amount: 1000, Date: testDate("2026-10-02")},
+			ExpenseData{Title: "a", Amount: 500, Date: testDate("2026-10-01")},
+			// the same date, amount and title as the first expense
+			ExpenseData{Title: "b", Amount: 1000, Date: testDate("2026-10-02")},
+			// 10 yen and 10 dollars compare as the same amount
+			ExpenseData{Title: "c", Amount: 10, Currency: "JPY", Date: testDate("2026-10-01")},
+			ExpenseData{Title: "a", Amount: 250, Currency: "EUR", Date: testDate("2026-10-03")},
+			// expenses whose date was cleared sort first
+			ExpenseData{Title: "d", Amount: 100},
+			ExpenseData{Title: "d", Amount: 100},
+		)
+
+		tests := []struct {
+			sort string
+			want []int
+		}{
+			{SortById, []int{0, 1, 2, 3, 4, 5, 6}},
+			{SortByDate, []int{5, 6, 1, 3, 0, 2, 4}},
+			{SortByAmount, []int{5, 6, 4, 1, 0, 2, 3}},
+			{SortByTitle, []int{1, 4, 0, 2, 3, 5, 6}},
+			{SortByCategory, []int{0, 1, 2, 3, 4, 5, 6}},
+		}
+		for _, tt := range tests {
+			for _, desc := range []bool{false, true} {
+				want := []uint{}
+				for _, i := range tt.want {
+					want = append(want, expenses[i].ID)
+				}
+				if desc {
+					// ties are broken by the ID in the same direction
+					slices.Reverse(want)
+				}
+
+				query := ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: 1}, Sort: tt.sort, Desc: desc, Limit: 2}
+				got := []uint{}
+				for pages := 0; pages < len(want); pages++ {
+					page, err := store.Expenses.List(query)
+					if err != nil {
+						t.Fatalf("%s desc %t: %v", tt.sort, desc, err)
+					}
+					if page.Total != int64(len(want)) {
+						t.Errorf("%s desc %t: got total %d, want %d", tt.sort, desc, page.Total, len(want))
+					}
+					got = append(got, expenseIds(page.Expenses)...)
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("%s desc %t: got %v, want %v", tt.sort, desc, got, want)
+				}
+
+				// offsets page through the same order
+				query.Cursor, query.Offset, query.Limit = "", 3, 3
+				page, err := store.Expenses.List(query)
+				if err != nil || !slices.Equal(expenseIds(page.Expenses), want[3:6]) {
+					t.Errorf("%s desc %t offset 3: got %v, %v, want %v", tt.sort, desc, expenseIds(page.Expenses), err, want[3:6])
+				}
+			}
+		}
+
+		// a cursor only resumes the listing it was returned by
+		page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: 1}, Sort: SortByDate, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, query := range []ExpenseQuery{
+			{Sort: SortByTitle, Cursor: page.NextCursor},
+			{Sort: SortByDate, Cursor: "not a cursor"},
+			{Sort: SortByDate, Cursor: page.NextCursor[:len(page.NextCursor)-2]},
+		} {
+			query.UserId, query.Limit = 1, 2
+			if _, err := store.Expenses.List(query); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("%s cursor %q: got %v, want ErrInvalidCursor", query.Sort, query.Cursor, err)
+			}
+		}
+	})
+}
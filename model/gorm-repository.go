@@ -0,0 +1,1543 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+type gormExpenseRepository struct {
+	db *gorm.DB
+}
+
+type gormCategoryRepository struct {
+	db *gorm.DB
+}
+
+type gormTagRepository struct {
+	db *gorm.DB
+}
+
+type gormRecurringRepository struct {
+	db *gorm.DB
+}
+
+type gormBudgetRepository struct {
+	db *gorm.DB
+}
+
+type gormTokenRepository struct {
+	db *gorm.DB
+}
+
+type gormEmailTokenRepository struct {
+	db *gorm.DB
+}
+
+type gormMFARepository struct {
+	db *gorm.DB
+}
+
+type gormAPITokenRepository struct {
+	db *gorm.DB
+}
+
+type gormRateRepository struct {
+	db *gorm.DB
+}
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+type gormExportRepository struct {
+	db *gorm.DB
+}
+
+type gormImportMappingRepository struct {
+	db *gorm.DB
+}
+
+type gormImportRepository struct {
+	db *gorm.DB
+}
+
+// gormLoginLimiter keeps the throttles in the database so that every instance
+// of the API sees the same failures
+type gormLoginLimiter struct {
+	db *gorm.DB
+}
+
+// NewGormStore migrates the schema and returns a store backed by the given
+// gorm connection, which may point at MySQL or SQLite. It fails when the
+// stored data still needs one of the data migrations.
+func NewGormStore(db *gorm.DB) (*Store, error) {
+	db.DB().SetConnMaxLifetime(10 * time.Minute)
+	db.DB().SetMaxIdleConns(10)
+	db.DB().SetMaxOpenConns(100)
+	if err := db.AutoMigrate(&UserData{}, &ExpenseData{}, &Category{}, &Tag{}, &ExpenseTag{}, &RecurringExpense{}, &RecurringOverride{}, &Budget{}, &RefreshToken{}, &RevokedToken{}, &EmailToken{}, &MFASettings{}, &RecoveryCode{}, &MFAChallenge{}, &APIToken{}, &ExchangeRate{}, &LoginThrottle{}, &AuditEntry{}, &DataExport{}, &DataExportFile{}, &ImportMapping{}, &Import{}, &ImportRow{}).Error; err != nil {
+		return nil, err
+	}
+	if err := checkMigrations(db); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Users:          &gormUserRepository{db: db},
+		Expenses:       &gormExpenseRepository{db: db},
+		Categories:     &gormCategoryRepository{db: db},
+		Tags:           &gormTagRepository{db: db},
+		Recurring:      &gormRecurringRepository{db: db},
+		Budgets:        &gormBudgetRepository{db: db},
+		Tokens:         &gormTokenRepository{db: db},
+		EmailTokens:    &gormEmailTokenRepository{db: db},
+		MFA:            &gormMFARepository{db: db},
+		APITokens:      &gormAPITokenRepository{db: db},
+		Rates:          &gormRateRepository{db: db},
+		Exports:        &gormExportRepository{db: db},
+		ImportMappings: &gormImportMappingRepository{db: db},
+		Imports:        &gormImportRepository{db: db},
+		Logins:         &gormLoginLimiter{db: db},
+		Audit:          &gormAuditRepository{db: db},
+	}, nil
+}
+
+// notFound maps gorm's missing record error onto ErrNotFound
+func notFound(err error) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *gormUserRepository) Create(user *UserData) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUserRepository) FindById(id int64) (*UserData, error) {
+	var user UserData
+	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByEmail(email string) (*UserData, error) {
+	var user UserData
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Update(user *UserData) error {
+	return r.db.Save(user).Error
+}
+
+func (r *gormUserRepository) DueForDeletion(now time.Time) ([]UserData, error) {
+	var users []UserData
+	err := r.db.Unscoped().Where("delete_at <= ? OR deleted_at IS NOT NULL", now.UTC()).Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepository) Purge(id int64) error {
+	var user UserData
+	if err := r.db.Unscoped().Where("id = ?", id).First(&user).Error; err != nil {
+		return notFound(err)
+	}
+
+	tx := r.db.Begin()
+	// the soft-deleted rows go as well, hence Unscoped throughout
+	expenses := tx.Unscoped().Model(&ExpenseData{}).Select("id").Where("user_id = ?", id).SubQuery()
+	recurring := tx.Unscoped().Model(&RecurringExpense{}).Select("id").Where("user_id = ?", id).SubQuery()
+	exports := tx.Model(&DataExport{}).Select("id").Where("user_id = ?", id).SubQuery()
+	imports := tx.Model(&Import{}).Select("id").Where("user_id = ?", id).SubQuery()
+	deletions := []struct {
+		model interface{}
+		where string
+		arg   interface{}
+	}{
+		{&ExpenseTag{}, "expense_id IN ?", expenses},
+		{&RecurringOverride{}, "recurring_id IN ?", recurring},
+		{&DataExportFile{}, "export_id IN ?", exports},
+		{&ImportRow{}, "import_id IN ?", imports},
+		{&ExpenseData{}, "user_id = ?", id},
+		{&RecurringExpense{}, "user_id = ?", id},
+		{&Tag{}, "user_id = ?", id},
+		{&Category{}, "user_id = ?", id},
+		{&Budget{}, "user_id = ?", id},
+		{&DataExport{}, "user_id = ?", id},
+		{&Import{}, "user_id = ?", id},
+		{&ImportMapping{}, "user_id = ?", id},
+		{&RefreshToken{}, "user_id = ?", id},
+		{&EmailToken{}, "user_id = ?", id},
+		{&MFASettings{}, "user_id = ?", id},
+		{&RecoveryCode{}, "user_id = ?", id},
+		{&MFAChallenge{}, "user_id = ?", id},
+		{&APIToken{}, "user_id = ?", id},
+		{&LoginThrottle{}, "throttle_key = ?", AccountLoginKey(user.Email)},
+		{&UserData{}, "id = ?", id},
+	}
+	for _, d := range deletions {
+		if err := tx.Unscoped().Where(d.where, d.arg).Delete(d.model).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormUserRepository) Search(q UserQuery) (*UserPage, error) {
+	query := r.db.Model(&UserData{})
+	if q.Search != "" {
+		pattern := "%" + q.Search + "%"
+		query = query.Where("(email LIKE ? OR first_name LIKE ? OR last_name LIKE ?)", pattern, pattern, pattern)
+	}
+	if q.Role != "" {
+		query = query.Where("role = ?", q.Role)
+	}
+	if q.Disabled != nil {
+		if *q.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	page := &UserPage{Users: []UserData{}}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := query.Order("id").Limit(q.Limit).Offset(q.Offset).Find(&page.Users).Error
+	return page, err
+}
+
+func (r *gormUserRepository) Stats(id int64) (*UserStats, error) {
+	stats := &UserStats{}
+	counts := []struct {
+		model interface{}
+		count *int64
+	}{
+		{&ExpenseData{}, &stats.Expenses},
+		{&Category{}, &stats.Categories},
+		{&Tag{}, &stats.Tags},
+		{&RecurringExpense{}, &stats.Recurring},
+		{&Budget{}, &stats.Budgets},
+		{&APIToken{}, &stats.APITokens},
+	}
+	for _, c := range counts {
+		if err := r.db.Model(c.model).Where("user_id = ?", id).Count(c.count).Error; err != nil {
+			return nil, err
+		}
+	}
+	err := r.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", id, time.Now().UTC()).
+		Count(&stats.Sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range []string{"date ASC", "date DESC"} {
+		var expense ExpenseData
+		err := r.db.Where("user_id = ? AND date IS NOT NULL", id).Order(order).First(&expense).Error
+		if gorm.IsRecordNotFoundError(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		date := expense.Date
+		if order == "date ASC" {
+			stats.FirstExpense = &date
+		} else {
+			stats.LastExpense = &date
+		}
+	}
+	return stats, nil
+}
+
+func (r *gormExpenseRepository) Create(expense *ExpenseData) error {
+	tx := r.db.Begin()
+	if err := tx.Create(expense).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveTags(tx, expense); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormExpenseRepository) FindById(id int64) (*ExpenseData, error) {
+	var expense ExpenseData
+	if err := r.db.Where("id = ?", id).First(&expense).Error; err != nil {
+		return nil, notFound(err)
+	}
+	expenses := []ExpenseData{expense}
+	if err := loadTags(r.db, expenses); err != nil {
+		return nil, err
+	}
+	return &expenses[0], nil
+}
+
+// saveTags replaces the tags of an expense with expense.Tags, creating the
+// tags its owner does not have yet
+func saveTags(tx *gorm.DB, expense *ExpenseData) error {
+	tags, err := NormalizeTags(expense.Tags)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("expense_id = ?", expense.ID).Delete(&ExpenseTag{}).Error; err != nil {
+		return err
+	}
+	for _, name := range tags {
+		var tag Tag
+		err := tx.Where(Tag{UserId: expense.UserId, Name: name}).FirstOrCreate(&tag).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&ExpenseTag{ExpenseId: expense.ID, TagId: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	expense.Tags = tags
+	return nil
+}
+
+// loadTags fills in the tags of the expenses with a single query
+func loadTags(db *gorm.DB, expenses []ExpenseData) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(expenses))
+	for i := range expenses {
+		ids[i] = expenses[i].ID
+	}
+	rows, err := db.Table("expense_tags").
+		Select("expense_tags.expense_id, tags.name").
+		Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+		Where("expense_tags.expense_id IN (?)", ids).
+		Order("tags.name").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := map[uint][]string{}
+	for rows.Next() {
+		var expenseId uint
+		var name string
+		if err := rows.Scan(&expenseId, &name); err != nil {
+			return err
+		}
+		tags[expenseId] = append(tags[expenseId], name)
+	}
+	for i := range expenses {
+		expenses[i].Tags = tags[expenses[i].ID]
+		if expenses[i].Tags == nil {
+			expenses[i].Tags = []string{}
+		}
+	}
+	return rows.Err()
+}
+
+// sortColumn maps a sort field onto the column to order by
+func sortColumn(sort string) string {
+	switch sort {
+	case SortByAmount:
+		return scaledAmountColumn
+	case SortByDate:
+		// expenses whose date was cleared sort as the zero date, which is
+		// what their cursors record
+		return "COALESCE(date, '0001-01-01')"
+	case SortByTitle, SortByCategory:
+		return sort
+	}
+	return "id"
+}
+
+// filter applies the criteria of an ExpenseFilter to a query
+func (r *gormExpenseRepository) filter(f ExpenseFilter) *gorm.DB {
+	query := r.db.Model(&ExpenseData{}).Where("expense_data.user_id = ?", f.UserId)
+	if !f.StartDate.IsZero() {
+		query = query.Where("date >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("date <= ?", f.EndDate)
+	}
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+	if len(f.CategoryIds) > 0 {
+		query = query.Where("category_id IN (?)", f.CategoryIds)
+	}
+	if f.MinAmount != nil {
+		query = query.Where(scaledAmountColumn+" >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where(scaledAmountColumn+" <= ?", *f.MaxAmount)
+	}
+	if f.Search != "" {
+		pattern := "%" + f.Search + "%"
+		query = query.Where("(title LIKE ? OR description LIKE ?)", pattern, pattern)
+	}
+	if f.RecurringId != 0 {
+		query = query.Where("recurring_id = ?", f.RecurringId)
+	}
+	if len(f.Tags) > 0 {
+		tagged := r.db.Table("expense_tags").
+			Select("expense_tags.expense_id").
+			Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN (?)", f.UserId, f.Tags)
+		if f.AllTags {
+			tagged = tagged.Group("expense_tags.expense_id").Having("COUNT(DISTINCT tags.id) = ?", len(f.Tags))
+		}
+		query = query.Where("expense_data.id IN ?", tagged.SubQuery())
+	}
+	return query
+}
+
+func (r *gormExpenseRepository) List(q ExpenseQuery) (*ExpensePage, error) {
+	page := &ExpensePage{}
+	if err := r.filter(q.ExpenseFilter).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	column, direction, op := sortColumn(q.Sort), "ASC", ">"
+	if q.Desc {
+		direction, op = "DESC", "<"
+	}
+	query := r.filter(q.ExpenseFilter)
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		if column == "id" {
+			query = query.Where("id "+op+" ?", cursor.Id)
+		} else {
+			query = query.Where("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", cursor.Value, cursor.Value, cursor.Id)
+		}
+	} else if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+	if column != "id" {
+		query = query.Order(column + " " + direction)
+	}
+	query = query.Order("id " + direction)
+
+	// fetch one extra row to find out whether another page follows
+	if err := query.Limit(q.Limit + 1).Find(&page.Expenses).Error; err != nil {
+		return nil, err
+	}
+	hasMore := len(page.Expenses) > q.Limit
+	if hasMore {
+		page.Expenses = page.Expenses[:q.Limit]
+	}
+	page.NextCursor = nextCursor(page.Expenses, q, hasMore)
+	return page, loadTags(r.db, page.Expenses)
+}
+
+func (r *gormExpenseRepository) TotalsByTag(f ExpenseFilter) ([]TagTotal, error) {
+	rows, err := r.filter(f).
+		Select("tags.name, expense_data.currency, COUNT(*), SUM(expense_data.amount_minor)").
+		Joins("JOIN expense_tags ON expense_tags.expense_id = expense_data.id").
+		Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+		Group("tags.name, expense_data.currency").
+		Order("tags.name").
+		Order("expense_data.currency").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []TagTotal{}
+	for rows.Next() {
+		var t TagTotal
+		if err := rows.Scan(&t.Tag, &t.Currency, &t.Count, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (r *gormExpenseRepository) DailyTotals(f ExpenseFilter, groupBy string) ([]DailyTotal, error) {
+	query, key, err := r.grouped(r.filter(f), groupBy)
+	if err != nil {
+		return nil, err
+	}
+	query = query.Select("expense_data.date, " + key + ", expense_data.currency, COUNT(*), SUM(expense_data.amount_minor)")
+	if groupBy == GroupByNone {
+		query = query.Group("expense_data.date, expense_data.currency").Order("expense_data.date")
+	} else {
+		query = query.Group("expense_data.date, " + key + ", expense_data.currency").Order("expense_data.date").Order(key)
+	}
+	rows, err := query.Order("expense_data.currency").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []DailyTotal{}
+	for rows.Next() {
+		var t DailyTotal
+		if err := rows.Scan(&t.Date, &t.Key, &t.Currency, &t.Count, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// dateGroupKeys holds the SQL expressions computing the key of each date
+// grouping, per gorm dialect
+var dateGroupKeys = map[string]map[string]string{
+	"sqlite3": {
+		GroupByDay:   "strftime('%Y-%m-%d', expense_data.date)",
+		GroupByWeek:  "date(expense_data.date, '-' || ((CAST(strftime('%w', expense_data.date) AS INTEGER) + 6) % 7) || ' days')",
+		GroupByMonth: "strftime('%Y-%m', expense_data.date)",
+		GroupByYear:  "strftime('%Y', expense_data.date)",
+	},
+	"mysql": {
+		GroupByDay:   "DATE_FORMAT(expense_data.date, '%Y-%m-%d')",
+		GroupByWeek:  "DATE_FORMAT(DATE_SUB(expense_data.date, INTERVAL WEEKDAY(expense_data.date) DAY), '%Y-%m-%d')",
+		GroupByMonth: "DATE_FORMAT(expense_data.date, '%Y-%m')",
+		GroupByYear:  "DATE_FORMAT(expense_data.date, '%Y')",
+	},
+}
+
+// grouped joins what the grouping needs to the query and returns the SQL
+// expression of the group key, an empty string for GroupByNone
+func (r *gormExpenseRepository) grouped(query *gorm.DB, groupBy string) (*gorm.DB, string, error) {
+	switch groupBy {
+	case GroupByNone:
+		return query, "''", nil
+	case GroupByCategory:
+		return query, "expense_data.category", nil
+	case GroupByTag:
+		return query.
+			Joins("JOIN expense_tags ON expense_tags.expense_id = expense_data.id").
+			Joins("JOIN tags ON tags.id = expense_tags.tag_id"), "tags.name", nil
+	}
+	key := dateGroupKeys[r.db.Dialect().GetName()][groupBy]
+	if key == "" {
+		return nil, "", fmt.Errorf("cannot group expenses by %q", groupBy)
+	}
+	return query, key, nil
+}
+
+func (r *gormExpenseRepository) Summarize(f ExpenseFilter, groupBy string) ([]GroupTotal, error) {
+	query, key, err := r.grouped(r.filter(f), groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates := "expense_data.currency, COUNT(*), SUM(expense_data.amount_minor), MIN(expense_data.amount_minor), MAX(expense_data.amount_minor)"
+	if groupBy == GroupByNone {
+		query = query.Select(key + ", " + aggregates).Group("expense_data.currency")
+	} else {
+		query = query.Select(key + ", " + aggregates).Group(key + ", expense_data.currency").Order(key)
+	}
+	rows, err := query.Order("expense_data.currency").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []GroupTotal{}
+	for rows.Next() {
+		var t GroupTotal
+		if err := rows.Scan(&t.Key, &t.Currency, &t.Count, &t.Total, &t.Min, &t.Max); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (r *gormExpenseRepository) Update(expense *ExpenseData) error {
+	tx := r.db.Begin()
+	if err := tx.Save(expense).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveTags(tx, expense); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormExpenseRepository) Delete(id int64) error {
+	tx := r.db.Begin()
+	result := tx.Where("id = ?", id).Delete(&ExpenseData{})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrNotFound
+	}
+	if err := tx.Where("expense_id = ?", id).Delete(&ExpenseTag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormCategoryRepository) Create(category *Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *gormCategoryRepository) Seed(userId int64) error {
+	return seedCategories(r.db, userId)
+}
+
+// seedCategories creates the default categories of a user inside a
+// transaction, unless the user already has categories
+func seedCategories(db *gorm.DB, userId int64) error {
+	var count int
+	if err := db.Model(&Category{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	tx := db.Begin()
+	for _, category := range DefaultCategories {
+		category.UserId = userId
+		if err := tx.Create(&category).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormCategoryRepository) FindById(id int64) (*Category, error) {
+	var category Category
+	if err := r.db.Where("id = ?", id).First(&category).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) FindByName(userId int64, name string) (*Category, error) {
+	var category Category
+	if err := r.db.Where("user_id = ? AND name = ?", userId, name).First(&category).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) List(userId int64) ([]Category, error) {
+	var categories []Category
+	err := r.db.Where("user_id = ?", userId).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) Update(category *Category) error {
+	tx := r.db.Begin()
+	if err := tx.Save(category).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	err := tx.Unscoped().Model(&ExpenseData{}).Where("category_id = ?", category.ID).
+		UpdateColumn("category", category.Name).Error
+	if err == nil {
+		err = tx.Unscoped().Model(&RecurringExpense{}).Where("category_id = ?", category.ID).
+			UpdateColumn("category", category.Name).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormCategoryRepository) Delete(id int64, replacement *Category) error {
+	var category Category
+	if err := r.db.Where("id = ?", id).First(&category).Error; err != nil {
+		return notFound(err)
+	}
+
+	tx := r.db.Begin()
+	err := tx.Unscoped().Model(&ExpenseData{}).Where("category_id = ?", id).
+		UpdateColumns(map[string]interface{}{"category_id": replacement.ID, "category": replacement.Name}).Error
+	if err == nil {
+		err = tx.Unscoped().Model(&RecurringExpense{}).Where("category_id = ?", id).
+			UpdateColumns(map[string]interface{}{"category_id": replacement.ID, "category": replacement.Name}).Error
+	}
+	if err == nil {
+		err = tx.Model(&Budget{}).Where("category_id = ?", id).
+			UpdateColumn("category_id", replacement.ID).Error
+	}
+	if err == nil {
+		err = tx.Model(&Category{}).Where("parent_id = ?", id).
+			UpdateColumn("parent_id", category.ParentId).Error
+	}
+	if err == nil {
+		err = tx.Delete(&category).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormTagRepository) FindById(id int64) (*Tag, error) {
+	var tag Tag
+	if err := r.db.Where("id = ?", id).First(&tag).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &tag, nil
+}
+
+func (r *gormTagRepository) FindByName(userId int64, name string) (*Tag, error) {
+	var tag Tag
+	if err := r.db.Where("user_id = ? AND name = ?", userId, name).First(&tag).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &tag, nil
+}
+
+func (r *gormTagRepository) List(userId int64) ([]Tag, error) {
+	tags := []Tag{}
+	if err := r.db.Where("user_id = ?", userId).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Table("expense_tags").
+		Select("expense_tags.tag_id, COUNT(*)").
+		Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+		Where("tags.user_id = ?", userId).
+		Group("expense_tags.tag_id").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[uint]int64{}
+	for rows.Next() {
+		var id uint
+		var count int64
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	for i := range tags {
+		tags[i].Expenses = counts[tags[i].ID]
+	}
+	return tags, rows.Err()
+}
+
+func (r *gormTagRepository) Update(tag *Tag) error {
+	return r.db.Save(tag).Error
+}
+
+func (r *gormTagRepository) Merge(source, target *Tag) error {
+	var tagged []uint
+	err := r.db.Model(&ExpenseTag{}).
+		Where("tag_id = ? AND expense_id NOT IN ?", source.ID,
+			r.db.Model(&ExpenseTag{}).Select("expense_id").Where("tag_id = ?", target.ID).SubQuery()).
+		Pluck("expense_id", &tagged).Error
+	if err != nil {
+		return err
+	}
+
+	tx := r.db.Begin()
+	for _, expenseId := range tagged {
+		if err := tx.Create(&ExpenseTag{ExpenseId: expenseId, TagId: target.ID}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Where("tag_id = ?", source.ID).Delete(&ExpenseTag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(source).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormTagRepository) Delete(id int64) error {
+	tx := r.db.Begin()
+	if err := tx.Where("tag_id = ?", id).Delete(&ExpenseTag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	result := tx.Where("id = ?", id).Delete(&Tag{})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrNotFound
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormRecurringRepository) Create(recurring *RecurringExpense) error {
+	return r.db.Create(recurring).Error
+}
+
+func (r *gormRecurringRepository) FindById(id int64) (*RecurringExpense, error) {
+	var recurring RecurringExpense
+	if err := r.db.Where("id = ?", id).First(&recurring).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &recurring, nil
+}
+
+func (r *gormRecurringRepository) List(userId int64) ([]RecurringExpense, error) {
+	recurring := []RecurringExpense{}
+	err := r.db.Where("user_id = ?", userId).Order("id").Find(&recurring).Error
+	return recurring, err
+}
+
+func (r *gormRecurringRepository) Update(recurring *RecurringExpense) error {
+	return r.db.Save(recurring).Error
+}
+
+func (r *gormRecurringRepository) Delete(id int64) error {
+	result := r.db.Where("id = ?", id).Delete(&RecurringExpense{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormRecurringRepository) Due(on Date) ([]RecurringExpense, error) {
+	var due []RecurringExpense
+	err := r.db.Select("recurring_expenses.*").
+		Joins("JOIN user_data ON user_data.id = recurring_expenses.user_id AND user_data.deleted_at IS NULL").
+		Where("user_data.disabled_at IS NULL AND user_data.delete_at IS NULL").
+		Where("recurring_expenses.next_date <= ?", on).
+		Where("recurring_expenses.end_date IS NULL OR recurring_expenses.next_date <= recurring_expenses.end_date").
+		Order("recurring_expenses.id").
+		Find(&due).Error
+	return due, err
+}
+
+func (r *gormRecurringRepository) Materialize(recurring *RecurringExpense, expenses []ExpenseData, next Date) (int, error) {
+	tx := r.db.Begin()
+	created := 0
+	for i := range expenses {
+		// deleted expenses count too, a deleted occurrence stays deleted
+		var count int
+		err := tx.Unscoped().Model(&ExpenseData{}).
+			Where("recurring_id = ? AND occurrence_date = ?", recurring.ID, *expenses[i].OccurrenceDate).
+			Count(&count).Error
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := tx.Create(&expenses[i]).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		created++
+	}
+	if err := tx.Model(recurring).UpdateColumn("next_date", next).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	recurring.NextDate = next
+	return created, tx.Commit().Error
+}
+
+func (r *gormRecurringRepository) FindOccurrence(recurringId uint, occurrence Date) (*ExpenseData, error) {
+	var expense ExpenseData
+	err := r.db.Where("recurring_id = ? AND occurrence_date = ?", recurringId, occurrence).First(&expense).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	expenses := []ExpenseData{expense}
+	if err := loadTags(r.db, expenses); err != nil {
+		return nil, err
+	}
+	return &expenses[0], nil
+}
+
+func (r *gormRecurringRepository) Overrides(recurringId uint) ([]RecurringOverride, error) {
+	overrides := []RecurringOverride{}
+	err := r.db.Where("recurring_id = ?", recurringId).Order("occurrence").Find(&overrides).Error
+	return overrides, err
+}
+
+func (r *gormRecurringRepository) SaveOverride(override *RecurringOverride) error {
+	var existing RecurringOverride
+	err := r.db.Where("recurring_id = ? AND occurrence = ?", override.RecurringId, override.Occurrence).First(&existing).Error
+	if err == nil {
+		override.ID = existing.ID
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+	return r.db.Save(override).Error
+}
+
+func (r *gormRecurringRepository) DeleteOverride(recurringId uint, occurrence Date) error {
+	result := r.db.Where("recurring_id = ? AND occurrence = ?", recurringId, occurrence).Delete(&RecurringOverride{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormBudgetRepository) Create(budget *Budget) error {
+	return r.db.Create(budget).Error
+}
+
+func (r *gormBudgetRepository) FindById(id int64) (*Budget, error) {
+	var budget Budget
+	if err := r.db.Where("id = ?", id).First(&budget).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &budget, nil
+}
+
+func (r *gormBudgetRepository) List(userId int64) ([]Budget, error) {
+	budgets := []Budget{}
+	err := r.db.Where("user_id = ?", userId).Order("id").Find(&budgets).Error
+	return budgets, err
+}
+
+func (r *gormBudgetRepository) Update(budget *Budget) error {
+	return r.db.Save(budget).Error
+}
+
+func (r *gormBudgetRepository) Delete(id int64) error {
+	result := r.db.Where("id = ?", id).Delete(&Budget{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormTokenRepository) Create(token *RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *gormTokenRepository) FindByHash(hash string) (*RefreshToken, error) {
+	var token RefreshToken
+	if err := r.db.Where("hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *gormTokenRepository) Rotate(token, next *RefreshToken) error {
+	now := time.Now().UTC()
+	tx := r.db.Begin()
+	// the condition makes the token single-use even under concurrent requests
+	result := tx.Model(&RefreshToken{}).Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", token.ID).
+		UpdateColumn("used_at", now)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrTokenReused
+	}
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+func (r *gormTokenRepository) RevokeFamily(family string) error {
+	return r.revoke("family = ?", family)
+}
+
+func (r *gormTokenRepository) RevokeUser(userId int64) error {
+	return r.revoke("user_id = ?", userId)
+}
+
+// revoke revokes the refresh tokens matching the condition and adds the
+// access tokens issued with them that have not expired to the revocation list
+func (r *gormTokenRepository) revoke(condition string, value interface{}) error {
+	now := time.Now().UTC()
+	tx := r.db.Begin()
+	var tokens []RefreshToken
+	err := tx.Where(condition, value).Where("access_expires_at > ?", now).Find(&tokens).Error
+	for i := 0; err == nil && i < len(tokens); i++ {
+		err = tx.Where(RevokedToken{Jti: tokens[i].AccessId}).
+			FirstOrCreate(&RevokedToken{Jti: tokens[i].AccessId, ExpiresAt: tokens[i].AccessExpiresAt}).Error
+	}
+	if err == nil {
+		err = tx.Model(&RefreshToken{}).Where(condition, value).Where("revoked_at IS NULL").
+			UpdateColumn("revoked_at", now).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormTokenRepository) RevokeAccess(jti string, expiresAt time.Time) error {
+	now := time.Now().UTC()
+	tx := r.db.Begin()
+	// entries of expired tokens are no longer needed
+	err := tx.Where("expires_at <= ?", now).Delete(&RevokedToken{}).Error
+	if err == nil {
+		err = tx.Where(RevokedToken{Jti: jti}).FirstOrCreate(&RevokedToken{Jti: jti, ExpiresAt: expiresAt.UTC()}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormTokenRepository) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&RevokedToken{}).Where("jti = ? AND expires_at > ?", jti, time.Now().UTC()).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormEmailTokenRepository) Create(token *EmailToken) error {
+	tx := r.db.Begin()
+	err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserId, token.Purpose).
+		Delete(&EmailToken{}).Error
+	if err == nil {
+		err = tx.Create(token).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormEmailTokenRepository) Consume(hash, purpose string) (*EmailToken, error) {
+	now := time.Now().UTC()
+	var token EmailToken
+	if err := r.db.Where("hash = ? AND purpose = ?", hash, purpose).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	// the condition makes the token single-use even under concurrent requests
+	result := r.db.Model(&EmailToken{}).Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+		UpdateColumn("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	token.UsedAt = &now
+	return &token, nil
+}
+
+func (r *gormMFARepository) FindSettings(userId int64) (*MFASettings, error) {
+	var settings MFASettings
+	if err := r.db.Where("user_id = ?", userId).First(&settings).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &settings, nil
+}
+
+func (r *gormMFARepository) SaveSettings(settings *MFASettings) error {
+	return r.db.Save(settings).Error
+}
+
+func (r *gormMFARepository) DeleteSettings(userId int64) error {
+	tx := r.db.Begin()
+	if err := tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("user_id = ?", userId).Delete(&MFASettings{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormMFARepository) UseStep(userId, step int64) error {
+	result := r.db.Model(&MFASettings{}).Where("user_id = ? AND last_step < ?", userId, step).
+		UpdateColumn("last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormMFARepository) ReplaceRecoveryCodes(userId int64, hashes []string) error {
+	tx := r.db.Begin()
+	if err := tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hash := range hashes {
+		if err := tx.Create(&RecoveryCode{UserId: userId, Hash: hash}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormMFARepository) UseRecoveryCode(userId int64, hash string) error {
+	result := r.db.Model(&RecoveryCode{}).Where("user_id = ? AND hash = ? AND used_at IS NULL", userId, hash).
+		UpdateColumn("used_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormMFARepository) CountRecoveryCodes(userId int64) (int, error) {
+	var count int
+	err := r.db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&count).Error
+	return count, err
+}
+
+func (r *gormMFARepository) CreateChallenge(challenge *MFAChallenge) error {
+	// expired challenges are of no use anymore
+	if err := r.db.Where("expires_at < ?", time.Now().UTC()).Delete(&MFAChallenge{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(challenge).Error
+}
+
+func (r *gormMFARepository) FindChallenge(hash string) (*MFAChallenge, error) {
+	var challenge MFAChallenge
+	err := r.db.Where("hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?", hash, time.Now().UTC(), MFAChallengeAttempts).
+		First(&challenge).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &challenge, nil
+}
+
+func (r *gormMFARepository) FailChallenge(id uint) error {
+	return r.db.Model(&MFAChallenge{}).Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func (r *gormMFARepository) CompleteChallenge(id uint) error {
+	result := r.db.Model(&MFAChallenge{}).Where("id = ? AND used_at IS NULL", id).
+		UpdateColumn("used_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormAPITokenRepository) Create(token *APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *gormAPITokenRepository) FindByHash(hash string) (*APIToken, error) {
+	var token APIToken
+	if err := r.db.Where("hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *gormAPITokenRepository) List(userId int64) ([]APIToken, error) {
+	tokens := []APIToken{}
+	err := r.db.Where("user_id = ?", userId).Order("created_at DESC, id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *gormAPITokenRepository) Delete(userId int64, id uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userId).Delete(&APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormAPITokenRepository) DeleteByUser(userId int64) error {
+	return r.db.Where("user_id = ?", userId).Delete(&APIToken{}).Error
+}
+
+func (r *gormAPITokenRepository) Touch(id uint, usedAt time.Time) error {
+	return r.db.Model(&APIToken{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
+
+func (r *gormRateRepository) Save(rates []ExchangeRate) error {
+	tx := r.db.Begin()
+	for _, rate := range rates {
+		var stored ExchangeRate
+		err := tx.Where("date = ? AND base = ? AND quote = ?", rate.Date, rate.Base, rate.Quote).
+			Assign(ExchangeRate{Rate: rate.Rate}).
+			FirstOrCreate(&stored, rate).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormRateRepository) FindLatest(base, quote string, on Date) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	err := r.db.Where("base = ? AND quote = ? AND date <= ?", base, quote, on).
+		Order("date DESC").
+		First(&rate).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &rate, nil
+}
+
+func (r *gormRateRepository) Bases() ([]string, error) {
+	var bases []string
+	err := r.db.Model(&ExchangeRate{}).Order("base").Pluck("DISTINCT base", &bases).Error
+	return bases, err
+}
+
+func (r *gormAuditRepository) Record(entry *AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *gormAuditRepository) List(q AuditQuery) (*AuditPage, error) {
+	query := r.db.Model(&AuditEntry{})
+	if q.UserId != 0 {
+		query = query.Where("user_id = ?", q.UserId)
+	}
+	if q.ActorId != 0 {
+		query = query.Where("actor_id = ?", q.ActorId)
+	}
+	if strings.HasSuffix(q.Action, ".") {
+		query = query.Where("action LIKE ?", q.Action+"%")
+	} else if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+
+	page := &AuditPage{Entries: []AuditEntry{}}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(q.Limit).Offset(q.Offset).Find(&page.Entries).Error
+	return page, err
+}
+
+func (r *gormExportRepository) Create(export *DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *gormExportRepository) FindById(id int64) (*DataExport, error) {
+	var export DataExport
+	if err := r.db.Where("id = ?", id).First(&export).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &export, nil
+}
+
+func (r *gormExportRepository) List(userId int64) ([]DataExport, error) {
+	exports := []DataExport{}
+	err := r.db.Where("user_id = ?", userId).Order("id DESC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormExportRepository) Pending() ([]DataExport, error) {
+	var exports []DataExport
+	err := r.db.Where("status = ?", ExportPending).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormExportRepository) Complete(export *DataExport, archive []byte) error {
+	tx := r.db.Begin()
+	// the user may have been deleted while the export was built, Save would
+	// insert the export again
+	var count int64
+	if err := tx.Model(&DataExport{}).Where("id = ?", export.ID).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if count == 0 {
+		tx.Rollback()
+		return ErrNotFound
+	}
+	if archive != nil {
+		if err := tx.Save(&DataExportFile{ExportId: export.ID, Data: archive}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Save(export).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormExportRepository) Archive(id uint) ([]byte, error) {
+	var file DataExportFile
+	if err := r.db.Where("export_id = ?", id).First(&file).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return file.Data, nil
+}
+
+func (r *gormExportRepository) DeleteExpired(now time.Time) (int64, error) {
+	expired := r.db.Model(&DataExport{}).Select("id").Where("expires_at < ?", now.UTC()).SubQuery()
+	tx := r.db.Begin()
+	if err := tx.Where("export_id IN ?", expired).Delete(&DataExportFile{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	result := tx.Where("expires_at < ?", now.UTC()).Delete(&DataExport{})
+	if result.Error != nil {
+		tx.Rollback()
+		return 0, result.Error
+	}
+	return result.RowsAffected, tx.Commit().Error
+}
+
+func (r *gormImportMappingRepository) Create(mapping *ImportMapping) error {
+	return r.db.Create(mapping).Error
+}
+
+func (r *gormImportMappingRepository) FindById(id int64) (*ImportMapping, error) {
+	var mapping ImportMapping
+	if err := r.db.Where("id = ?", id).First(&mapping).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &mapping, nil
+}
+
+func (r *gormImportMappingRepository) List(userId int64) ([]ImportMapping, error) {
+	mappings := []ImportMapping{}
+	err := r.db.Where("user_id = ?", userId).Order("name, id").Find(&mappings).Error
+	return mappings, err
+}
+
+func (r *gormImportMappingRepository) Update(mapping *ImportMapping) error {
+	return r.db.Save(mapping).Error
+}
+
+func (r *gormImportMappingRepository) Delete(id int64) error {
+	result := r.db.Where("id = ?", id).Delete(&ImportMapping{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormImportRepository) Create(imp *Import, rows []ImportRow) error {
+	tx := r.db.Begin()
+	if err := tx.Create(imp).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range rows {
+		rows[i].ImportId = imp.ID
+		if err := tx.Create(&rows[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormImportRepository) FindById(id int64) (*Import, error) {
+	var imp Import
+	if err := r.db.Where("id = ?", id).First(&imp).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &imp, nil
+}
+
+func (r *gormImportRepository) List(userId int64) ([]Import, error) {
+	imports := []Import{}
+	err := r.db.Where("user_id = ?", userId).Order("id DESC").Find(&imports).Error
+	return imports, err
+}
+
+func (r *gormImportRepository) Rows(importId uint) ([]ImportRow, error) {
+	rows := []ImportRow{}
+	err := r.db.Where("import_id = ?", importId).Order("line, id").Find(&rows).Error
+	return rows, err
+}
+
+func (r *gormImportRepository) Commit(imp *Import, expenses []ExpenseData) error {
+	tx := r.db.Begin()
+	// only one commit of the preview goes through
+	now := time.Now().UTC()
+	result := tx.Model(&Import{}).Where("id = ? AND status = ?", imp.ID, ImportPreview).
+		Updates(map[string]interface{}{"status": ImportCommitted, "created": len(expenses), "committed_at": now})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrImportState
+	}
+	for i := range expenses {
+		if err := tx.Create(&expenses[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	imp.Status, imp.Created, imp.CommittedAt = ImportCommitted, len(expenses), &now
+	return nil
+}
+
+func (r *gormImportRepository) Rollback(imp *Import) error {
+	tx := r.db.Begin()
+	now := time.Now().UTC()
+	result := tx.Model(&Import{}).Where("id = ? AND status = ?", imp.ID, ImportCommitted).
+		Updates(map[string]interface{}{"status": ImportRolledBack, "rolled_back_at": now})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrImportState
+	}
+	expenses := tx.Model(&ExpenseData{}).Select("id").Where("import_id = ?", imp.ID).SubQuery()
+	if err := tx.Where("expense_id IN ?", expenses).Delete(&ExpenseTag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("import_id = ?", imp.ID).Delete(&ExpenseData{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	imp.Status, imp.RolledBackAt = ImportRolledBack, &now
+	return nil
+}
+
+func (r *gormImportRepository) Delete(id int64) error {
+	tx := r.db.Begin()
+	result := tx.Where("id = ? AND status <> ?", id, ImportCommitted).Delete(&Import{})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrImportState
+	}
+	if err := tx.Where("import_id = ?", id).Delete(&ImportRow{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormImportRepository) Imported(userId int64, externalIds []string) (map[string]bool, error) {
+	imported := map[string]bool{}
+	// in chunks, databases limit the number of query parameters
+	for start := 0; start < len(externalIds); start += 500 {
+		chunk := externalIds[start:min(start+500, len(externalIds))]
+		var found []string
+		err := r.db.Model(&ExpenseData{}).Where("user_id = ? AND external_id IN (?)", userId, chunk).
+			Pluck("external_id", &found).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			imported[id] = true
+		}
+	}
+	return imported, nil
+}
+
+func (l *gormLoginLimiter) Wait(key string, now time.Time) (time.Duration, error) {
+	var throttle LoginThrottle
+	err := l.db.Where("throttle_key = ?", key).First(&throttle).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return throttle.Wait(now), nil
+}
+
+func (l *gormLoginLimiter) Fail(key string, policy LoginPolicy, now time.Time) (*LoginThrottle, bool, error) {
+	tx := l.db.Begin()
+	if tx.Dialect().GetName() == "mysql" {
+		// concurrent failures of the key wait for each other
+		tx = tx.Set("gorm:query_option", "FOR UPDATE")
+	}
+	throttle := LoginThrottle{Key: key}
+	err := tx.Where("throttle_key = ?", key).First(&throttle).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return nil, false, err
+	}
+	locked := policy.Fail(&throttle, now)
+	if err := tx.Save(&throttle).Error; err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	err = tx.Where("last_failure < ? AND blocked_until <= ?", now.Add(-loginThrottleRetention), now).
+		Delete(&LoginThrottle{}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	return &throttle, locked, tx.Commit().Error
+}
+
+func (l *gormLoginLimiter) Reset(key string) error {
+	return l.db.Where("throttle_key = ?", key).Delete(&LoginThrottle{}).Error
+}
@@ -0,0 +1,578 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Formats of the import files
+const (
+	ImportCSV = "csv"
+	ImportOFX = "ofx"
+	ImportQIF = "qif"
+)
+
+// Statuses of an import. A preview only holds the rows read from the file,
+// committing it creates their expenses and rolling it back deletes them again.
+const (
+	ImportPreview    = "preview"
+	ImportCommitted  = "committed"
+	ImportRolledBack = "rolled-back"
+)
+
+// Statuses of an import row
+const (
+	RowValid   = "valid"
+	RowSkipped = "skipped"
+	RowInvalid = "invalid"
+)
+
+// Sign conventions of the amounts of a statement
+const (
+	// SignNegative is the convention of bank statements, expenses are
+	// negative and credits positive
+	SignNegative = "negative"
+	// SignPositive is the convention of card statements, expenses are
+	// positive and payments negative
+	SignPositive = "positive"
+	// SignDebitCredit puts expenses in a debit column and credits in a
+	// credit column
+	SignDebitCredit = "debit-credit"
+)
+
+// Encodings of the import files
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16       = "utf-16"
+	EncodingISO88591    = "iso-8859-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Limits of an import file
+const (
+	MaxImportSize = 5 << 20
+	MaxImportRows = 10000
+)
+
+// ErrImportState is returned when an import is committed or rolled back
+// while it is not in the state that allows it
+var ErrImportState = errors.New("the import cannot change state")
+
+// ImportMapping tells how the columns of a user's CSV statements map onto
+// expenses, users save one per bank or card. Columns are named by their
+// header, or by their position from 1 when the file has no header.
+type ImportMapping struct {
+	ID     uint   `gorm:"primary_key" json:"id"`
+	UserId int64  `gorm:"index" json:"-"`
+	Name   string `json:"name" example:"Checking account"`
+	// Delimiter separates the fields, a comma by default
+	Delimiter string `json:"delimiter" example:";"`
+	// Encoding is utf-8 (default), utf-16, iso-8859-1 or windows-1252
+	Encoding string `json:"encoding" example:"utf-8"`
+	// NoHeader tells that the first row already holds data
+	NoHeader bool `json:"noHeader"`
+	// SkipRows is the number of lines before the header, such as the
+	// account details some banks put first
+	SkipRows          int    `json:"skipRows"`
+	DateColumn        string `json:"dateColumn" example:"Booking date"`
+	DescriptionColumn string `json:"descriptionColumn" example:"Description"`
+	// TitleColumn is optional, the description is the title without it
+	TitleColumn  string `json:"titleColumn"`
+	AmountColumn string `json:"amountColumn" example:"Amount"`
+	// DebitColumn and CreditColumn replace AmountColumn with the
+	// debit-credit sign convention
+	DebitColumn  string `json:"debitColumn"`
+	CreditColumn string `json:"creditColumn"`
+	// CurrencyColumn is optional, Currency applies to the rows without one
+	CurrencyColumn string `json:"currencyColumn"`
+	Currency       string `json:"currency" example:"EUR"`
+	// DateFormat is one of DateFormats, ISO 8601 dates are always accepted
+	DateFormat string `json:"dateFormat" example:"DD.MM.YYYY"`
+	// Sign is negative (default), positive or debit-credit
+	Sign string `json:"sign" example:"negative"`
+	// DecimalSeparator is "." (default) or ","
+	DecimalSeparator string `json:"decimalSeparator" example:","`
+	// Category is the name of the category the expenses go in, Others by
+	// default
+	Category  string    `json:"category" example:"Others"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Normalize fills in the defaults of the mapping and returns the first
+// problem found with it, or nil
+func (m *ImportMapping) Normalize() error {
+	m.Name = strings.TrimSpace(m.Name)
+	m.Encoding = strings.ToLower(strings.TrimSpace(m.Encoding))
+	m.Currency = strings.ToUpper(strings.TrimSpace(m.Currency))
+	if m.Delimiter == "" {
+		m.Delimiter = ","
+	}
+	if m.Encoding == "" {
+		m.Encoding = EncodingUTF8
+	}
+	if m.Sign == "" {
+		m.Sign = SignNegative
+	}
+	if m.DecimalSeparator == "" {
+		m.DecimalSeparator = "."
+	}
+	if m.Category == "" {
+		m.Category = "Others"
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(m.Delimiter)
+	switch {
+	case m.Name == "":
+		return errors.New("the name is required")
+	case size != len(m.Delimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n':
+		return errors.New("the delimiter must be a single character other than a quote or a line break")
+	case m.Encoding != EncodingUTF8 && m.Encoding != EncodingUTF16 && m.Encoding != EncodingISO88591 && m.Encoding != EncodingWindows1252:
+		return errors.New("the encoding must be utf-8, utf-16, iso-8859-1 or windows-1252")
+	case m.SkipRows < 0:
+		return errors.New("the number of rows to skip cannot be negative")
+	case m.DateColumn == "" || m.DescriptionColumn == "":
+		return errors.New("the date and description columns are required")
+	case m.Sign != SignNegative && m.Sign != SignPositive && m.Sign != SignDebitCredit:
+		return errors.New("the sign must be negative, positive or debit-credit")
+	case m.Sign == SignDebitCredit && m.DebitColumn == "":
+		return errors.New("the debit column is required with the debit-credit sign")
+	case m.Sign != SignDebitCredit && m.AmountColumn == "":
+		return errors.New("the amount column is required")
+	case m.DecimalSeparator != "." && m.DecimalSeparator != ",":
+		return errors.New(`the decimal separator must be "." or ","`)
+	case m.DecimalSeparator == m.Delimiter:
+		return errors.New("the decimal separator and the delimiter must differ")
+	case m.Currency != "" && !ValidCurrency(m.Currency):
+		return errors.New("the currency must be an ISO 4217 code such as USD")
+	case !ValidDateFormat(m.DateFormat):
+		return errors.New("the date format must be one of " + strings.Join(DateFormatNames(), ", "))
+	}
+	return nil
+}
+
+// Import is a statement file read into rows, whose valid rows become expenses
+// once it is committed. The expenses carry the ID of the import, which rolls
+// them back together.
+type Import struct {
+	ID        uint   `gorm:"primary_key" json:"id"`
+	UserId    int64  `gorm:"index" json:"-"`
+	Format    string `json:"format" example:"csv"`
+	MappingId *uint  `json:"mappingId"`
+	// CategoryId and Category are the category the expenses go in
+	CategoryId uint   `json:"categoryId"`
+	Category   string `json:"category"`
+	Status     string `json:"status" example:"preview"`
+	// Valid, Skipped and Invalid count the rows of each status
+	Valid   int `json:"valid"`
+	Skipped int `json:"skipped"`
+	Invalid int `json:"invalid"`
+	// Created is the number of expenses the commit created
+	Created      int        `json:"created"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	CommittedAt  *time.Time `json:"committedAt"`
+	RolledBackAt *time.Time `json:"rolledBackAt"`
+}
+
+// Count sets the row counts of the import
+func (imp *Import) Count(rows []ImportRow) {
+	imp.Valid, imp.Skipped, imp.Invalid = 0, 0, 0
+	for _, row := range rows {
+		switch row.Status {
+		case RowValid:
+			imp.Valid++
+		case RowSkipped:
+			imp.Skipped++
+		default:
+			imp.Invalid++
+		}
+	}
+}
+
+// ImportRow is a row of an import file and the expense it becomes
+type ImportRow struct {
+	ID       uint `gorm:"primary_key" json:"-"`
+	ImportId uint `gorm:"index" json:"-"`
+	// Line is the line of the file the row starts on
+	Line        int    `json:"line"`
+	Date        Date   `json:"date" gorm:"type:date"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Amount is the amount of the expense in minor units of Currency
+	Amount   int64  `json:"amount" gorm:"column:amount_minor"`
+	Currency string `json:"currency" gorm:"type:char(3)"`
+	// ExternalId identifies the transaction at the bank, the account and
+	// FITID of OFX transactions
+	ExternalId string `json:"externalId,omitempty"`
+	// Status is valid, skipped or invalid, Message tells why a row is not
+	// valid
+	Status  string `json:"status" example:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// MarshalJSON writes the amount as an exact decimal number in the currency of
+// the row
+func (r ImportRow) MarshalJSON() ([]byte, error) {
+	type row ImportRow
+	return json.Marshal(struct {
+		row
+		Amount json.RawMessage `json:"amount"`
+	}{row(r), json.RawMessage(FormatAmount(r.Amount, r.Currency))})
+}
+
+// Expense returns the expense a valid row becomes
+func (r ImportRow) Expense(imp *Import) ExpenseData {
+	importId := imp.ID
+	return ExpenseData{
+		Title:       r.Title,
+		Description: r.Description,
+		Amount:      r.Amount,
+		Currency:    r.Currency,
+		Date:        r.Date,
+		CategoryId:  imp.CategoryId,
+		Category:    imp.Category,
+		UserId:      imp.UserId,
+		ImportId:    &importId,
+		ExternalId:  r.ExternalId,
+	}
+}
+
+// DetectImportFormat guesses the format of a statement file from its first
+// bytes: OFX files start with their header, QIF files with a !Type or
+// !Account line and anything else is taken for CSV
+func DetectImportFormat(start []byte) string {
+	start = bytes.TrimLeft(bytes.TrimPrefix(start, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	upper := bytes.ToUpper(start)
+	switch {
+	case bytes.Contains(upper, []byte("OFXHEADER")) || bytes.Contains(upper, []byte("<OFX>")):
+		return ImportOFX
+	case bytes.HasPrefix(start, []byte("!")):
+		return ImportQIF
+	}
+	return ImportCSV
+}
+
+// SkipDuplicates skips the rows of transactions that come up again later in
+// the same file
+func SkipDuplicates(rows []ImportRow) {
+	seen := map[string]int{}
+	for i := range rows {
+		if rows[i].ExternalId == "" {
+			continue
+		}
+		if line, ok := seen[rows[i].ExternalId]; ok {
+			if rows[i].Status == RowValid {
+				rows[i].Status, rows[i].Message = RowSkipped, fmt.Sprintf("Duplicate of line %d", line)
+			}
+			continue
+		}
+		seen[rows[i].ExternalId] = rows[i].Line
+	}
+}
+
+// ParseCSV reads the rows of a CSV statement with the mapping, rows without
+// the currency of their own are in currency. Problems with single rows are
+// reported on the rows, the error is for files that cannot be read at all.
+func ParseCSV(r io.Reader, mapping *ImportMapping, currency string) ([]ImportRow, error) {
+	data, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decodeText(data, mapping.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	// the lines before the header may not even be CSV
+	skipped := 0
+	for ; skipped < mapping.SkipRows && text != ""; skipped++ {
+		_, text, _ = strings.Cut(text, "\n")
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma, _ = utf8.DecodeRuneInString(mapping.Delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var header []string
+	if !mapping.NoHeader {
+		if header, err = reader.Read(); err == io.EOF {
+			return nil, errors.New("the file is empty")
+		} else if err != nil {
+			return nil, fmt.Errorf("the header cannot be read: %w", err)
+		}
+	}
+	columns, err := mapping.columns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the file is not valid CSV: %w", err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("the file has more than %d rows", MaxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+		row := columns.read(record, mapping, currency)
+		row.Line = line + skipped
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file has no rows")
+	}
+	return rows, nil
+}
+
+// csvColumns holds the positions of the mapped columns, -1 when a column is
+// not mapped
+type csvColumns struct {
+	date, description, title, amount, debit, credit, currency int
+}
+
+// columns finds the mapped columns in the header
+func (m *ImportMapping) columns(header []string) (*csvColumns, error) {
+	find := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+		if position, err := strconv.Atoi(name); err == nil && position > 0 {
+			return position - 1, nil
+		}
+		if header == nil {
+			return 0, fmt.Errorf("column %q must be a position from 1 as the file has no header", name)
+		}
+		return 0, fmt.Errorf("column %q is not in the header", name)
+	}
+
+	c := &csvColumns{}
+	var err error
+	for _, col := range []struct {
+		name     string
+		position *int
+	}{
+		{m.DateColumn, &c.date},
+		{m.DescriptionColumn, &c.description},
+		{m.TitleColumn, &c.title},
+		{m.AmountColumn, &c.amount},
+		{m.DebitColumn, &c.debit},
+		{m.CreditColumn, &c.credit},
+		{m.CurrencyColumn, &c.currency},
+	} {
+		if *col.position, err = find(col.name); err != nil {
+			return nil, err
+		}
+	}
+	if m.Sign == SignDebitCredit {
+		c.amount = -1
+	} else {
+		c.debit, c.credit = -1, -1
+	}
+	return c, nil
+}
+
+// read turns a record into a row
+func (c *csvColumns) read(record []string, m *ImportMapping, currency string) ImportRow {
+	field := func(position int) string {
+		if position < 0 || position >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[position])
+	}
+	invalid := func(row ImportRow, message string) ImportRow {
+		row.Status, row.Message = RowInvalid, message
+		return row
+	}
+
+	row := ImportRow{Description: field(c.description), Title: field(c.title), Currency: currency, Status: RowValid}
+	if row.Title == "" {
+		row.Title = row.Description
+	}
+	if code := strings.ToUpper(field(c.currency)); code != "" {
+		row.Currency = code
+	}
+	if c.date >= len(record) || c.description >= len(record) {
+		return invalid(row, fmt.Sprintf("The row has only %d columns", len(record)))
+	}
+	if row.Description == "" {
+		return invalid(row, "The description is empty")
+	}
+	if !ValidCurrency(row.Currency) {
+		return invalid(row, fmt.Sprintf("Invalid currency %q", row.Currency))
+	}
+	date, err := ParseDate(field(c.date), m.DateFormat)
+	if err != nil {
+		return invalid(row, "Invalid date "+strconv.Quote(field(c.date))+", use "+DateFormatHint(m.DateFormat))
+	}
+	row.Date = date
+
+	if m.Sign == SignDebitCredit {
+		debit, credit := field(c.debit), field(c.credit)
+		if debit == "" && credit == "" {
+			return invalid(row, "Both the debit and the credit are empty")
+		}
+		if debit == "" {
+			row.Status, row.Message = RowSkipped, "Credit, not an expense"
+			return row
+		}
+		amount, err := parseStatementAmount(debit, m.DecimalSeparator, row.Currency)
+		if err != nil {
+			return invalid(row, "Invalid amount "+strconv.Quote(debit))
+		}
+		if amount < 0 {
+			amount = -amount
+		}
+		row.Amount = amount
+	} else {
+		amount, err := parseStatementAmount(field(c.amount), m.DecimalSeparator, row.Currency)
+		if err != nil {
+			return invalid(row, "Invalid amount "+strconv.Quote(field(c.amount)))
+		}
+		if m.Sign == SignNegative {
+			amount = -amount
+		}
+		if amount < 0 {
+			row.Status, row.Message = RowSkipped, "Credit, not an expense"
+			return row
+		}
+		row.Amount = amount
+	}
+	if row.Amount == 0 {
+		row.Status, row.Message = RowSkipped, "The amount is zero"
+	}
+	return row
+}
+
+// parseStatementAmount parses an amount as banks write them: with currency
+// symbols, thousands separators, a trailing minus sign or parentheses for
+// negative amounts
+func parseStatementAmount(value, decimalSeparator, currency string) (int64, error) {
+	var cleaned strings.Builder
+	for _, r := range value {
+		if (r >= '0' && r <= '9') || strings.ContainsRune(".,-+()", r) {
+			cleaned.WriteRune(r)
+		}
+	}
+	value = cleaned.String()
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative, value = true, strings.Trim(value, "()")
+	}
+	if strings.HasSuffix(value, "-") {
+		negative, value = true, strings.TrimSuffix(value, "-")
+	} else if strings.HasPrefix(value, "-") {
+		negative, value = true, strings.TrimPrefix(value, "-")
+	}
+	value = strings.TrimPrefix(value, "+")
+
+	thousands := ","
+	if decimalSeparator == "," {
+		thousands = "."
+	}
+	value = strings.ReplaceAll(value, thousands, "")
+	value = strings.ReplaceAll(value, decimalSeparator, ".")
+	if value == "" {
+		return 0, errors.New("no amount")
+	}
+	amount, err := ParseAmount(value, currency)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// readImportFile reads a whole import file, refusing files over MaxImportSize
+func readImportFile(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxImportSize {
+		return nil, fmt.Errorf("the file is larger than %d MB", MaxImportSize>>20)
+	}
+	return data, nil
+}
+
+// guessDecimalSeparator tells the decimal separator of an amount written by
+// software whose conventions are unknown: the last separator unless it is a
+// lone comma followed by three digits, which groups thousands
+func guessDecimalSeparator(value string) string {
+	i := strings.LastIndexAny(value, ".,")
+	if i < 0 || value[i] == '.' {
+		return "."
+	}
+	digits := strings.TrimRight(value[i+1:], " )-")
+	if strings.Count(value, ",") == 1 && !strings.Contains(value, ".") && len(digits) == 3 {
+		return "."
+	}
+	return ","
+}
+
+// windows1252 holds the characters of the bytes 0x80 to 0x9F in Windows-1252,
+// which are control characters in ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeText converts the contents of a file in the encoding to a string,
+// dropping any byte order mark
+func decodeText(data []byte, encoding string) (string, error) {
+	switch encoding {
+	case EncodingUTF16:
+		// without a byte order mark, little endian like Windows writes
+		bigEndian := bytes.HasPrefix(data, []byte{0xFE, 0xFF})
+		if bigEndian || bytes.HasPrefix(data, []byte{0xFF, 0xFE}) {
+			data = data[2:]
+		}
+		if len(data)%2 != 0 {
+			return "", errors.New("the file is not valid UTF-16")
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			} else {
+				units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+			}
+		}
+		return string(utf16.Decode(units)), nil
+	case EncodingISO88591, EncodingWindows1252:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+			if encoding == EncodingWindows1252 && b >= 0x80 && b < 0xA0 {
+				runes[i] = windows1252[b-0x80]
+			}
+		}
+		return string(runes), nil
+	}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if !utf8.Valid(data) {
+		return "", errors.New("the file is not valid UTF-8, set the encoding of the mapping")
+	}
+	return string(data), nil
+}
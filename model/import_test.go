@@ -0,0 +1,160 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  ImportMapping
+		currency string
+		file     string
+		want     []rowSummary
+	}{
+		{
+			name: "semicolons, decimal commas and account details before the header",
+			mapping: ImportMapping{Name: "Girokonto", Delimiter: ";", SkipRows: 2, DateColumn: "Booking date",
+				DescriptionColumn: "description", AmountColumn: "Amount", DateFormat: "DD.MM.YYYY", DecimalSeparator: ","},
+			currency: "EUR",
+			file: "Account;DE89 3704 0044 0532 0130 00\n" +
+				"Period;September 2026\n" +
+				"Booking date;Description;Amount\n" +
+				"02.09.2026;Bäckerei;-4,50\n" +
+				"05.09.2026;Salary;2.500,00\n" +
+				"10.09.2026;Rent;-1.250,00\n" +
+				"31.09.2026;Bad date;-1,00\n" +
+				"12.09.2026;;-3,00\n" +
+				"15.09.2026;Nothing;0,00\n",
+			want: []rowSummary{
+				{4, "2026-09-02", "Bäckerei", "Bäckerei", 450, "EUR", "", RowValid, ""},
+				{5, "2026-09-05", "Salary", "Salary", 0, "EUR", "", RowSkipped, "Credit, not an expense"},
+				{6, "2026-09-10", "Rent", "Rent", 125000, "EUR", "", RowValid, ""},
+				{7, "", "Bad date", "Bad date", 0, "EUR", "", RowInvalid, `Invalid date "31.09.2026", use ` + DateFormatHint("DD.MM.YYYY")},
+				{8, "", "", "", 0, "EUR", "", RowInvalid, "The description is empty"},
+				{9, "2026-09-15", "Nothing", "Nothing", 0, "EUR", "", RowSkipped, "The amount is zero"},
+			},
+		},
+		{
+			name: "positions without a header, debit and credit columns and Windows-1252",
+			mapping: ImportMapping{Name: "Card", NoHeader: true, Encoding: EncodingWindows1252, DateColumn: "1",
+				DescriptionColumn: "2", DebitColumn: "3", CreditColumn: "4", CurrencyColumn: "5", Sign: SignDebitCredit},
+			currency: "GBP",
+			file: "2026-09-01,Caf\xe9,12.00,,usd\n" +
+				"2026-09-02,Deposit,,100.00,\n" +
+				"2026-09-03,Fee,(3.50),,\n" +
+				"2026-09-04,Nothing,,,\n" +
+				"2026-09-05\n",
+			want: []rowSummary{
+				{1, "2026-09-01", "Café", "Café", 1200, "USD", "", RowValid, ""},
+				{2, "2026-09-02", "Deposit", "Deposit", 0, "GBP", "", RowSkipped, "Credit, not an expense"},
+				{3, "2026-09-03", "Fee", "Fee", 350, "GBP", "", RowValid, ""},
+				{4, "2026-09-04", "Nothing", "Nothing", 0, "GBP", "", RowInvalid, "Both the debit and the credit are empty"},
+				{5, "", "", "", 0, "GBP", "", RowInvalid, "The row has only 1 columns"},
+			},
+		},
+		{
+			name: "a title column and positive expenses",
+			mapping: ImportMapping{Name: "Shop card", DateColumn: "Date", TitleColumn: "Payee", DescriptionColumn: "Memo",
+				AmountColumn: "AMOUNT", CurrencyColumn: "Currency", Sign: SignPositive},
+			currency: "USD",
+			file: "\xEF\xBB\xBFDate,Payee,Memo,Amount,Currency\n" +
+				"2026-09-01,Shop,\"Socks, wool\",$12.99,\n" +
+				"2026-09-02,Shop,Refund,-5.00,\n" +
+				"2026-09-03,Shop,Hats,abc,\n" +
+				"2026-09-04,Shop,Scarf,1,XYZ\n",
+			want: []rowSummary{
+				{2, "2026-09-01", "Shop", "Socks, wool", 1299, "USD", "", RowValid, ""},
+				{3, "2026-09-02", "Shop", "Refund", 0, "USD", "", RowSkipped, "Credit, not an expense"},
+				{4, "2026-09-03", "Shop", "Hats", 0, "USD", "", RowInvalid, `Invalid amount "abc"`},
+				{5, "", "Shop", "Scarf", 0, "XYZ", "", RowInvalid, `Invalid currency "XYZ"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mapping.Normalize(); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := ParseCSV(strings.NewReader(tt.file), &tt.mapping, tt.currency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareRows(t, rows, tt.want)
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	mapping := ImportMapping{Name: "Bank", DateColumn: "Date", DescriptionColumn: "Description", AmountColumn: "Amount"}
+	if err := mapping.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	noHeader := mapping
+	noHeader.NoHeader = true
+
+	tests := []struct {
+		mapping ImportMapping
+		file    string
+		want    string
+	}{
+		{mapping, "", "the file is empty"},
+		{mapping, "Date,Description,Amount\n", "the file has no rows"},
+		{mapping, "Date,Description,Sum\n2026-09-01,Coffee,-3.50\n", `column "Amount" is not in the header`},
+		{noHeader, "2026-09-01,Coffee,-3.50\n", `column "Date" must be a position from 1 as the file has no header`},
+		{mapping, "Date,Description,Amount\n" + strings.Repeat("x", MaxImportSize), "the file is larger than 5 MB"},
+	}
+	for _, tt := range tests {
+		rows, err := ParseCSV(strings.NewReader(tt.file), &tt.mapping, "USD")
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%.40q: got %d rows, %v, want %q", tt.file, len(rows), err, tt.want)
+		}
+	}
+}
+
+func TestImportMappingNormalize(t *testing.T) {
+	valid := func(change func(m *ImportMapping)) ImportMapping {
+		m := ImportMapping{Name: " Bank ", DateColumn: "Date", DescriptionColumn: "Description", AmountColumn: "Amount"}
+		change(&m)
+		return m
+	}
+
+	tests := []struct {
+		mapping ImportMapping
+		want    string
+	}{
+		{valid(func(m *ImportMapping) {}), ""},
+		{valid(func(m *ImportMapping) { m.Name = " " }), "the name is required"},
+		{valid(func(m *ImportMapping) { m.Delimiter = ";;" }), "the delimiter must be a single character other than a quote or a line break"},
+		{valid(func(m *ImportMapping) { m.Delimiter = `"` }), "the delimiter must be a single character other than a quote or a line break"},
+		{valid(func(m *ImportMapping) { m.Encoding = "latin-2" }), "the encoding must be utf-8, utf-16, iso-8859-1 or windows-1252"},
+		{valid(func(m *ImportMapping) { m.SkipRows = -1 }), "the number of rows to skip cannot be negative"},
+		{valid(func(m *ImportMapping) { m.DescriptionColumn = "" }), "the date and description columns are required"},
+		{valid(func(m *ImportMapping) { m.Sign = "both" }), "the sign must be negative, positive or debit-credit"},
+		{valid(func(m *ImportMapping) { m.Sign = SignDebitCredit }), "the debit column is required with the debit-credit sign"},
+		{valid(func(m *ImportMapping) { m.Sign, m.AmountColumn, m.DebitColumn = SignDebitCredit, "", "Debit" }), ""},
+		{valid(func(m *ImportMapping) { m.AmountColumn = "" }), "the amount column is required"},
+		{valid(func(m *ImportMapping) { m.DecimalSeparator = "'" }), `the decimal separator must be "." or ","`},
+		{valid(func(m *ImportMapping) { m.DecimalSeparator = "," }), "the decimal separator and the delimiter must differ"},
+		{valid(func(m *ImportMapping) { m.Currency = "euro" }), "the currency must be an ISO 4217 code such as USD"},
+		{valid(func(m *ImportMapping) { m.Currency = " eur " }), ""},
+	}
+	for _, tt := range tests {
+		m := tt.mapping
+		err := m.Normalize()
+		if (err == nil && tt.want != "") || (err != nil && err.Error() != tt.want) {
+			t.Errorf("%+v: got %v, want %q", tt.mapping, err, tt.want)
+		}
+	}
+
+	// the defaults
+	m := valid(func(m *ImportMapping) { m.Currency = " eur " })
+	if err := m.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Bank" || m.Delimiter != "," || m.Encoding != EncodingUTF8 || m.Sign != SignNegative ||
+		m.DecimalSeparator != "." || m.Category != "Others" || m.Currency != "EUR" {
+		t.Errorf("defaults: got %+v", m)
+	}
+}
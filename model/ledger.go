@@ -0,0 +1,216 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultFundingAccount is the account expenses are paid from when the
+// export does not name one
+const DefaultFundingAccount = "Assets:Cash"
+
+// ledgerRoots are the top level accounts beancount allows, ledger and hledger
+// accept them too
+var ledgerRoots = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+var ledgerComponent = regexp.MustCompile(`^[\p{Lu}\p{Nd}][\p{L}\p{Nd}-]*$`)
+
+// ErrInvalidAccount is returned for account names the plain text accounting
+// tools would not read the same way
+var ErrInvalidAccount = errors.New("an account is a top level account (Assets, Liabilities, Equity, Income or Expenses) " +
+	"followed by names starting with a capital letter or a digit and holding letters, digits and dashes, such as Expenses:Food:Groceries")
+
+// ValidateAccount checks an account name such as Expenses:Groceries, so that
+// it is read alike by ledger, hledger and beancount
+func ValidateAccount(account string) error {
+	components := strings.Split(account, ":")
+	if len(components) < 2 || !slices.Contains(ledgerRoots, components[0]) {
+		return ErrInvalidAccount
+	}
+	for _, component := range components[1:] {
+		if !ledgerComponent.MatchString(component) {
+			return ErrInvalidAccount
+		}
+	}
+	return nil
+}
+
+// AccountName turns a category name into the name of an account component,
+// "eating out" becomes Eating-Out
+func AccountName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			if b.Len() == 0 || dash {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "Uncategorized"
+	}
+	return b.String()
+}
+
+// CategoryAccounts returns the account of each category, by ID. Categories
+// without an account of their own are booked below the account of their
+// parent, or below Expenses at the top level.
+func CategoryAccounts(categories []Category) map[uint]string {
+	byId := make(map[uint]*Category, len(categories))
+	for i := range categories {
+		byId[categories[i].ID] = &categories[i]
+	}
+	accounts := make(map[uint]string, len(categories))
+	var account func(c *Category) string
+	account = func(c *Category) string {
+		if a, ok := accounts[c.ID]; ok {
+			return a
+		}
+		a := c.Account
+		if a == "" {
+			parent := "Expenses"
+			if c.ParentId != nil && byId[*c.ParentId] != nil {
+				parent = account(byId[*c.ParentId])
+			}
+			a = parent + ":" + AccountName(c.Name)
+		}
+		accounts[c.ID] = a
+		return a
+	}
+	for i := range categories {
+		account(&categories[i])
+	}
+	return accounts
+}
+
+// ledgerExpenseWriter writes every expense as a transaction moving its
+// amount from the funding account to the account of its category. The
+// transactions carry the expense ID as metadata, so exports in the same order
+// can be compared line by line.
+type ledgerExpenseWriter struct {
+	out     *bufio.Writer
+	format  string
+	options ExportOptions
+	// opened are the accounts beancount has been told about
+	opened map[string]bool
+}
+
+func newLedgerExpenseWriter(w io.Writer, format string, options ExportOptions) *ledgerExpenseWriter {
+	return &ledgerExpenseWriter{out: bufio.NewWriter(w), format: format, options: options, opened: map[string]bool{}}
+}
+
+func (l *ledgerExpenseWriter) Write(e *ExpenseData) error {
+	account := l.options.Accounts[e.CategoryId]
+	if account == "" {
+		account = "Expenses:" + AccountName(e.Category)
+	}
+	title, description := ledgerText(e.Title), ledgerText(e.Description)
+	amount := FormatAmount(e.Amount, e.Currency) + " " + e.Currency
+
+	var t strings.Builder
+	if l.format == ExportBeancount {
+		// beancount refuses postings to accounts it has not seen opened
+		for _, a := range []string{account, l.options.FundingAccount} {
+			if !l.opened[a] {
+				fmt.Fprintf(&t, "%s open %s\n\n", e.Date, a)
+				l.opened[a] = true
+			}
+		}
+		fmt.Fprintf(&t, "%s * %s %s", e.Date, beancountString(title), beancountString(description))
+		for _, tag := range e.Tags {
+			t.WriteString(" #" + ledgerTag(tag, true))
+		}
+		fmt.Fprintf(&t, "\n  expense-id: %d\n", e.ID)
+		fmt.Fprintf(&t, "  %s  %s\n  %s\n\n", account, amount, l.options.FundingAccount)
+	} else {
+		fmt.Fprintf(&t, "%s * %s\n", e.Date, title)
+		if description != "" && description != title {
+			fmt.Fprintf(&t, "    ; %s\n", description)
+		}
+		fmt.Fprintf(&t, "    ; expense-id: %d\n", e.ID)
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, tag := range e.Tags {
+				tags[i] = ledgerTag(tag, false)
+			}
+			if l.format == ExportLedger {
+				fmt.Fprintf(&t, "    ; :%s:\n", strings.Join(tags, ":"))
+			} else {
+				fmt.Fprintf(&t, "    ; %s:\n", strings.Join(tags, ":, "))
+			}
+		}
+		fmt.Fprintf(&t, "    %s  %s\n    %s\n\n", account, amount, l.options.FundingAccount)
+	}
+	_, err := l.out.WriteString(t.String())
+	return err
+}
+
+func (l *ledgerExpenseWriter) Flush() error {
+	return l.out.Flush()
+}
+
+func (l *ledgerExpenseWriter) Close() error {
+	return l.out.Flush()
+}
+
+// ledgerText puts a text on a single line, without the runs of spaces ledger
+// reads as the start of a comment
+func ledgerText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// beancountString quotes a text as a beancount string
+func beancountString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
+
+// ledgerTag replaces the characters tags cannot hold with dashes, beancount
+// tags are limited to ASCII letters, digits and -_/.
+func ledgerTag(tag string, ascii bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_/.", r)):
+			return r
+		case !ascii && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return r
+		}
+		return '-'
+	}, tag)
+}
+
+// ledgerExtensions are the file name extensions of the journal formats
+var ledgerExtensions = map[string]string{
+	ExportLedger:    "ledger",
+	ExportHledger:   "journal",
+	ExportBeancount: "beancount",
+}
+
+// ExportExtension returns the file name extension of an export format
+func ExportExtension(format string) string {
+	if extension, ok := ledgerExtensions[format]; ok {
+		return extension
+	}
+	return format
+}
+
+// JournalFormat tells whether the format is one of the plain text
+// accounting journals
+func JournalFormat(format string) bool {
+	_, ok := ledgerExtensions[format]
+	return ok
+}
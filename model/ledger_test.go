@@ -0,0 +1,228 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the journal exports")
+
+func TestValidateAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		valid   bool
+	}{
+		{"Expenses:Groceries", true},
+		{"Assets:Bank:Checking-2", true},
+		{"Liabilities:Credit-Card", true},
+		{"Expenses:Café", true},
+		{"Expenses:2026", true},
+		{"Expenses", false},
+		{"Spending:Groceries", false},
+		{"expenses:Groceries", false},
+		{"Expenses:groceries", false},
+		{"Expenses:Eating Out", false},
+		{"Expenses::Food", false},
+		{"Expenses:Food:", false},
+		{"Expenses:-Food", false},
+		{"Expenses:Food_Drink", false},
+	}
+	for _, tt := range tests {
+		err := ValidateAccount(tt.account)
+		if tt.valid && err != nil || !tt.valid && !errors.Is(err, ErrInvalidAccount) {
+			t.Errorf("%q: got %v, want valid %t", tt.account, err, tt.valid)
+		}
+	}
+}
+
+func TestAccountName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Groceries", "Groceries"},
+		{"eating out", "Eating-Out"},
+		{"  health & fitness  ", "Health-Fitness"},
+		{"café", "Café"},
+		{"élan vital", "Élan-Vital"},
+		{"2nd home", "2nd-Home"},
+		{"kids' stuff", "Kids-Stuff"},
+		{"", "Uncategorized"},
+		{"!!!", "Uncategorized"},
+	}
+	for _, tt := range tests {
+		got := AccountName(tt.name)
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+		// every name makes a valid account
+		if err := ValidateAccount("Expenses:" + got); err != nil {
+			t.Errorf("%q: Expenses:%s is not valid", tt.name, got)
+		}
+	}
+}
+
+func TestCategoryAccounts(t *testing.T) {
+	food, car := uint(1), uint(3)
+	categories := []Category{
+		{Name: "eating out", ParentId: &food},
+		{Name: "Food"},
+		{Name: "fuel", ParentId: &car},
+		{Name: "Car", Account: "Expenses:Transport:Car"},
+		{Name: "lost", ParentId: &car},
+	}
+	for i, id := range []uint{2, 1, 4, 3, 5} {
+		categories[i].ID = id
+	}
+	// a parent that is not in the list
+	missing := uint(99)
+	categories[4].ParentId = &missing
+
+	want := map[uint]string{
+		1: "Expenses:Food",
+		2: "Expenses:Food:Eating-Out",
+		3: "Expenses:Transport:Car",
+		4: "Expenses:Transport:Car:Fuel",
+		5: "Expenses:Lost",
+	}
+	got := CategoryAccounts(categories)
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for id, account := range want {
+		if got[id] != account {
+			t.Errorf("category %d: got %q, want %q", id, got[id], account)
+		}
+	}
+}
+
+func TestLedgerTag(t *testing.T) {
+	tests := []struct {
+		tag               string
+		ledger, beancount string
+	}{
+		{"trip", "trip", "trip"},
+		{"work trip", "work-trip", "work-trip"},
+		{"trip:lagos", "trip-lagos", "trip-lagos"},
+		{"café", "café", "caf-"},
+		{"東京", "東京", "--"},
+		{"v1.2/beta_3", "v1.2/beta_3", "v1.2/beta_3"},
+		{"#hash", "-hash", "-hash"},
+	}
+	for _, tt := range tests {
+		if got := ledgerTag(tt.tag, false); got != tt.ledger {
+			t.Errorf("ledger %q: got %q, want %q", tt.tag, got, tt.ledger)
+		}
+		if got := ledgerTag(tt.tag, true); got != tt.beancount {
+			t.Errorf("beancount %q: got %q, want %q", tt.tag, got, tt.beancount)
+		}
+	}
+}
+
+// journalExpenses are written into the golden files of the journal exports
+func journalExpenses() []ExpenseData {
+	expenses := []ExpenseData{
+		{Date: testDate("2026-10-01"), Title: "Coffee  with   Ana", Description: "at the café", Amount: 450, Currency: "USD",
+			CategoryId: 2, Category: "Groceries", Tags: []string{"work trip", "café"}},
+		{Date: testDate("2026-10-02"), Title: `Book "Go"`, Description: `C:\books`, Amount: 2599, Currency: "EUR",
+			Category: "eating out", Tags: []string{"東京/2026"}},
+		{Date: testDate("2026-10-03"), Title: "Rent", Description: "Rent", Amount: 90000, Currency: "JPY",
+			CategoryId: 2, Category: "Groceries"},
+		{Date: testDate("2026-10-03"), Title: "Fuel", Amount: 6000, Currency: "USD", CategoryId: 3, Category: "Fuel"},
+	}
+	for i := range expenses {
+		expenses[i].ID = uint(i + 1)
+	}
+	return expenses
+}
+
+func TestJournalExport(t *testing.T) {
+	options := ExportOptions{
+		Accounts:       map[uint]string{2: "Expenses:Food:Groceries", 3: "Expenses:Car:Fuel"},
+		FundingAccount: "Liabilities:Credit-Card",
+	}
+	if err := options.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{ExportLedger, ExportHledger, ExportBeancount} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			w, err := NewExpenseWriter(&out, format, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expenses := journalExpenses()
+			for i := range expenses {
+				if err := w.Write(&expenses[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "expenses."+ExportExtension(format))
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("got:\n%s\nwant:\n%s", out.Bytes(), want)
+			}
+		})
+	}
+}
+
+// TestBeancountOpen checks that beancount sees every account opened once,
+// before the first transaction posting to it
+func TestBeancountOpen(t *testing.T) {
+	options := ExportOptions{Accounts: map[uint]string{2: "Expenses:Food:Groceries", 3: "Expenses:Car:Fuel"}}
+	if err := options.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w := newLedgerExpenseWriter(&out, ExportBeancount, options)
+	expenses := journalExpenses()
+	for i := range expenses {
+		if err := w.Write(&expenses[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	opened := map[string]string{}
+	var last string
+	for _, line := range strings.Split(out.String(), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[1] == "open":
+			if _, ok := opened[fields[2]]; ok {
+				t.Errorf("%s opened twice", fields[2])
+			}
+			if fields[0] < last {
+				t.Errorf("%s opened on %s, after a transaction of %s", fields[2], fields[0], last)
+			}
+			opened[fields[2]] = fields[0]
+		case len(fields) > 1 && fields[1] == "*":
+			last = fields[0]
+		case strings.HasPrefix(line, "  ") && len(fields) > 0 && !strings.HasSuffix(fields[0], ":"):
+			if date, ok := opened[fields[0]]; !ok || date > last {
+				t.Errorf("posting to %s on %s, opened %q", fields[0], last, date)
+			}
+		}
+	}
+	if len(opened) != 4 {
+		t.Errorf("got opened accounts %v, want 4", opened)
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// localePattern matches BCP 47 language tags such as en, en-GB or zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// ValidTimezone reports whether zone is an IANA time zone, the empty string
+// stands for UTC
+func ValidTimezone(zone string) bool {
+	if zone == "" {
+		return true
+	}
+	if zone == "Local" {
+		// the zone of the server, not one a user can mean
+		return false
+	}
+	_, err := time.LoadLocation(zone)
+	return err == nil
+}
+
+// ValidLocale reports whether locale looks like a BCP 47 language tag, the
+// empty string leaves the locale unset
+func ValidLocale(locale string) bool {
+	return locale == "" || localePattern.MatchString(locale)
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginPolicy decides how failed logins of a key slow down and lock out
+// further attempts. The first FreeAttempts failures cost nothing, each
+// following one doubles the wait before the next attempt, starting at a
+// second, and LockoutAfter failures lock the key for LockoutDuration.
+// Failures older than Window are forgotten.
+type LoginPolicy struct {
+	FreeAttempts    int
+	LockoutAfter    int
+	Window          time.Duration
+	LockoutDuration time.Duration
+}
+
+// Policies for the two kinds of keys, an address may be shared by many users
+// so it is allowed more failures than an account
+var (
+	AccountLoginPolicy = LoginPolicy{FreeAttempts: 3, LockoutAfter: 10, Window: 15 * time.Minute, LockoutDuration: 15 * time.Minute}
+	AddressLoginPolicy = LoginPolicy{FreeAttempts: 10, LockoutAfter: 50, Window: 15 * time.Minute, LockoutDuration: 15 * time.Minute}
+)
+
+// AccountLoginKey and AddressLoginKey return the keys failed logins are
+// tracked under. Accounts are keyed by the email given, registered or not,
+// so the limits do not tell which emails have an account.
+func AccountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func AddressLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginThrottleRetention is how long the failures of a key that is not
+// blocked are kept, it must exceed the Window of every policy
+const loginThrottleRetention = 24 * time.Hour
+
+// LoginThrottle holds the recent failed logins of a key
+type LoginThrottle struct {
+	Key         string `gorm:"column:throttle_key;primary_key"`
+	Failures    int
+	LastFailure time.Time `gorm:"index"`
+	// BlockedUntil is when the key may try again
+	BlockedUntil time.Time
+}
+
+// Wait returns how long the key must wait at now before its next attempt
+func (t *LoginThrottle) Wait(now time.Time) time.Duration {
+	if t.BlockedUntil.After(now) {
+		return t.BlockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// expired tells whether the throttle no longer affects the key at now
+func (t *LoginThrottle) expired(now time.Time) bool {
+	return now.Sub(t.LastFailure) > loginThrottleRetention && !t.BlockedUntil.After(now)
+}
+
+// Fail records a failed login at now and returns whether it locked the key
+func (p LoginPolicy) Fail(t *LoginThrottle, now time.Time) bool {
+	if now.Sub(t.LastFailure) > p.Window {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailure = now
+	switch {
+	case t.Failures >= p.LockoutAfter:
+		t.BlockedUntil = now.Add(p.LockoutDuration)
+		return t.Failures == p.LockoutAfter
+	case t.Failures > p.FreeAttempts:
+		delay := time.Second << (t.Failures - p.FreeAttempts - 1)
+		if delay > p.LockoutDuration {
+			delay = p.LockoutDuration
+		}
+		t.BlockedUntil = now.Add(delay)
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyFail(t *testing.T) {
+	policy := LoginPolicy{FreeAttempts: 2, LockoutAfter: 6, Window: 10 * time.Minute, LockoutDuration: 15 * time.Minute}
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		// after is the time of the failure since start
+		after  time.Duration
+		wait   time.Duration
+		locked bool
+	}{
+		// the free attempts cost nothing
+		{0, 0, false},
+		{time.Second, 0, false},
+		// then the wait doubles with each failure
+		{2 * time.Second, time.Second, false},
+		{4 * time.Second, 2 * time.Second, false},
+		{8 * time.Second, 4 * time.Second, false},
+		// until the key is locked out
+		{16 * time.Second, 15 * time.Minute, true},
+		// failing again while locked extends the lockout, without locking
+		// it anew
+		{time.Minute, 15 * time.Minute, false},
+		// once it ends, failures older than the window are forgotten
+		{17 * time.Minute, 0, false},
+		{17*time.Minute + time.Second, 0, false},
+		{17*time.Minute + 2*time.Second, time.Second, false},
+	}
+	throttle := &LoginThrottle{Key: "account:jane@example.com"}
+	for i, tt := range tests {
+		now := start.Add(tt.after)
+		locked := policy.Fail(throttle, now)
+		if wait := throttle.Wait(now); wait != tt.wait || locked != tt.locked {
+			t.Errorf("failure %d: got wait %s, locked %t, want %s, %t", i+1, wait, locked, tt.wait, tt.locked)
+		}
+	}
+
+	// the delay never exceeds the lockout
+	policy = LoginPolicy{FreeAttempts: 0, LockoutAfter: 100, Window: time.Hour, LockoutDuration: 10 * time.Second}
+	throttle = &LoginThrottle{}
+	for i := 0; i < 20; i++ {
+		policy.Fail(throttle, start)
+	}
+	if wait := throttle.Wait(start); wait != 10*time.Second {
+		t.Errorf("capped delay: got %s, want 10s", wait)
+	}
+	if wait := throttle.Wait(start.Add(11 * time.Second)); wait != 0 {
+		t.Errorf("after the delay: got %s, want 0", wait)
+	}
+}
+
+func TestLoginLimiter(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		policy := LoginPolicy{FreeAttempts: 1, LockoutAfter: 3, Window: time.Minute, LockoutDuration: time.Hour}
+		now := time.Now().UTC().Truncate(time.Second)
+		key, other := AccountLoginKey(" Jane@Example.com"), AccountLoginKey("john@example.com")
+		if key != "account:jane@example.com" {
+			t.Errorf("account key: got %q", key)
+		}
+
+		fail := func(key string) (*LoginThrottle, bool) {
+			throttle, locked, err := store.Logins.Fail(key, policy, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return throttle, locked
+		}
+		wait := func(key string) time.Duration {
+			wait, err := store.Logins.Wait(key, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return wait
+		}
+
+		if wait(key) != 0 {
+			t.Error("a key without failures has to wait")
+		}
+		fail(key)
+		throttle, locked := fail(key)
+		if throttle.Failures != 2 || locked || wait(key) != time.Second {
+			t.Errorf("second failure: got %+v, locked %t, wait %s", throttle, locked, wait(key))
+		}
+		// each key is counted on its own
+		if throttle, _ := fail(other); throttle.Failures != 1 || wait(other) != 0 {
+			t.Errorf("other key: got %+v, wait %s", throttle, wait(other))
+		}
+		if throttle, locked = fail(key); throttle.Failures != 3 || !locked || wait(key) != time.Hour {
+			t.Errorf("lockout: got %+v, locked %t, wait %s", throttle, locked, wait(key))
+		}
+
+		if err := store.Logins.Reset(key); err != nil {
+			t.Fatal(err)
+		}
+		if wait(key) != 0 {
+			t.Errorf("after a reset: got wait %s", wait(key))
+		}
+		if throttle, _ := fail(key); throttle.Failures != 1 {
+			t.Errorf("failure after a reset: got %d failures, want 1", throttle.Failures)
+		}
+	})
+}
@@ -0,0 +1,1862 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// memoryDB holds every table of the in-memory backend behind a single lock so
+// that operations touching several tables stay consistent.
+type memoryDB struct {
+	mu         sync.RWMutex
+	seq        map[string]uint
+	users      map[uint]UserData
+	expenses   map[uint]ExpenseData
+	categories map[uint]Category
+	tags       map[uint]Tag
+	// expenseTags holds the IDs of the tags of each expense
+	expenseTags map[uint][]uint
+	recurring   map[uint]RecurringExpense
+	overrides   map[uint][]RecurringOverride
+	// occurrences records every occurrence ever materialized, deleted
+	// expenses included, keyed by recurring expense ID and date
+	occurrences map[string]bool
+	budgets     map[uint]Budget
+	// refreshTokens holds the refresh tokens by ID, revokedTokens the
+	// expiry of each revoked access token by jti
+	refreshTokens map[uint]RefreshToken
+	revokedTokens map[string]time.Time
+	emailTokens   map[uint]EmailToken
+	mfaSettings   map[int64]MFASettings
+	recoveryCodes map[uint]RecoveryCode
+	mfaChallenges map[uint]MFAChallenge
+	apiTokens     map[uint]APIToken
+	// rates holds the rates of each base and quote pair sorted by date
+	rates map[string][]ExchangeRate
+	audit map[uint]AuditEntry
+	// exports holds the data exports by ID, exportFiles their archives
+	exports     map[uint]DataExport
+	exportFiles map[uint][]byte
+	// imports holds the imports by ID, importRows the rows of each import
+	importMappings map[uint]ImportMapping
+	imports        map[uint]Import
+	importRows     map[uint][]ImportRow
+}
+
+type memoryUserRepository struct {
+	db *memoryDB
+}
+
+type memoryExpenseRepository struct {
+	db *memoryDB
+}
+
+type memoryCategoryRepository struct {
+	db *memoryDB
+}
+
+type memoryTagRepository struct {
+	db *memoryDB
+}
+
+type memoryRecurringRepository struct {
+	db *memoryDB
+}
+
+type memoryBudgetRepository struct {
+	db *memoryDB
+}
+
+type memoryTokenRepository struct {
+	db *memoryDB
+}
+
+type memoryEmailTokenRepository struct {
+	db *memoryDB
+}
+
+type memoryMFARepository struct {
+	db *memoryDB
+}
+
+type memoryAPITokenRepository struct {
+	db *memoryDB
+}
+
+type memoryRateRepository struct {
+	db *memoryDB
+}
+
+type memoryAuditRepository struct {
+	db *memoryDB
+}
+
+type memoryExportRepository struct {
+	db *memoryDB
+}
+
+type memoryImportMappingRepository struct {
+	db *memoryDB
+}
+
+type memoryImportRepository struct {
+	db *memoryDB
+}
+
+// memoryLoginLimiter keeps the throttles in process memory, each instance of
+// the API counts only the failures it saw itself
+type memoryLoginLimiter struct {
+	mu        sync.Mutex
+	throttles map[string]LoginThrottle
+}
+
+// NewMemoryLoginLimiter returns a LoginLimiter that keeps the failed logins
+// in process memory, which spares the database a write per failed login when
+// a single instance serves the API
+func NewMemoryLoginLimiter() LoginLimiter {
+	return &memoryLoginLimiter{throttles: map[string]LoginThrottle{}}
+}
+
+// NewMemoryStore returns a store that keeps all data in process memory. It is
+// meant for local development and tests; nothing survives a restart.
+func NewMemoryStore() *Store {
+	db := &memoryDB{
+		seq:            map[string]uint{},
+		users:          map[uint]UserData{},
+		expenses:       map[uint]ExpenseData{},
+		categories:     map[uint]Category{},
+		tags:           map[uint]Tag{},
+		expenseTags:    map[uint][]uint{},
+		recurring:      map[uint]RecurringExpense{},
+		overrides:      map[uint][]RecurringOverride{},
+		occurrences:    map[string]bool{},
+		budgets:        map[uint]Budget{},
+		refreshTokens:  map[uint]RefreshToken{},
+		revokedTokens:  map[string]time.Time{},
+		emailTokens:    map[uint]EmailToken{},
+		mfaSettings:    map[int64]MFASettings{},
+		recoveryCodes:  map[uint]RecoveryCode{},
+		mfaChallenges:  map[uint]MFAChallenge{},
+		apiTokens:      map[uint]APIToken{},
+		rates:          map[string][]ExchangeRate{},
+		audit:          map[uint]AuditEntry{},
+		exports:        map[uint]DataExport{},
+		exportFiles:    map[uint][]byte{},
+		importMappings: map[uint]ImportMapping{},
+		imports:        map[uint]Import{},
+		importRows:     map[uint][]ImportRow{},
+	}
+	return &Store{
+		Users:          &memoryUserRepository{db: db},
+		Expenses:       &memoryExpenseRepository{db: db},
+		Categories:     &memoryCategoryRepository{db: db},
+		Tags:           &memoryTagRepository{db: db},
+		Recurring:      &memoryRecurringRepository{db: db},
+		Budgets:        &memoryBudgetRepository{db: db},
+		Tokens:         &memoryTokenRepository{db: db},
+		EmailTokens:    &memoryEmailTokenRepository{db: db},
+		MFA:            &memoryMFARepository{db: db},
+		APITokens:      &memoryAPITokenRepository{db: db},
+		Rates:          &memoryRateRepository{db: db},
+		Exports:        &memoryExportRepository{db: db},
+		ImportMappings: &memoryImportMappingRepository{db: db},
+		Imports:        &memoryImportRepository{db: db},
+		Logins:         NewMemoryLoginLimiter(),
+		Audit:          &memoryAuditRepository{db: db},
+	}
+}
+
+// nextId returns the next primary key for the given table, callers must hold
+// the write lock.
+func (m *memoryDB) nextId(table string) uint {
+	m.seq[table]++
+	return m.seq[table]
+}
+
+// sortedIds returns the keys of a table in ascending order so listings are
+// deterministic.
+func sortedIds[T any](rows map[uint]T) []uint {
+	ids := make([]uint, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (r *memoryUserRepository) Create(user *UserData) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	user.ID = r.db.nextId("users")
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) FindById(id int64) (*UserData, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	user, ok := r.db.users[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(email string) (*UserData, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, id := range sortedIds(r.db.users) {
+		if r.db.users[id].Email == email {
+			user := r.db.users[id]
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Update(user *UserData) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	user.UpdatedAt = time.Now()
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) DueForDeletion(now time.Time) ([]UserData, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var users []UserData
+	for _, id := range sortedIds(r.db.users) {
+		if user := r.db.users[id]; user.DeleteAt != nil && !user.DeleteAt.After(now) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) Purge(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	for expenseId, expense := range r.db.expenses {
+		if expense.UserId == id {
+			delete(r.db.expenseTags, expenseId)
+			delete(r.db.expenses, expenseId)
+		}
+	}
+	for recurringId, recurring := range r.db.recurring {
+		if recurring.UserId == id {
+			delete(r.db.overrides, recurringId)
+			delete(r.db.recurring, recurringId)
+		}
+	}
+	for exportId, export := range r.db.exports {
+		if export.UserId == id {
+			delete(r.db.exportFiles, exportId)
+			delete(r.db.exports, exportId)
+		}
+	}
+	for importId, imp := range r.db.imports {
+		if imp.UserId == id {
+			delete(r.db.importRows, importId)
+			delete(r.db.imports, importId)
+		}
+	}
+	deleteRows(r.db.importMappings, func(m ImportMapping) bool { return m.UserId == id })
+	deleteRows(r.db.categories, func(c Category) bool { return c.UserId == id })
+	deleteRows(r.db.tags, func(t Tag) bool { return t.UserId == id })
+	deleteRows(r.db.budgets, func(b Budget) bool { return b.UserId == id })
+	deleteRows(r.db.refreshTokens, func(t RefreshToken) bool { return t.UserId == id })
+	deleteRows(r.db.emailTokens, func(t EmailToken) bool { return t.UserId == id })
+	deleteRows(r.db.recoveryCodes, func(c RecoveryCode) bool { return c.UserId == id })
+	deleteRows(r.db.mfaChallenges, func(c MFAChallenge) bool { return c.UserId == id })
+	deleteRows(r.db.apiTokens, func(t APIToken) bool { return t.UserId == id })
+	delete(r.db.mfaSettings, id)
+	delete(r.db.users, uint(id))
+	return nil
+}
+
+func (r *memoryUserRepository) Search(q UserQuery) (*UserPage, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	search := strings.ToLower(q.Search)
+	page := &UserPage{Users: []UserData{}}
+	for _, id := range sortedIds(r.db.users) {
+		user := r.db.users[id]
+		if search != "" && !strings.Contains(strings.ToLower(user.Email), search) &&
+			!strings.Contains(strings.ToLower(user.FirstName), search) &&
+			!strings.Contains(strings.ToLower(user.LastName), search) {
+			continue
+		}
+		if q.Role != "" && user.Role != q.Role {
+			continue
+		}
+		if q.Disabled != nil && user.Disabled() != *q.Disabled {
+			continue
+		}
+		page.Total++
+		if page.Total > int64(q.Offset) && len(page.Users) < q.Limit {
+			page.Users = append(page.Users, user)
+		}
+	}
+	return page, nil
+}
+
+func (r *memoryUserRepository) Stats(id int64) (*UserStats, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	now := time.Now()
+	stats := &UserStats{
+		Categories: countRows(r.db.categories, func(c Category) bool { return c.UserId == id }),
+		Tags:       countRows(r.db.tags, func(t Tag) bool { return t.UserId == id }),
+		Recurring:  countRows(r.db.recurring, func(e RecurringExpense) bool { return e.UserId == id }),
+		Budgets:    countRows(r.db.budgets, func(b Budget) bool { return b.UserId == id }),
+		APITokens:  countRows(r.db.apiTokens, func(t APIToken) bool { return t.UserId == id }),
+		Sessions: countRows(r.db.refreshTokens, func(t RefreshToken) bool {
+			return t.UserId == id && t.UsedAt == nil && t.RevokedAt == nil && t.ExpiresAt.After(now)
+		}),
+	}
+	for _, expense := range r.db.expenses {
+		if expense.UserId != id {
+			continue
+		}
+		stats.Expenses++
+		if date := expense.Date; !date.IsZero() {
+			if stats.FirstExpense == nil || date.Before(stats.FirstExpense.Time) {
+				stats.FirstExpense = &date
+			}
+			if stats.LastExpense == nil || date.After(stats.LastExpense.Time) {
+				stats.LastExpense = &date
+			}
+		}
+	}
+	return stats, nil
+}
+
+// countRows counts the rows of a table matching the condition, callers must
+// hold the lock
+func countRows[T any](rows map[uint]T, match func(T) bool) int64 {
+	var count int64
+	for _, row := range rows {
+		if match(row) {
+			count++
+		}
+	}
+	return count
+}
+
+// deleteRows deletes the rows of a table matching the condition, callers must
+// hold the write lock
+func deleteRows[T any](rows map[uint]T, match func(T) bool) {
+	for id, row := range rows {
+		if match(row) {
+			delete(rows, id)
+		}
+	}
+}
+
+func (r *memoryExpenseRepository) Create(expense *ExpenseData) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	tags, err := NormalizeTags(expense.Tags)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	expense.ID = r.db.nextId("expenses")
+	expense.CreatedAt, expense.UpdatedAt = now, now
+	r.db.saveTags(expense, tags)
+	return nil
+}
+
+// saveTags stores the expense and replaces its tags, creating the tags its
+// owner does not have yet. Callers must hold the write lock.
+func (m *memoryDB) saveTags(expense *ExpenseData, tags []string) {
+	var ids []uint
+	for _, name := range tags {
+		id := uint(0)
+		for _, tagId := range sortedIds(m.tags) {
+			if tag := m.tags[tagId]; tag.UserId == expense.UserId && tag.Name == name {
+				id = tagId
+				break
+			}
+		}
+		if id == 0 {
+			now := time.Now()
+			id = m.nextId("tags")
+			m.tags[id] = Tag{Model: gorm.Model{ID: id, CreatedAt: now, UpdatedAt: now}, UserId: expense.UserId, Name: name}
+		}
+		ids = append(ids, id)
+	}
+	m.expenseTags[expense.ID] = ids
+	expense.Tags = tags
+
+	stored := *expense
+	stored.Tags = nil
+	m.expenses[expense.ID] = stored
+}
+
+// withTags returns a copy of a stored expense with its tag names filled in,
+// callers must hold the lock
+func (m *memoryDB) withTags(e ExpenseData) ExpenseData {
+	e.Tags = []string{}
+	for _, id := range m.expenseTags[e.ID] {
+		e.Tags = append(e.Tags, m.tags[id].Name)
+	}
+	slices.Sort(e.Tags)
+	return e
+}
+
+func (r *memoryExpenseRepository) FindById(id int64) (*ExpenseData, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	expense, ok := r.db.expenses[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	expense = r.db.withTags(expense)
+	return &expense, nil
+}
+
+// matchesExpense reports whether an expense, with its tags filled in,
+// satisfies every criterion of the filter, mirroring the WHERE clause built
+// by the gorm repository
+func matchesExpense(e ExpenseData, f ExpenseFilter) bool {
+	if e.UserId != f.UserId {
+		return false
+	}
+	if !f.StartDate.IsZero() && (e.Date.IsZero() || e.Date.Before(f.StartDate.Time)) {
+		return false
+	}
+	if !f.EndDate.IsZero() && (e.Date.IsZero() || e.Date.After(f.EndDate.Time)) {
+		return false
+	}
+	if f.Category != "" && e.Category != f.Category {
+		return false
+	}
+	if len(f.CategoryIds) > 0 && !slices.Contains(f.CategoryIds, e.CategoryId) {
+		return false
+	}
+	if f.MinAmount != nil && scaledAmount(e.Amount, e.Currency) < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && scaledAmount(e.Amount, e.Currency) > *f.MaxAmount {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(e.Title), search) && !strings.Contains(strings.ToLower(e.Description), search) {
+			return false
+		}
+	}
+	if f.RecurringId != 0 && (e.RecurringId == nil || *e.RecurringId != f.RecurringId) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		found := 0
+		for _, tag := range f.Tags {
+			if slices.Contains(e.Tags, tag) {
+				found++
+			}
+		}
+		if found == 0 || (f.AllTags && found < len(f.Tags)) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareKeys orders two (sort value, ID) pairs the way the gorm repository
+// orders rows
+func compareKeys(av, bv interface{}, aid, bid uint) int {
+	switch av := av.(type) {
+	case string:
+		if bv, ok := bv.(string); ok {
+			if c := strings.Compare(av, bv); c != 0 {
+				return c
+			}
+		}
+	case int64:
+		if bv, ok := bv.(int64); ok {
+			if av < bv {
+				return -1
+			} else if av > bv {
+				return 1
+			}
+		}
+	}
+	if aid < bid {
+		return -1
+	} else if aid > bid {
+		return 1
+	}
+	return 0
+}
+
+func (r *memoryExpenseRepository) List(q ExpenseQuery) (*ExpensePage, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var matches []ExpenseData
+	for _, id := range sortedIds(r.db.expenses) {
+		if expense := r.db.withTags(r.db.expenses[id]); matchesExpense(expense, q.ExpenseFilter) {
+			matches = append(matches, expense)
+		}
+	}
+	direction := 1
+	if q.Desc {
+		direction = -1
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		return direction*compareKeys(sortValue(a, q.Sort), sortValue(b, q.Sort), a.ID, b.ID) < 0
+	})
+
+	page := &ExpensePage{Total: int64(len(matches))}
+	start := 0
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		// the page starts at the first expense ordered after the cursor
+		start = sort.Search(len(matches), func(i int) bool {
+			e := matches[i]
+			return direction*compareKeys(sortValue(e, q.Sort), cursor.Value, e.ID, cursor.Id) > 0
+		})
+	} else if q.Offset > 0 {
+		start = min(q.Offset, len(matches))
+	}
+	end := min(start+q.Limit, len(matches))
+
+	page.Expenses = matches[start:end]
+	page.NextCursor = nextCursor(page.Expenses, q, end < len(matches))
+	return page, nil
+}
+
+func (r *memoryExpenseRepository) Update(expense *ExpenseData) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.expenses[expense.ID]; !ok {
+		return ErrNotFound
+	}
+	tags, err := NormalizeTags(expense.Tags)
+	if err != nil {
+		return err
+	}
+	expense.UpdatedAt = time.Now()
+	r.db.saveTags(expense, tags)
+	return nil
+}
+
+func (r *memoryExpenseRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.expenses[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.expenses, uint(id))
+	delete(r.db.expenseTags, uint(id))
+	return nil
+}
+
+func (r *memoryExpenseRepository) TotalsByTag(f ExpenseFilter) ([]TagTotal, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	totals := []TagTotal{}
+	for _, id := range sortedIds(r.db.expenses) {
+		expense := r.db.withTags(r.db.expenses[id])
+		if !matchesExpense(expense, f) {
+			continue
+		}
+		for _, tag := range expense.Tags {
+			i, found := slices.BinarySearchFunc(totals, TagTotal{Tag: tag, Currency: expense.Currency}, func(a, b TagTotal) int {
+				if c := strings.Compare(a.Tag, b.Tag); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Currency, b.Currency)
+			})
+			if !found {
+				totals = slices.Insert(totals, i, TagTotal{Tag: tag, Currency: expense.Currency})
+			}
+			totals[i].Count++
+			totals[i].Total += expense.Amount
+		}
+	}
+	return totals, nil
+}
+
+func (r *memoryExpenseRepository) DailyTotals(f ExpenseFilter, groupBy string) ([]DailyTotal, error) {
+	if groupBy != GroupByNone && !slices.Contains(SummaryGroupings, groupBy) {
+		return nil, fmt.Errorf("cannot group expenses by %q", groupBy)
+	}
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	totals := []DailyTotal{}
+	for _, id := range sortedIds(r.db.expenses) {
+		expense := r.db.withTags(r.db.expenses[id])
+		if !matchesExpense(expense, f) {
+			continue
+		}
+		for _, key := range groupKeys(expense, groupBy) {
+			i, found := slices.BinarySearchFunc(totals, DailyTotal{Date: expense.Date, Key: key, Currency: expense.Currency}, func(a, b DailyTotal) int {
+				if c := a.Date.Compare(b.Date.Time); c != 0 {
+					return c
+				}
+				if c := strings.Compare(a.Key, b.Key); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Currency, b.Currency)
+			})
+			if !found {
+				totals = slices.Insert(totals, i, DailyTotal{Date: expense.Date, Key: key, Currency: expense.Currency})
+			}
+			totals[i].Count++
+			totals[i].Total += expense.Amount
+		}
+	}
+	return totals, nil
+}
+
+// groupKeys returns the keys of the groups an expense counts towards
+func groupKeys(expense ExpenseData, groupBy string) []string {
+	switch groupBy {
+	case GroupByNone:
+		return []string{""}
+	case GroupByCategory:
+		return []string{expense.Category}
+	case GroupByTag:
+		return expense.Tags
+	}
+	return []string{dateKey(expense.Date, groupBy)}
+}
+
+func (r *memoryExpenseRepository) Summarize(f ExpenseFilter, groupBy string) ([]GroupTotal, error) {
+	if groupBy != GroupByNone && !slices.Contains(SummaryGroupings, groupBy) {
+		return nil, fmt.Errorf("cannot group expenses by %q", groupBy)
+	}
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	totals := []GroupTotal{}
+	for _, id := range sortedIds(r.db.expenses) {
+		expense := r.db.withTags(r.db.expenses[id])
+		if !matchesExpense(expense, f) {
+			continue
+		}
+		for _, key := range groupKeys(expense, groupBy) {
+			i, found := slices.BinarySearchFunc(totals, GroupTotal{Key: key, Currency: expense.Currency}, func(a, b GroupTotal) int {
+				if c := strings.Compare(a.Key, b.Key); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Currency, b.Currency)
+			})
+			if !found {
+				totals = slices.Insert(totals, i, GroupTotal{Key: key, Currency: expense.Currency, Min: expense.Amount, Max: expense.Amount})
+			}
+			totals[i].Count++
+			totals[i].Total += expense.Amount
+			totals[i].Min = min(totals[i].Min, expense.Amount)
+			totals[i].Max = max(totals[i].Max, expense.Amount)
+		}
+	}
+	return totals, nil
+}
+
+func (r *memoryCategoryRepository) Create(category *Category) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.createCategory(category)
+	return nil
+}
+
+// createCategory stores a new category, callers must hold the write lock
+func (m *memoryDB) createCategory(category *Category) {
+	now := time.Now()
+	category.ID = m.nextId("categories")
+	category.CreatedAt, category.UpdatedAt = now, now
+	m.categories[category.ID] = *category
+}
+
+func (r *memoryCategoryRepository) Seed(userId int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, category := range r.db.categories {
+		if category.UserId == userId {
+			return nil
+		}
+	}
+	for _, category := range DefaultCategories {
+		category.UserId = userId
+		r.db.createCategory(&category)
+	}
+	return nil
+}
+
+func (r *memoryCategoryRepository) FindById(id int64) (*Category, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	category, ok := r.db.categories[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+func (r *memoryCategoryRepository) FindByName(userId int64, name string) (*Category, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, id := range sortedIds(r.db.categories) {
+		if category := r.db.categories[id]; category.UserId == userId && category.Name == name {
+			return &category, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryCategoryRepository) List(userId int64) ([]Category, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var categories []Category
+	for _, id := range sortedIds(r.db.categories) {
+		if r.db.categories[id].UserId == userId {
+			categories = append(categories, r.db.categories[id])
+		}
+	}
+	return categories, nil
+}
+
+func (r *memoryCategoryRepository) Update(category *Category) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.categories[category.ID]; !ok {
+		return ErrNotFound
+	}
+	category.UpdatedAt = time.Now()
+	r.db.categories[category.ID] = *category
+	for id, expense := range r.db.expenses {
+		if expense.CategoryId == category.ID {
+			expense.Category = category.Name
+			r.db.expenses[id] = expense
+		}
+	}
+	for id, recurring := range r.db.recurring {
+		if recurring.CategoryId == category.ID {
+			recurring.Category = category.Name
+			r.db.recurring[id] = recurring
+		}
+	}
+	return nil
+}
+
+func (r *memoryCategoryRepository) Delete(id int64, replacement *Category) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	category, ok := r.db.categories[uint(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	for expenseId, expense := range r.db.expenses {
+		if expense.CategoryId == category.ID {
+			expense.CategoryId, expense.Category = replacement.ID, replacement.Name
+			r.db.expenses[expenseId] = expense
+		}
+	}
+	for recurringId, recurring := range r.db.recurring {
+		if recurring.CategoryId == category.ID {
+			recurring.CategoryId, recurring.Category = replacement.ID, replacement.Name
+			r.db.recurring[recurringId] = recurring
+		}
+	}
+	for budgetId, budget := range r.db.budgets {
+		if budget.CategoryId != nil && *budget.CategoryId == category.ID {
+			budget.CategoryId = &replacement.ID
+			r.db.budgets[budgetId] = budget
+		}
+	}
+	for childId, child := range r.db.categories {
+		if child.ParentId != nil && *child.ParentId == category.ID {
+			child.ParentId = category.ParentId
+			r.db.categories[childId] = child
+		}
+	}
+	delete(r.db.categories, category.ID)
+	return nil
+}
+
+func (r *memoryTagRepository) FindById(id int64) (*Tag, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tag, ok := r.db.tags[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &tag, nil
+}
+
+func (r *memoryTagRepository) FindByName(userId int64, name string) (*Tag, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, id := range sortedIds(r.db.tags) {
+		if tag := r.db.tags[id]; tag.UserId == userId && tag.Name == name {
+			return &tag, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryTagRepository) List(userId int64) ([]Tag, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tags := []Tag{}
+	for _, id := range sortedIds(r.db.tags) {
+		if r.db.tags[id].UserId == userId {
+			tags = append(tags, r.db.tags[id])
+		}
+	}
+	for i := range tags {
+		for _, ids := range r.db.expenseTags {
+			if slices.Contains(ids, tags[i].ID) {
+				tags[i].Expenses++
+			}
+		}
+	}
+	slices.SortFunc(tags, func(a, b Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+func (r *memoryTagRepository) Update(tag *Tag) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.tags[tag.ID]; !ok {
+		return ErrNotFound
+	}
+	tag.UpdatedAt = time.Now()
+	r.db.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *memoryTagRepository) Merge(source, target *Tag) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for expenseId, ids := range r.db.expenseTags {
+		if i := slices.Index(ids, source.ID); i >= 0 {
+			ids = slices.Delete(ids, i, i+1)
+			if !slices.Contains(ids, target.ID) {
+				ids = append(ids, target.ID)
+			}
+			r.db.expenseTags[expenseId] = ids
+		}
+	}
+	delete(r.db.tags, source.ID)
+	return nil
+}
+
+func (r *memoryTagRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.tags[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	for expenseId, ids := range r.db.expenseTags {
+		r.db.expenseTags[expenseId] = slices.DeleteFunc(ids, func(tagId uint) bool { return tagId == uint(id) })
+	}
+	delete(r.db.tags, uint(id))
+	return nil
+}
+
+func (r *memoryRecurringRepository) Create(recurring *RecurringExpense) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	recurring.ID = r.db.nextId("recurring_expenses")
+	recurring.CreatedAt, recurring.UpdatedAt = now, now
+	r.db.recurring[recurring.ID] = *recurring
+	return nil
+}
+
+func (r *memoryRecurringRepository) FindById(id int64) (*RecurringExpense, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	recurring, ok := r.db.recurring[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &recurring, nil
+}
+
+func (r *memoryRecurringRepository) List(userId int64) ([]RecurringExpense, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	recurring := []RecurringExpense{}
+	for _, id := range sortedIds(r.db.recurring) {
+		if r.db.recurring[id].UserId == userId {
+			recurring = append(recurring, r.db.recurring[id])
+		}
+	}
+	return recurring, nil
+}
+
+func (r *memoryRecurringRepository) Update(recurring *RecurringExpense) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.recurring[recurring.ID]; !ok {
+		return ErrNotFound
+	}
+	recurring.UpdatedAt = time.Now()
+	r.db.recurring[recurring.ID] = *recurring
+	return nil
+}
+
+func (r *memoryRecurringRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.recurring[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.recurring, uint(id))
+	return nil
+}
+
+func (r *memoryRecurringRepository) Due(on Date) ([]RecurringExpense, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var due []RecurringExpense
+	for _, id := range sortedIds(r.db.recurring) {
+		recurring := r.db.recurring[id]
+		user, ok := r.db.users[uint(recurring.UserId)]
+		if !ok || user.DisabledAt != nil || user.DeleteAt != nil || recurring.NextDate.After(on.Time) {
+			continue
+		}
+		if !recurring.EndDate.IsZero() && recurring.NextDate.After(recurring.EndDate.Time) {
+			continue
+		}
+		due = append(due, recurring)
+	}
+	return due, nil
+}
+
+func (r *memoryRecurringRepository) Materialize(recurring *RecurringExpense, expenses []ExpenseData, next Date) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.recurring[recurring.ID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	created := 0
+	for i := range expenses {
+		key := occurrenceKey(recurring.ID, *expenses[i].OccurrenceDate)
+		if r.db.occurrences[key] {
+			continue
+		}
+		now := time.Now()
+		expenses[i].ID = r.db.nextId("expenses")
+		expenses[i].CreatedAt, expenses[i].UpdatedAt = now, now
+		r.db.saveTags(&expenses[i], nil)
+		r.db.occurrences[key] = true
+		created++
+	}
+	stored.NextDate, recurring.NextDate = next, next
+	r.db.recurring[recurring.ID] = stored
+	return created, nil
+}
+
+// occurrenceKey identifies an occurrence of a recurring expense
+func occurrenceKey(recurringId uint, occurrence Date) string {
+	return strconv.FormatUint(uint64(recurringId), 10) + "/" + occurrence.String()
+}
+
+func (r *memoryRecurringRepository) FindOccurrence(recurringId uint, occurrence Date) (*ExpenseData, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, id := range sortedIds(r.db.expenses) {
+		expense := r.db.expenses[id]
+		if expense.RecurringId != nil && *expense.RecurringId == recurringId && expense.OccurrenceDate.Equal(occurrence.Time) {
+			expense = r.db.withTags(expense)
+			return &expense, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryRecurringRepository) Overrides(recurringId uint) ([]RecurringOverride, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return append([]RecurringOverride{}, r.db.overrides[recurringId]...), nil
+}
+
+func (r *memoryRecurringRepository) SaveOverride(override *RecurringOverride) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	overrides := r.db.overrides[override.RecurringId]
+	i, found := slices.BinarySearchFunc(overrides, override.Occurrence, func(o RecurringOverride, d Date) int {
+		return o.Occurrence.Compare(d.Time)
+	})
+	if found {
+		override.ID = overrides[i].ID
+		overrides[i] = *override
+		return nil
+	}
+	override.ID = r.db.nextId("recurring_overrides")
+	r.db.overrides[override.RecurringId] = slices.Insert(overrides, i, *override)
+	return nil
+}
+
+func (r *memoryRecurringRepository) DeleteOverride(recurringId uint, occurrence Date) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	overrides := r.db.overrides[recurringId]
+	i := slices.IndexFunc(overrides, func(o RecurringOverride) bool { return o.Occurrence.Equal(occurrence.Time) })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.db.overrides[recurringId] = slices.Delete(overrides, i, i+1)
+	return nil
+}
+
+func (r *memoryBudgetRepository) Create(budget *Budget) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	budget.ID = r.db.nextId("budgets")
+	budget.CreatedAt, budget.UpdatedAt = now, now
+	r.db.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (r *memoryBudgetRepository) FindById(id int64) (*Budget, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	budget, ok := r.db.budgets[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &budget, nil
+}
+
+func (r *memoryBudgetRepository) List(userId int64) ([]Budget, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	budgets := []Budget{}
+	for _, id := range sortedIds(r.db.budgets) {
+		if r.db.budgets[id].UserId == userId {
+			budgets = append(budgets, r.db.budgets[id])
+		}
+	}
+	return budgets, nil
+}
+
+func (r *memoryBudgetRepository) Update(budget *Budget) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.budgets[budget.ID]; !ok {
+		return ErrNotFound
+	}
+	budget.UpdatedAt = time.Now()
+	r.db.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (r *memoryBudgetRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.budgets[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.budgets, uint(id))
+	return nil
+}
+
+func (r *memoryTokenRepository) Create(token *RefreshToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.createRefreshToken(token)
+	return nil
+}
+
+// createRefreshToken stores a new refresh token, callers must hold the write
+// lock
+func (m *memoryDB) createRefreshToken(token *RefreshToken) {
+	token.ID = m.nextId("refresh_tokens")
+	token.CreatedAt = time.Now().UTC()
+	m.refreshTokens[token.ID] = *token
+}
+
+func (r *memoryTokenRepository) FindByHash(hash string) (*RefreshToken, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, token := range r.db.refreshTokens {
+		if token.Hash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryTokenRepository) Rotate(token, next *RefreshToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.refreshTokens[token.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		return ErrTokenReused
+	}
+	now := time.Now().UTC()
+	stored.UsedAt = &now
+	r.db.refreshTokens[token.ID] = stored
+	r.db.createRefreshToken(next)
+	token.UsedAt = &now
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeFamily(family string) error {
+	return r.revoke(func(token RefreshToken) bool { return token.Family == family })
+}
+
+func (r *memoryTokenRepository) RevokeUser(userId int64) error {
+	return r.revoke(func(token RefreshToken) bool { return token.UserId == userId })
+}
+
+// revoke revokes the refresh tokens matching the condition and adds the
+// access tokens issued with them that have not expired to the revocation list
+func (r *memoryTokenRepository) revoke(matches func(RefreshToken) bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, token := range r.db.refreshTokens {
+		if !matches(token) {
+			continue
+		}
+		if token.AccessExpiresAt.After(now) {
+			r.db.revokedTokens[token.AccessId] = token.AccessExpiresAt
+		}
+		if token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.db.refreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeAccess(jti string, expiresAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, expiry := range r.db.revokedTokens {
+		if !expiry.After(now) {
+			delete(r.db.revokedTokens, id)
+		}
+	}
+	r.db.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *memoryTokenRepository) IsRevoked(jti string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	expiry, ok := r.db.revokedTokens[jti]
+	return ok && expiry.After(time.Now()), nil
+}
+
+func (r *memoryEmailTokenRepository) Create(token *EmailToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, t := range r.db.emailTokens {
+		if t.UserId == token.UserId && t.Purpose == token.Purpose && t.UsedAt == nil {
+			delete(r.db.emailTokens, id)
+		}
+	}
+	token.ID = r.db.nextId("email_tokens")
+	token.CreatedAt = time.Now().UTC()
+	r.db.emailTokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryEmailTokenRepository) Consume(hash, purpose string) (*EmailToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, token := range r.db.emailTokens {
+		if token.Hash != hash || token.Purpose != purpose {
+			continue
+		}
+		if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			return nil, ErrNotFound
+		}
+		token.UsedAt = &now
+		r.db.emailTokens[id] = token
+		return &token, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryMFARepository) FindSettings(userId int64) (*MFASettings, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	settings, ok := r.db.mfaSettings[userId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &settings, nil
+}
+
+func (r *memoryMFARepository) SaveSettings(settings *MFASettings) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if settings.CreatedAt.IsZero() {
+		settings.CreatedAt = time.Now()
+	}
+	r.db.mfaSettings[settings.UserId] = *settings
+	return nil
+}
+
+func (r *memoryMFARepository) DeleteSettings(userId int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.deleteRecoveryCodes(userId)
+	delete(r.db.mfaSettings, userId)
+	return nil
+}
+
+func (r *memoryMFARepository) UseStep(userId, step int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	settings, ok := r.db.mfaSettings[userId]
+	if !ok || settings.LastStep >= step {
+		return ErrNotFound
+	}
+	settings.LastStep = step
+	r.db.mfaSettings[userId] = settings
+	return nil
+}
+
+func (r *memoryMFARepository) ReplaceRecoveryCodes(userId int64, hashes []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.deleteRecoveryCodes(userId)
+	for _, hash := range hashes {
+		id := r.db.nextId("recovery_codes")
+		r.db.recoveryCodes[id] = RecoveryCode{ID: id, UserId: userId, Hash: hash}
+	}
+	return nil
+}
+
+// deleteRecoveryCodes deletes the recovery codes of a user, callers must hold
+// the write lock
+func (m *memoryDB) deleteRecoveryCodes(userId int64) {
+	for id, code := range m.recoveryCodes {
+		if code.UserId == userId {
+			delete(m.recoveryCodes, id)
+		}
+	}
+}
+
+func (r *memoryMFARepository) UseRecoveryCode(userId int64, hash string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, code := range r.db.recoveryCodes {
+		if code.UserId == userId && code.Hash == hash && code.UsedAt == nil {
+			now := time.Now().UTC()
+			code.UsedAt = &now
+			r.db.recoveryCodes[id] = code
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryMFARepository) CountRecoveryCodes(userId int64) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	count := 0
+	for _, code := range r.db.recoveryCodes {
+		if code.UserId == userId && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryMFARepository) CreateChallenge(challenge *MFAChallenge) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, c := range r.db.mfaChallenges {
+		if c.ExpiresAt.Before(now) {
+			delete(r.db.mfaChallenges, id)
+		}
+	}
+	challenge.ID = r.db.nextId("mfa_challenges")
+	challenge.CreatedAt = now
+	r.db.mfaChallenges[challenge.ID] = *challenge
+	return nil
+}
+
+func (r *memoryMFARepository) FindChallenge(hash string) (*MFAChallenge, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	now := time.Now().UTC()
+	for _, challenge := range r.db.mfaChallenges {
+		if challenge.Hash == hash && challenge.UsedAt == nil && challenge.ExpiresAt.After(now) &&
+			challenge.Attempts < MFAChallengeAttempts {
+			return &challenge, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryMFARepository) FailChallenge(id uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if challenge, ok := r.db.mfaChallenges[id]; ok {
+		challenge.Attempts++
+		r.db.mfaChallenges[id] = challenge
+	}
+	return nil
+}
+
+func (r *memoryMFARepository) CompleteChallenge(id uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	challenge, ok := r.db.mfaChallenges[id]
+	if !ok || challenge.UsedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	challenge.UsedAt = &now
+	r.db.mfaChallenges[id] = challenge
+	return nil
+}
+
+func (r *memoryAPITokenRepository) Create(token *APIToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	token.ID = r.db.nextId("api_tokens")
+	token.CreatedAt = time.Now().UTC()
+	r.db.apiTokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryAPITokenRepository) FindByHash(hash string) (*APIToken, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, token := range r.db.apiTokens {
+		if token.Hash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAPITokenRepository) List(userId int64) ([]APIToken, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	tokens := []APIToken{}
+	ids := sortedIds(r.db.apiTokens)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if token := r.db.apiTokens[ids[i]]; token.UserId == userId {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryAPITokenRepository) Delete(userId int64, id uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if token, ok := r.db.apiTokens[id]; !ok || token.UserId != userId {
+		return ErrNotFound
+	}
+	delete(r.db.apiTokens, id)
+	return nil
+}
+
+func (r *memoryAPITokenRepository) DeleteByUser(userId int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, token := range r.db.apiTokens {
+		if token.UserId == userId {
+			delete(r.db.apiTokens, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAPITokenRepository) Touch(id uint, usedAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if token, ok := r.db.apiTokens[id]; ok {
+		token.LastUsedAt = &usedAt
+		r.db.apiTokens[id] = token
+	}
+	return nil
+}
+
+func (r *memoryRateRepository) Save(rates []ExchangeRate) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, rate := range rates {
+		pair := r.db.rates[rate.Base+rate.Quote]
+		i := sort.Search(len(pair), func(i int) bool { return !pair[i].Date.Before(rate.Date.Time) })
+		if i < len(pair) && pair[i].Date.Equal(rate.Date.Time) {
+			pair[i].Rate = rate.Rate
+			continue
+		}
+		rate.ID = r.db.nextId("exchange_rates")
+		pair = append(pair, ExchangeRate{})
+		copy(pair[i+1:], pair[i:])
+		pair[i] = rate
+		r.db.rates[rate.Base+rate.Quote] = pair
+	}
+	return nil
+}
+
+func (r *memoryRateRepository) FindLatest(base, quote string, on Date) (*ExchangeRate, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	pair := r.db.rates[base+quote]
+	i := sort.Search(len(pair), func(i int) bool { return pair[i].Date.After(on.Time) })
+	if i == 0 {
+		return nil, ErrNotFound
+	}
+	rate := pair[i-1]
+	return &rate, nil
+}
+
+func (r *memoryRateRepository) Bases() ([]string, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var bases []string
+	for pair := range r.db.rates {
+		if base := pair[:3]; !slices.Contains(bases, base) {
+			bases = append(bases, base)
+		}
+	}
+	slices.Sort(bases)
+	return bases, nil
+}
+
+func (r *memoryAuditRepository) Record(entry *AuditEntry) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	entry.ID = r.db.nextId("audit")
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	r.db.audit[entry.ID] = *entry
+	return nil
+}
+
+func (r *memoryAuditRepository) List(q AuditQuery) (*AuditPage, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	page := &AuditPage{Entries: []AuditEntry{}}
+	ids := sortedIds(r.db.audit)
+	// entries are recorded in order, the newest has the greatest ID
+	for i := len(ids) - 1; i >= 0; i-- {
+		entry := r.db.audit[ids[i]]
+		if q.UserId != 0 && (entry.UserId == nil || *entry.UserId != q.UserId) {
+			continue
+		}
+		if q.ActorId != 0 && (entry.ActorId == nil || *entry.ActorId != q.ActorId) {
+			continue
+		}
+		if !q.matchAction(entry.Action) {
+			continue
+		}
+		page.Total++
+		if page.Total > int64(q.Offset) && len(page.Entries) < q.Limit {
+			page.Entries = append(page.Entries, entry)
+		}
+	}
+	return page, nil
+}
+
+func (r *memoryExportRepository) Create(export *DataExport) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	export.ID = r.db.nextId("exports")
+	export.CreatedAt = time.Now()
+	r.db.exports[export.ID] = *export
+	return nil
+}
+
+func (r *memoryExportRepository) FindById(id int64) (*DataExport, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	export, ok := r.db.exports[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &export, nil
+}
+
+func (r *memoryExportRepository) List(userId int64) ([]DataExport, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	exports := []DataExport{}
+	ids := sortedIds(r.db.exports)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if export := r.db.exports[ids[i]]; export.UserId == userId {
+			exports = append(exports, export)
+		}
+	}
+	return exports, nil
+}
+
+func (r *memoryExportRepository) Pending() ([]DataExport, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var exports []DataExport
+	for _, id := range sortedIds(r.db.exports) {
+		if export := r.db.exports[id]; export.Status == ExportPending {
+			exports = append(exports, export)
+		}
+	}
+	return exports, nil
+}
+
+func (r *memoryExportRepository) Complete(export *DataExport, archive []byte) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// the user may have been deleted while the export was built
+	if _, ok := r.db.exports[export.ID]; !ok {
+		return ErrNotFound
+	}
+	if archive != nil {
+		r.db.exportFiles[export.ID] = archive
+	}
+	r.db.exports[export.ID] = *export
+	return nil
+}
+
+func (r *memoryExportRepository) Archive(id uint) ([]byte, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	archive, ok := r.db.exportFiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return archive, nil
+}
+
+func (r *memoryExportRepository) DeleteExpired(now time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var deleted int64
+	for id, export := range r.db.exports {
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(now) {
+			delete(r.db.exportFiles, id)
+			delete(r.db.exports, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (l *memoryLoginLimiter) Wait(key string, now time.Time) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	throttle := l.throttles[key]
+	return throttle.Wait(now), nil
+}
+
+func (l *memoryLoginLimiter) Fail(key string, policy LoginPolicy, now time.Time) (*LoginThrottle, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for k, throttle := range l.throttles {
+		if throttle.expired(now) {
+			delete(l.throttles, k)
+		}
+	}
+	throttle := l.throttles[key]
+	throttle.Key = key
+	locked := policy.Fail(&throttle, now)
+	l.throttles[key] = throttle
+	return &throttle, locked, nil
+}
+
+func (l *memoryLoginLimiter) Reset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.throttles, key)
+	return nil
+}
+
+func (r *memoryImportMappingRepository) Create(mapping *ImportMapping) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	mapping.ID = r.db.nextId("importMappings")
+	mapping.CreatedAt, mapping.UpdatedAt = now, now
+	r.db.importMappings[mapping.ID] = *mapping
+	return nil
+}
+
+func (r *memoryImportMappingRepository) FindById(id int64) (*ImportMapping, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	mapping, ok := r.db.importMappings[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &mapping, nil
+}
+
+func (r *memoryImportMappingRepository) List(userId int64) ([]ImportMapping, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	mappings := []ImportMapping{}
+	for _, id := range sortedIds(r.db.importMappings) {
+		if mapping := r.db.importMappings[id]; mapping.UserId == userId {
+			mappings = append(mappings, mapping)
+		}
+	}
+	sort.SliceStable(mappings, func(i, j int) bool { return mappings[i].Name < mappings[j].Name })
+	return mappings, nil
+}
+
+func (r *memoryImportMappingRepository) Update(mapping *ImportMapping) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.importMappings[mapping.ID]; !ok {
+		return ErrNotFound
+	}
+	mapping.UpdatedAt = time.Now()
+	r.db.importMappings[mapping.ID] = *mapping
+	return nil
+}
+
+func (r *memoryImportMappingRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.importMappings[uint(id)]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.importMappings, uint(id))
+	return nil
+}
+
+func (r *memoryImportRepository) Create(imp *Import, rows []ImportRow) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	imp.ID = r.db.nextId("imports")
+	imp.CreatedAt = time.Now()
+	for i := range rows {
+		rows[i].ID = r.db.nextId("importRows")
+		rows[i].ImportId = imp.ID
+	}
+	r.db.imports[imp.ID] = *imp
+	r.db.importRows[imp.ID] = append([]ImportRow(nil), rows...)
+	return nil
+}
+
+func (r *memoryImportRepository) FindById(id int64) (*Import, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	imp, ok := r.db.imports[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &imp, nil
+}
+
+func (r *memoryImportRepository) List(userId int64) ([]Import, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	imports := []Import{}
+	ids := sortedIds(r.db.imports)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if imp := r.db.imports[ids[i]]; imp.UserId == userId {
+			imports = append(imports, imp)
+		}
+	}
+	return imports, nil
+}
+
+func (r *memoryImportRepository) Rows(importId uint) ([]ImportRow, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rows := append([]ImportRow{}, r.db.importRows[importId]...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Line < rows[j].Line })
+	return rows, nil
+}
+
+func (r *memoryImportRepository) Commit(imp *Import, expenses []ExpenseData) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.imports[imp.ID]
+	if !ok || stored.Status != ImportPreview {
+		return ErrImportState
+	}
+	now := time.Now().UTC()
+	for i := range expenses {
+		expenses[i].ID = r.db.nextId("expenses")
+		expenses[i].CreatedAt, expenses[i].UpdatedAt = now, now
+		r.db.expenses[expenses[i].ID] = expenses[i]
+	}
+	stored.Status, stored.Created, stored.CommittedAt = ImportCommitted, len(expenses), &now
+	r.db.imports[imp.ID] = stored
+	*imp = stored
+	return nil
+}
+
+func (r *memoryImportRepository) Rollback(imp *Import) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.imports[imp.ID]
+	if !ok || stored.Status != ImportCommitted {
+		return ErrImportState
+	}
+	for id, expense := range r.db.expenses {
+		if expense.ImportId != nil && *expense.ImportId == imp.ID {
+			delete(r.db.expenseTags, id)
+			delete(r.db.expenses, id)
+		}
+	}
+	now := time.Now().UTC()
+	stored.Status, stored.RolledBackAt = ImportRolledBack, &now
+	r.db.imports[imp.ID] = stored
+	*imp = stored
+	return nil
+}
+
+func (r *memoryImportRepository) Delete(id int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	imp, ok := r.db.imports[uint(id)]
+	if !ok || imp.Status == ImportCommitted {
+		return ErrImportState
+	}
+	delete(r.db.importRows, uint(id))
+	delete(r.db.imports, uint(id))
+	return nil
+}
+
+func (r *memoryImportRepository) Imported(userId int64, externalIds []string) (map[string]bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, id := range externalIds {
+		wanted[id] = true
+	}
+	imported := map[string]bool{}
+	for _, expense := range r.db.expenses {
+		if expense.UserId == userId && wanted[expense.ExternalId] {
+			imported[expense.ExternalId] = true
+		}
+	}
+	return imported, nil
+}
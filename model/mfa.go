@@ -0,0 +1,168 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters, the defaults of RFC 6238 that every authenticator app
+// supports
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	// TOTPSkew is the number of periods a code may be early or late, to
+	// allow for clock drift
+	TOTPSkew = 1
+)
+
+// MFAChallengeTTL is how long a login waiting for its second factor can be
+// completed, MFAChallengeAttempts how many wrong codes it accepts
+const (
+	MFAChallengeTTL      = 5 * time.Minute
+	MFAChallengeAttempts = 5
+)
+
+// RecoveryCodeCount is the number of recovery codes issued at a time
+const RecoveryCodeCount = 10
+
+// MFASettings holds the TOTP secret of a user. Two-factor authentication is
+// enabled once the enrollment is confirmed with a code.
+type MFASettings struct {
+	UserId      int64 `gorm:"primary_key;auto_increment:false"`
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastStep is the time step of the last accepted code, a code is never
+	// accepted twice
+	LastStep  int64
+	CreatedAt time.Time
+}
+
+// Enabled tells whether the enrollment was confirmed
+func (s *MFASettings) Enabled() bool {
+	return s.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is lost. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID     uint   `gorm:"primary_key"`
+	UserId int64  `gorm:"index"`
+	Hash   string `gorm:"index"`
+	UsedAt *time.Time
+}
+
+// MFAChallenge is a login that passed the password check and waits for the
+// second factor. Only the SHA-256 hash of the challenge is stored.
+type MFAChallenge struct {
+	ID        uint   `gorm:"primary_key"`
+	UserId    int64  `gorm:"index"`
+	Hash      string `gorm:"unique_index"`
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewMFAChallenge creates a challenge for the user and returns it with the
+// challenge to hand to the client, which is never stored
+func NewMFAChallenge(userId int64) (string, *MFAChallenge) {
+	challenge := randomToken()
+	return challenge, &MFAChallenge{
+		UserId:    userId,
+		Hash:      HashToken(challenge),
+		ExpiresAt: time.Now().UTC().Add(MFAChallengeTTL),
+	}
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random 160-bit secret encoded in base32
+func NewTOTPSecret() string {
+	raw := make([]byte, 20)
+	rand.Read(raw)
+	return totpEncoding.EncodeToString(raw)
+}
+
+// TOTPURI returns the otpauth URI of a secret, authenticator apps read it
+// from a QR code
+func TOTPURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(TOTPPeriod))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// TOTPCode returns the code of a secret for a time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000), nil
+}
+
+// TOTPStep returns the time step a time falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// MatchTOTP returns the time step of the code if it is valid at the given
+// time, allowing TOTPSkew steps of drift, and 0 otherwise
+func MatchTOTP(secret, code string, t time.Time) int64 {
+	if len(code) != TOTPDigits {
+		return 0
+	}
+	now := TOTPStep(t)
+	for step := now - TOTPSkew; step <= now+TOTPSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step
+		}
+	}
+	return 0
+}
+
+// NewRecoveryCodes returns RecoveryCodeCount random codes formatted as
+// xxxxx-xxxxx
+func NewRecoveryCodes() []string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		rand.Read(raw)
+		for j := range raw {
+			raw[j] = alphabet[raw[j]&31]
+		}
+		codes[i] = string(raw[:5]) + "-" + string(raw[5:])
+	}
+	return codes
+}
+
+// HashRecoveryCode returns the hash under which a recovery code is stored,
+// the code is compared without case, spaces or dashes
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(code)
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// the test vectors of RFC 6238, cut to six digits
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("%d: got %s, %v, want %s", unix, got, err, want)
+		}
+	}
+	// secrets are accepted in lower case, as some apps show them
+	if got, _ := TOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1); got != "287082" {
+		t.Errorf("lower case secret: got %s, want 287082", got)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("an invalid secret was accepted")
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	code := func(step int64) string {
+		c, _ := TOTPCode(rfcSecret, step)
+		return c
+	}
+
+	tests := []struct {
+		name, secret, code string
+		want               int64
+	}{
+		{"current", rfcSecret, code(step), step},
+		{"one period early", rfcSecret, code(step - 1), step - 1},
+		{"one period late", rfcSecret, code(step + 1), step + 1},
+		{"two periods early", rfcSecret, code(step - 2), 0},
+		{"two periods late", rfcSecret, code(step + 2), 0},
+		{"wrong code", rfcSecret, "000000", 0},
+		{"too short", rfcSecret, code(step)[:5], 0},
+		{"too long", rfcSecret, code(step) + "0", 0},
+		{"empty", rfcSecret, "", 0},
+		{"other secret", NewTOTPSecret(), code(step), 0},
+		{"invalid secret", "not base32!", code(step), 0},
+	}
+	for _, tt := range tests {
+		if got := MatchTOTP(tt.secret, tt.code, now); got != tt.want {
+			t.Errorf("%s: got step %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// legacyDateLayouts are tried in order on the free-form strings stored before
+// expense dates became a DATE column, DD/MM/YYYY is what the API used to expect
+var legacyDateLayouts = []string{
+	ISODate,
+	"02/01/2006",
+	"2/1/2006",
+	"2006/01/02",
+	"2006-01-02 15:04:05",
+	time.RFC3339Nano,
+}
+
+// UnconvertedDate is an expense whose stored date could not be understood
+type UnconvertedDate struct {
+	ExpenseId uint
+	UserId    int64
+	Value     string
+}
+
+// DateMigrationReport summarises a run of MigrateExpenseDates
+type DateMigrationReport struct {
+	Converted int
+	Unchanged int
+	Failed    []UnconvertedDate
+}
+
+// MigrateExpenseDates rewrites the string dates of every expense, including
+// soft deleted ones, as YYYY-MM-DD and turns the column into a DATE column on
+// MySQL. Dates that cannot be parsed are cleared and returned in the report
+// together with their original value so they can be fixed by hand. Running
+// it again on migrated data changes nothing.
+func MigrateExpenseDates(db *gorm.DB) (*DateMigrationReport, error) {
+	// the SQLite driver turns the text of DATE columns into times, and text
+	// it cannot parse into the zero time, so the stored text is read as is
+	column := "CAST(date AS CHAR)"
+	if db.Dialect().GetName() == "sqlite3" {
+		column = "CAST(date AS TEXT)"
+	}
+	rows, err := db.Table("expense_data").Select("id, user_id, " + column).Rows()
+	if err != nil {
+		return nil, err
+	}
+	type storedDate struct {
+		id     uint
+		userId int64
+		value  sql.NullString
+	}
+	var stored []storedDate
+	for rows.Next() {
+		var s storedDate
+		if err := rows.Scan(&s.id, &s.userId, &s.value); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored = append(stored, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &DateMigrationReport{}
+	tx := db.Begin()
+	for _, s := range stored {
+		if !s.value.Valid {
+			report.Unchanged++
+			continue
+		}
+
+		// empty strings become NULL, the zero Date
+		var converted interface{}
+		if s.value.String != "" {
+			for _, layout := range legacyDateLayouts {
+				if t, err := time.Parse(layout, s.value.String); err == nil {
+					converted = t.Format(ISODate)
+					break
+				}
+			}
+			if converted == nil {
+				report.Failed = append(report.Failed, UnconvertedDate{ExpenseId: s.id, UserId: s.userId, Value: s.value.String})
+			}
+		}
+		if converted == s.value.String {
+			report.Unchanged++
+			continue
+		}
+		if converted != nil || s.value.String == "" {
+			report.Converted++
+		}
+
+		if err := tx.Table("expense_data").Where("id = ?", s.id).UpdateColumn("date", converted).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	// SQLite compares the ISO strings correctly whatever the declared type,
+	// MySQL needs the column converted
+	if db.Dialect().GetName() == "mysql" {
+		if err := db.Model(&ExpenseData{}).ModifyColumn("date", "date").Error; err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// checkMigrations refuses a database holding expenses in a format older than
+// the one the repositories read, which the data migrations have to convert
+// first
+func checkMigrations(db *gorm.DB) error {
+	var dates int64
+	err := db.Unscoped().Model(&ExpenseData{}).Where("date IS NOT NULL AND date NOT LIKE '____-__-__'").Count(&dates).Error
+	if err != nil {
+		return err
+	}
+	if dates > 0 {
+		return fmt.Errorf("%d expenses have dates stored as free-form text, convert them with -migrate-dates first", dates)
+	}
+
+	// amounts of expenses without a currency are only in the old column
+	if !db.Dialect().HasColumn("expense_data", "amount") {
+		return nil
+	}
+	var amounts int64
+	err = db.Unscoped().Model(&ExpenseData{}).Where("currency IS NULL OR currency = ''").Count(&amounts).Error
+	if err != nil {
+		return err
+	}
+	if amounts > 0 {
+		return fmt.Errorf("%d expenses have floating point amounts without a currency, convert them with -migrate-amounts first", amounts)
+	}
+	return nil
+}
+
+// MigrateExpenseAmounts fills the minor unit amount and currency of the
+// expenses stored before amounts carried a currency, from the floating point
+// amount column they used. Existing amounts are taken to be in the given
+// currency and rounded to its precision. Expenses that already have a
+// currency are left alone, so running it again changes nothing. It returns
+// the number of expenses converted. The old amount column is kept.
+func MigrateExpenseAmounts(db *gorm.DB, currency string) (int, error) {
+	if !ValidCurrency(currency) {
+		return 0, fmt.Errorf("invalid currency %q", currency)
+	}
+	if !db.Dialect().HasColumn("expense_data", "amount") {
+		return 0, nil
+	}
+	// add the amount_minor and currency columns if the server never ran
+	if err := db.AutoMigrate(&ExpenseData{}).Error; err != nil {
+		return 0, err
+	}
+
+	rows, err := db.Table("expense_data").
+		Select("id, amount").
+		Where("currency IS NULL OR currency = ''").
+		Rows()
+	if err != nil {
+		return 0, err
+	}
+	type storedAmount struct {
+		id     uint
+		amount sql.NullFloat64
+	}
+	var stored []storedAmount
+	for rows.Next() {
+		var s storedAmount
+		if err := rows.Scan(&s.id, &s.amount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stored = append(stored, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	scale := math.Pow10(MinorUnits(currency))
+	tx := db.Begin()
+	for _, s := range stored {
+		minor := int64(math.Round(s.amount.Float64 * scale))
+		err := tx.Table("expense_data").Where("id = ?", s.id).
+			UpdateColumns(map[string]interface{}{"amount_minor": minor, "currency": currency}).Error
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(stored), tx.Commit().Error
+}
+
+// CategoryMigrationReport summarises a run of MigrateExpenseCategories
+type CategoryMigrationReport struct {
+	Linked  int64
+	Created []Category
+}
+
+// MigrateExpenseCategories links the expenses stored before categories had
+// their own table to the categories of their owner. Every user gets the
+// default categories, a category is created for each other name found on
+// their expenses, and expenses without a category are linked to "Others".
+// Expenses already linked are skipped, so running it again changes nothing.
+func MigrateExpenseCategories(db *gorm.DB) (*CategoryMigrationReport, error) {
+	if err := db.AutoMigrate(&ExpenseData{}, &Category{}).Error; err != nil {
+		return nil, err
+	}
+
+	var userIds []int64
+	if err := db.Unscoped().Model(&UserData{}).Pluck("id", &userIds).Error; err != nil {
+		return nil, err
+	}
+	for _, userId := range userIds {
+		if err := seedCategories(db, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := db.Table("expense_data").
+		Select("DISTINCT user_id, category").
+		Where("category_id IS NULL OR category_id = 0").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	type storedCategory struct {
+		userId int64
+		name   sql.NullString
+	}
+	var stored []storedCategory
+	for rows.Next() {
+		var s storedCategory
+		if err := rows.Scan(&s.userId, &s.name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored = append(stored, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &CategoryMigrationReport{}
+	tx := db.Begin()
+	for _, s := range stored {
+		name := s.name.String
+		if name == "" {
+			name = "Others"
+		}
+		var category Category
+		err := tx.Where("user_id = ? AND name = ?", s.userId, name).First(&category).Error
+		if gorm.IsRecordNotFoundError(err) {
+			category = Category{UserId: s.userId, Name: name}
+			err = tx.Create(&category).Error
+			report.Created = append(report.Created, category)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		query := tx.Table("expense_data").
+			Where("user_id = ? AND (category_id IS NULL OR category_id = 0)", s.userId)
+		if s.name.Valid {
+			query = query.Where("category = ?", s.name.String)
+		} else {
+			query = query.Where("category IS NULL")
+		}
+		result := query.UpdateColumns(map[string]interface{}{"category_id": category.ID, "category": name})
+		if result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+		report.Linked += result.RowsAffected
+	}
+	return report, tx.Commit().Error
+}
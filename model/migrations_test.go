@@ -0,0 +1,157 @@
+package model
+
+import (
+	"expense-tracker/config"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+// openLegacyDB returns a SQLite database with the expense table of the current
+// schema, for the tests to fill with rows in the formats of older versions
+func openLegacyDB(t *testing.T) *gorm.DB {
+	db, err := config.Connect(config.DriverSQLite, filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.AutoMigrate(&ExpenseData{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateExpenseDates(t *testing.T) {
+	db := openLegacyDB(t)
+	legacy := []struct {
+		value interface{}
+		want  string
+	}{
+		{"2026-10-01", "2026-10-01"},
+		{"15/10/2026", "2026-10-15"},
+		{"5/1/2026", "2026-01-05"},
+		{"2026/10/20", "2026-10-20"},
+		{"2026-10-21 10:30:00", "2026-10-21"},
+		{"2026-10-22T23:30:00Z", "2026-10-22"},
+		{"", ""},
+		{"next tuesday", ""},
+		{nil, ""},
+	}
+	for i, row := range legacy {
+		if err := db.Exec("INSERT INTO expense_data (user_id, title, date) VALUES (1, ?, ?)", i, row.value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// soft deleted expenses are converted as well
+	if err := db.Exec("INSERT INTO expense_data (user_id, title, date, deleted_at) VALUES (1, 'deleted', '01/02/2026', '2026-03-01')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// the dates cannot be read before they are converted
+	if _, err := NewGormStore(db); err == nil || !strings.Contains(err.Error(), "-migrate-dates") {
+		t.Errorf("store before the migration: got %v, want an error naming -migrate-dates", err)
+	}
+
+	report, err := MigrateExpenseDates(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Converted != 7 || report.Unchanged != 2 || len(report.Failed) != 1 {
+		t.Errorf("report: got %d converted, %d unchanged, %d failed, want 7, 2 and 1", report.Converted, report.Unchanged, len(report.Failed))
+	}
+	// the rows it cannot convert are reported with their value
+	if len(report.Failed) == 1 && (report.Failed[0].Value != "next tuesday" || report.Failed[0].UserId != 1) {
+		t.Errorf("failed: got %+v", report.Failed[0])
+	}
+
+	store, err := NewGormStore(db)
+	if err != nil {
+		t.Fatalf("store after the migration: %v", err)
+	}
+	page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: 1}, Sort: SortById, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Expenses) != len(legacy) {
+		t.Fatalf("got %d expenses, want %d", len(page.Expenses), len(legacy))
+	}
+	for i, e := range page.Expenses {
+		if e.Date.String() != legacy[i].want {
+			t.Errorf("%v: got %q, want %q", legacy[i].value, e.Date, legacy[i].want)
+		}
+	}
+
+	// running it again changes nothing
+	report, err = MigrateExpenseDates(db)
+	if err != nil || report.Converted != 0 || len(report.Failed) != 0 {
+		t.Errorf("second run: got %+v, %v", report, err)
+	}
+}
+
+func TestMigrateExpenseAmounts(t *testing.T) {
+	db := openLegacyDB(t)
+	if err := db.Exec("ALTER TABLE expense_data ADD COLUMN amount real").Error; err != nil {
+		t.Fatal(err)
+	}
+	legacy := []struct {
+		amount interface{}
+		want   int64
+	}{
+		{12.5, 1250},
+		{19.99, 1999},
+		// 0.29 and 1234567.89 are not exact in floating point
+		{0.29, 29},
+		{1234567.89, 123456789},
+		{-4.35, -435},
+		{0, 0},
+		{nil, 0},
+	}
+	for i, row := range legacy {
+		if err := db.Exec("INSERT INTO expense_data (user_id, title, amount) VALUES (1, ?, ?)", i, row.amount).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// expenses that have a currency already are left alone
+	if err := db.Exec("INSERT INTO expense_data (user_id, title, amount, amount_minor, currency) VALUES (1, 'euros', 99.99, 500, 'EUR')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// the amounts cannot be read before they are converted
+	if _, err := NewGormStore(db); err == nil || !strings.Contains(err.Error(), "-migrate-amounts") {
+		t.Errorf("store before the migration: got %v, want an error naming -migrate-amounts", err)
+	}
+	if _, err := MigrateExpenseAmounts(db, "XXX"); err == nil {
+		t.Error("an invalid currency was accepted")
+	}
+
+	converted, err := MigrateExpenseAmounts(db, "USD")
+	if err != nil || converted != len(legacy) {
+		t.Fatalf("got %d converted, %v, want %d", converted, err, len(legacy))
+	}
+	store, err := NewGormStore(db)
+	if err != nil {
+		t.Fatalf("store after the migration: %v", err)
+	}
+	page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: 1}, Sort: SortById, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Expenses) != len(legacy)+1 {
+		t.Fatalf("got %d expenses, want %d", len(page.Expenses), len(legacy)+1)
+	}
+	for i, row := range legacy {
+		if e := page.Expenses[i]; e.Amount != row.want || e.Currency != "USD" {
+			t.Errorf("%v: got %d %s, want %d USD", row.amount, e.Amount, e.Currency, row.want)
+		}
+	}
+	if e := page.Expenses[len(legacy)]; e.Amount != 500 || e.Currency != "EUR" {
+		t.Errorf("expense with a currency: got %d %s, want 500 EUR", e.Amount, e.Currency)
+	}
+
+	// running it again changes nothing
+	if converted, err := MigrateExpenseAmounts(db, "USD"); err != nil || converted != 0 {
+		t.Errorf("second run: got %d converted, %v", converted, err)
+	}
+}
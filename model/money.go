@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// currencyExceptions holds the number of decimal places of the active ISO
+// 4217 currencies that do not use two
+var currencyExceptions = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// twoDecimalCurrencies lists the remaining active ISO 4217 codes
+var twoDecimalCurrencies = strings.Fields(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV
+	BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUC CUP CVE
+	CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GTQ GYD HKD
+	HNL HRK HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR
+	LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD
+	NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR
+	SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TOP TRY
+	TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD YER ZAR ZMW ZWL`)
+
+// minorUnits maps every active ISO 4217 code onto its number of decimal places
+var minorUnits = func() map[string]int {
+	units := map[string]int{}
+	for _, code := range twoDecimalCurrencies {
+		units[code] = 2
+	}
+	for code, digits := range currencyExceptions {
+		units[code] = digits
+	}
+	return units
+}()
+
+// AmountScale is the number of decimal places amounts are normalised to when
+// amounts in different currencies are compared, it covers every currency
+const AmountScale = 4
+
+// ValidCurrency reports whether code is an active ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places used by a currency
+func MinorUnits(currency string) int {
+	if digits, ok := minorUnits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// ParseScaledAmount parses a decimal amount into AmountScale decimal places,
+// the unit used by the amount criteria of ExpenseFilter
+func ParseScaledAmount(value string) (int64, error) {
+	return parseDecimal(value, AmountScale, "")
+}
+
+// ParseAmount converts a decimal amount such as "12.50" into minor units of
+// the currency without going through floating point. It rejects amounts with
+// more decimal places than the currency has.
+func ParseAmount(value, currency string) (int64, error) {
+	return parseDecimal(value, MinorUnits(currency), currency)
+}
+
+func parseDecimal(value string, digits int, currency string) (int64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > digits {
+		if digits == 0 {
+			return 0, fmt.Errorf("%s amounts cannot have decimal places", currency)
+		}
+		if currency == "" {
+			return 0, fmt.Errorf("amounts have at most %d decimal places", digits)
+		}
+		return 0, fmt.Errorf("%s amounts have at most %d decimal places", currency, digits)
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is out of range", value)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// FormatAmount renders minor units as a decimal with the number of decimal
+// places of the currency, e.g. 1250 USD as "12.50"
+func FormatAmount(minor int64, currency string) string {
+	digits := MinorUnits(currency)
+	sign, value := "", strconv.FormatInt(minor, 10)
+	if minor < 0 {
+		sign, value = "-", value[1:]
+	}
+	if digits == 0 {
+		return sign + value
+	}
+	if len(value) <= digits {
+		value = strings.Repeat("0", digits-len(value)+1) + value
+	}
+	return sign + value[:len(value)-digits] + "." + value[len(value)-digits:]
+}
+
+// scaledAmount normalises minor units to AmountScale decimal places so that
+// amounts in currencies with different precisions compare correctly
+func scaledAmount(minor int64, currency string) int64 {
+	return minor * int64(math.Pow10(AmountScale-MinorUnits(currency)))
+}
+
+// scaledAmountColumn is the SQL counterpart of scaledAmount
+var scaledAmountColumn = func() string {
+	byDigits := map[int][]string{}
+	for code, digits := range currencyExceptions {
+		byDigits[digits] = append(byDigits[digits], "'"+code+"'")
+	}
+	expr := "(CASE"
+	for _, digits := range []int{0, 3, 4} {
+		slices.Sort(byDigits[digits])
+		expr += fmt.Sprintf(" WHEN currency IN (%s) THEN amount_minor * %d", strings.Join(byDigits[digits], ", "), int64(math.Pow10(AmountScale-digits)))
+	}
+	return expr + fmt.Sprintf(" ELSE amount_minor * %d END)", int64(math.Pow10(AmountScale-2)))
+}()
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinorUnits(t *testing.T) {
+	tests := map[string]int{
+		"USD": 2, "EUR": 2, "NGN": 2,
+		"JPY": 0, "KRW": 0, "XOF": 0,
+		"KWD": 3, "BHD": 3, "TND": 3,
+		"CLF": 4,
+		// unknown codes fall back to two decimal places
+		"XXX": 2, "": 2,
+	}
+	for currency, want := range tests {
+		if got := MinorUnits(currency); got != want {
+			t.Errorf("%q: got %d decimal places, want %d", currency, got, want)
+		}
+	}
+	if ValidCurrency("usd") || ValidCurrency("XXX") || !ValidCurrency("USD") {
+		t.Error("ValidCurrency accepts lower case or unknown codes")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value, currency string
+		want            int64
+		err             string
+	}{
+		{"12.50", "USD", 1250, ""},
+		{"12.5", "USD", 1250, ""},
+		{"12", "USD", 1200, ""},
+		{" 0.01 ", "EUR", 1, ""},
+		{"-3.20", "EUR", -320, ""},
+		{"12.500", "USD", 1250, ""},
+		{"1500", "JPY", 1500, ""},
+		{"1500.00", "JPY", 1500, ""},
+		{"12.345", "KWD", 12345, ""},
+		{"1.2345", "CLF", 12345, ""},
+		{"0.1", "USD", 10, ""},
+		// amounts are never rounded
+		{"12.505", "USD", 0, "USD amounts have at most 2 decimal places"},
+		{"1500.5", "JPY", 0, "JPY amounts cannot have decimal places"},
+		{"12.3456", "KWD", 0, "KWD amounts have at most 3 decimal places"},
+		{"", "USD", 0, "invalid amount"},
+		{".50", "USD", 0, "invalid amount"},
+		{"1,50", "USD", 0, "invalid amount"},
+		{"1.2.3", "USD", 0, "invalid amount"},
+		{"1e3", "USD", 0, "invalid amount"},
+		{"+5", "USD", 0, "invalid amount"},
+		{"--5", "USD", 0, "invalid amount"},
+		{"92233720368547758.08", "USD", 0, "out of range"},
+	}
+	for _, test := range tests {
+		got, err := ParseAmount(test.value, test.currency)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%q %s: got %d, %v, want an error containing %q", test.value, test.currency, got, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%q %s: got %d, %v, want %d", test.value, test.currency, got, err, test.want)
+		}
+	}
+}
+
+func TestParseScaledAmount(t *testing.T) {
+	got, err := ParseScaledAmount("12.5")
+	if err != nil || got != 125000 {
+		t.Errorf("got %d, %v, want 125000", got, err)
+	}
+	if _, err := ParseScaledAmount("0.00001"); err == nil || !strings.Contains(err.Error(), "at most 4 decimal places") {
+		t.Errorf("got %v, want an error about decimal places", err)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{1250, "USD", "12.50"},
+		{5, "USD", "0.05"},
+		{0, "USD", "0.00"},
+		{-320, "EUR", "-3.20"},
+		{-5, "EUR", "-0.05"},
+		{1500, "JPY", "1500"},
+		{-1500, "JPY", "-1500"},
+		{12345, "KWD", "12.345"},
+		{7, "KWD", "0.007"},
+		{12345, "CLF", "1.2345"},
+	}
+	for _, test := range tests {
+		if got := FormatAmount(test.minor, test.currency); got != test.want {
+			t.Errorf("%d %s: got %q, want %q", test.minor, test.currency, got, test.want)
+		}
+		// what is written is read back unchanged
+		if back, err := ParseAmount(test.want, test.currency); err != nil || back != test.minor {
+			t.Errorf("%q %s: read back as %d, %v", test.want, test.currency, back, err)
+		}
+	}
+}
+
+func TestScaledAmount(t *testing.T) {
+	// 15 JPY, 15.00 USD and 15.000 KWD compare as the same number
+	for _, a := range []struct {
+		minor    int64
+		currency string
+	}{{15, "JPY"}, {1500, "USD"}, {15000, "KWD"}, {150000, "CLF"}} {
+		if got := scaledAmount(a.minor, a.currency); got != 150000 {
+			t.Errorf("%d %s: got %d, want 150000", a.minor, a.currency, got)
+		}
+	}
+}
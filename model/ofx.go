@@ -0,0 +1,283 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ofxNode is an element of an OFX document. Leaf elements hold a value,
+// aggregates hold other elements.
+type ofxNode struct {
+	name     string
+	value    string
+	line     int
+	children []*ofxNode
+}
+
+// child returns the first element of the path below the node, or nil
+func (n *ofxNode) child(path ...string) *ofxNode {
+	for _, name := range path {
+		if n == nil {
+			return nil
+		}
+		var found *ofxNode
+		for _, c := range n.children {
+			if c.name == name {
+				found = c
+				break
+			}
+		}
+		n = found
+	}
+	return n
+}
+
+// text returns the value of the element of the path below the node, or the
+// empty string
+func (n *ofxNode) text(path ...string) string {
+	if c := n.child(path...); c != nil {
+		return c.value
+	}
+	return ""
+}
+
+// find returns the elements with the name anywhere below the node, in
+// document order
+func (n *ofxNode) find(name string) []*ofxNode {
+	if n == nil {
+		return nil
+	}
+	var found []*ofxNode
+	for _, c := range n.children {
+		if c.name == name {
+			found = append(found, c)
+		} else {
+			found = append(found, c.find(name)...)
+		}
+	}
+	return found
+}
+
+var (
+	ofxCharset     = regexp.MustCompile(`(?im)^\s*CHARSET:\s*(\S+)`)
+	ofxXMLEncoding = regexp.MustCompile(`(?i)<\?xml[^>]*encoding\s*=\s*["']([^"']+)["']`)
+)
+
+// ParseOFX reads the transactions of the bank and credit card statements in
+// an OFX 1.x (SGML) or 2.x (XML) file. Amounts are in the currency of their
+// statement, or currency when the file does not tell. Transactions carry the
+// account and FITID the bank identifies them with.
+func ParseOFX(r io.Reader, currency string) ([]ImportRow, error) {
+	data, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+	start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+	if start < 0 {
+		return nil, errors.New("the file is not an OFX statement")
+	}
+	header := string(data[:start])
+	text, err := decodeStatement(data[start:], ofxEncoding(header))
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseOFXElements(text, 1+strings.Count(header, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []*ofxNode
+	for _, name := range []string{"STMTRS", "CCSTMTRS"} {
+		statements = append(statements, root.find(name)...)
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("the file holds no bank or credit card statement")
+	}
+
+	rows := []ImportRow{}
+	for _, statement := range statements {
+		account := statement.text("BANKACCTFROM", "ACCTID")
+		if account == "" {
+			account = statement.text("CCACCTFROM", "ACCTID")
+		}
+		statementCurrency := strings.ToUpper(statement.text("CURDEF"))
+		if statementCurrency == "" {
+			statementCurrency = currency
+		}
+		for _, transaction := range statement.child("BANKTRANLIST").find("STMTTRN") {
+			if len(rows) == MaxImportRows {
+				return nil, fmt.Errorf("the file has more than %d transactions", MaxImportRows)
+			}
+			rows = append(rows, ofxRow(transaction, account, statementCurrency))
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file has no transactions")
+	}
+	SkipDuplicates(rows)
+	return rows, nil
+}
+
+// ofxRow turns a STMTTRN element into a row
+func ofxRow(t *ofxNode, account, currency string) ImportRow {
+	name := t.text("NAME")
+	if name == "" {
+		name = t.text("PAYEE", "NAME")
+	}
+	row := ImportRow{Line: t.line, Title: name, Description: t.text("MEMO"), Currency: currency, Status: RowValid}
+	if row.Title == "" {
+		row.Title = row.Description
+	}
+	if row.Description == "" {
+		row.Description = row.Title
+	}
+	if code := t.text("CURRENCY", "CURSYM"); code != "" {
+		row.Currency = strings.ToUpper(code)
+	}
+	if fitId := t.text("FITID"); fitId != "" {
+		row.ExternalId = fitId
+		if account != "" {
+			row.ExternalId = account + ":" + fitId
+		}
+	}
+	invalid := func(message string) ImportRow {
+		row.Status, row.Message = RowInvalid, message
+		return row
+	}
+
+	if row.Title == "" {
+		return invalid("The name and memo are empty")
+	}
+	if !ValidCurrency(row.Currency) {
+		return invalid(fmt.Sprintf("Invalid currency %q", row.Currency))
+	}
+	posted := t.text("DTPOSTED")
+	if posted == "" {
+		posted = t.text("DTUSER")
+	}
+	date, err := parseOFXDate(posted)
+	if err != nil {
+		return invalid(fmt.Sprintf("Invalid date %q", posted))
+	}
+	row.Date = date
+	value := t.text("TRNAMT")
+	amount, err := parseStatementAmount(value, guessDecimalSeparator(value), row.Currency)
+	if err != nil {
+		return invalid(fmt.Sprintf("Invalid amount %q", value))
+	}
+	switch {
+	case amount > 0:
+		row.Status, row.Message = RowSkipped, "Credit, not an expense"
+	case amount == 0:
+		row.Status, row.Message = RowSkipped, "The amount is zero"
+	default:
+		row.Amount = -amount
+	}
+	return row
+}
+
+// parseOFXDate reads the date of an OFX datetime, YYYYMMDD optionally
+// followed by the time and time zone
+func parseOFXDate(value string) (Date, error) {
+	if len(value) < 8 {
+		return Date{}, errors.New("invalid date")
+	}
+	return ParseDate(value[:4]+"-"+value[4:6]+"-"+value[6:8], "")
+}
+
+// parseOFXElements builds the element tree of an OFX document. Closing tags
+// are optional for leaf elements in OFX 1.x, an element holding a value ends
+// with the next tag.
+func parseOFXElements(text string, line int) (*ofxNode, error) {
+	root := &ofxNode{}
+	stack := []*ofxNode{root}
+	for len(text) > 0 {
+		if text[0] != '<' {
+			end := strings.IndexByte(text, '<')
+			if end < 0 {
+				end = len(text)
+			}
+			top := stack[len(stack)-1]
+			if value := strings.TrimSpace(text[:end]); value != "" && top != root {
+				top.value = html.UnescapeString(value)
+			}
+			line += strings.Count(text[:end], "\n")
+			text = text[end:]
+			continue
+		}
+
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("the tag on line %d is not closed", line)
+		}
+		tag := strings.TrimSpace(text[1:end])
+		tagLine := line
+		line += strings.Count(text[:end], "\n")
+		text = text[end+1:]
+
+		switch {
+		case tag == "" || tag[0] == '?' || tag[0] == '!':
+			// processing instructions and comments
+		case tag[0] == '/':
+			name := strings.ToUpper(strings.TrimSpace(tag[1:]))
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		default:
+			if top := stack[len(stack)-1]; top.value != "" {
+				stack = stack[:len(stack)-1]
+			}
+			selfClosing := strings.HasSuffix(tag, "/")
+			name := strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(tag, "/")))
+			if i := strings.IndexAny(name, " \t\r\n"); i >= 0 {
+				name = name[:i]
+			}
+			node := &ofxNode{name: name, line: tagLine}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			if !selfClosing {
+				stack = append(stack, node)
+			}
+		}
+	}
+	return root, nil
+}
+
+// ofxEncoding reads the single byte encoding an OFX header declares, the
+// empty string for UTF-8 and the encodings we do not know
+func ofxEncoding(header string) string {
+	name := ""
+	if m := ofxXMLEncoding.FindStringSubmatch(header); m != nil {
+		name = m[1]
+	} else if m := ofxCharset.FindStringSubmatch(header); m != nil {
+		name = m[1]
+	}
+	switch strings.ToUpper(name) {
+	case "1252", "WINDOWS-1252", "CP1252":
+		return EncodingWindows1252
+	case "ISO-8859-1", "8859-1", "LATIN1":
+		return EncodingISO88591
+	}
+	return ""
+}
+
+// decodeStatement decodes a statement file in the encoding, or without one
+// as UTF-8 falling back to Windows-1252, which most banking software writes
+func decodeStatement(data []byte, encoding string) (string, error) {
+	if encoding == "" {
+		encoding = EncodingUTF8
+		if !utf8.Valid(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))) {
+			encoding = EncodingWindows1252
+		}
+	}
+	return decodeText(data, encoding)
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rowSummary is what the parser tests compare of each row
+type rowSummary struct {
+	Line        int
+	Date        string
+	Title       string
+	Description string
+	Amount      int64
+	Currency    string
+	ExternalId  string
+	Status      string
+	Message     string
+}
+
+func summarize(rows []ImportRow) []rowSummary {
+	res := make([]rowSummary, len(rows))
+	for i, r := range rows {
+		res[i] = rowSummary{r.Line, r.Date.String(), r.Title, r.Description, r.Amount, r.Currency, r.ExternalId, r.Status, r.Message}
+	}
+	return res
+}
+
+func compareRows(t *testing.T, got []ImportRow, want []rowSummary) {
+	t.Helper()
+	rows := summarize(got)
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d:\n got %+v\nwant %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseOFXVersion1(t *testing.T) {
+	rows, err := ParseOFX(openFixture(t, "checking-v1.ofx"), "GBP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareRows(t, rows, []rowSummary{
+		{39, "2026-09-02", "Café Luna", "POS PURCHASE", 4210, "USD", "123456789:20260902001", RowValid, ""},
+		{47, "2026-09-05", "ACME PAYROLL", "ACME PAYROLL", 0, "USD", "123456789:20260905001", RowSkipped, "Credit, not an expense"},
+		{54, "2026-09-10", "Rent & Parking", "Rent & Parking", 125000, "USD", "123456789:20260910001", RowValid, ""},
+		{62, "2026-09-02", "Café Luna", "Café Luna", 4210, "USD", "123456789:20260902001", RowSkipped, "Duplicate of line 39"},
+		{69, "2026-09-12", "Online store", "Online store", 1500, "EUR", "123456789:20260912001", RowValid, ""},
+		{80, "", "Bad date", "Bad date", 0, "USD", "123456789:20260913001", RowInvalid, `Invalid date "2026"`},
+	})
+}
+
+func TestParseOFXVersion2(t *testing.T) {
+	rows, err := ParseOFX(openFixture(t, "card-v2.ofx"), "GBP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareRows(t, rows, []rowSummary{
+		{21, "2026-09-03", "Müller & Söhne", "Hardware", 2345, "EUR", "4111XXXXXXXX1111:C-0001", RowValid, ""},
+		{30, "2026-09-15", "Payment, thank you", "Payment, thank you", 0, "EUR", "4111XXXXXXXX1111:C-0002", RowSkipped, "Credit, not an expense"},
+		{37, "2026-09-20", "Streaming Co", "Streaming Co", 999, "EUR", "4111XXXXXXXX1111:C-0003", RowValid, ""},
+	})
+}
+
+func TestParseOFXErrors(t *testing.T) {
+	tests := []struct {
+		name, file, err string
+	}{
+		{"not OFX", "date,amount\n2026-09-01,1.00\n", "not an OFX statement"},
+		{"no statement", "OFXHEADER:100\n\n<OFX>\n<SIGNONMSGSRSV1>\n<SONRS>\n<STATUS>\n<CODE>0\n</STATUS>\n</SONRS>\n</SIGNONMSGSRSV1>\n</OFX>\n", "no bank or credit card statement"},
+		{"no transactions", "<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD</CURDEF><BANKTRANLIST></BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>", "no transactions"},
+		{"unclosed tag", "<OFX><BANKMSGSRSV1", "not closed"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseOFX(strings.NewReader(test.file), "USD")
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"checking-v1.ofx": ImportOFX,
+		"card-v2.ofx":     ImportOFX,
+		"checking.qif":    ImportQIF,
+		"card-dmy.qif":    ImportQIF,
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DetectImportFormat(data); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+	if got := DetectImportFormat([]byte("Date,Amount\n")); got != ImportCSV {
+		t.Errorf("CSV: got %q", got)
+	}
+}
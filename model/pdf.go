@@ -0,0 +1,184 @@
+package model
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// A4 page size in points
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+)
+
+// pdfDocument draws text, lines and boxes on pages and writes them as a PDF
+// file using the Helvetica fonts every PDF reader has, so nothing needs to be
+// embedded. Text is limited to the characters of Windows-1252. Positions are
+// measured in points from the top left corner of the page.
+type pdfDocument struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+}
+
+func (d *pdfDocument) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// text writes s with its baseline at y
+func (d *pdfDocument) text(x, y float64, bold bool, size float64, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %s Tf %s %s Td (", font, pdfNumber(size), pdfNumber(x), pdfNumber(pdfPageHeight-y))
+	for _, b := range pdfEncode(s) {
+		if b == '(' || b == ')' || b == '\\' {
+			d.page.WriteByte('\\')
+		}
+		d.page.WriteByte(b)
+	}
+	d.page.WriteString(") Tj ET\n")
+}
+
+// fillRect fills the rectangle whose top left corner is at x and y in a
+// shade of gray, from 0 for black to 1 for white
+func (d *pdfDocument) fillRect(x, y, width, height, gray float64) {
+	fmt.Fprintf(d.page, "%s g %s %s %s %s re f 0 g\n", pdfNumber(gray),
+		pdfNumber(x), pdfNumber(pdfPageHeight-y-height), pdfNumber(width), pdfNumber(height))
+}
+
+func (d *pdfDocument) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page, "%s w %s %s m %s %s l S\n", pdfNumber(width),
+		pdfNumber(x1), pdfNumber(pdfPageHeight-y1), pdfNumber(x2), pdfNumber(pdfPageHeight-y2))
+}
+
+// WriteTo writes the PDF file
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&out, format, args...)
+		out.WriteString("\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	// the catalog, the page tree and the fonts come first, the pages and
+	// their contents follow in pairs
+	kids := &bytes.Buffer{}
+	for i := range d.pages {
+		fmt.Fprintf(kids, "%d 0 R ", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(d.pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Title (%s) /Producer (Expense Tracker) /CreationDate (D:%s) >>",
+		pdfEscape(pdfEncode(d.title)), time.Now().UTC().Format("20060102150405Z"))
+	for _, page := range d.pages {
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		zw.Write(page.Bytes())
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(pdfPageWidth), pdfNumber(pdfPageHeight), len(offsets)+2)
+		object("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
+
+func pdfNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func pdfEscape(s []byte) []byte {
+	return bytes.ReplaceAll(bytes.ReplaceAll(bytes.ReplaceAll(s, []byte(`\`), []byte(`\\`)), []byte("("), []byte(`\(`)), []byte(")"), []byte(`\)`))
+}
+
+// pdfEncode converts text to Windows-1252, characters it lacks become '?'
+func pdfEncode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		default:
+			b := byte('?')
+			for i, c := range windows1252 {
+				if c == r {
+					b = byte(0x80 + i)
+					break
+				}
+			}
+			encoded = append(encoded, b)
+		}
+	}
+	return encoded
+}
+
+// pdfTextWidth measures text written in Helvetica, in points
+func pdfTextWidth(s string, bold bool, size float64) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, b := range pdfEncode(s) {
+		switch {
+		case b >= 32 && b < 127:
+			total += widths[b-32]
+		case b >= 0xC0:
+			// accented letters are about as wide as the letters they are
+			// built on
+			total += widths[latinBase[b-0xC0]-32]
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// helveticaWidths and helveticaBoldWidths are the widths of the characters
+// from space to tilde in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// latinBase maps the letters from 0xC0 to 0xFF in Windows-1252 onto the
+// ASCII letters they are measured as
+var latinBase = [64]byte{
+	'A', 'A', 'A', 'A', 'A', 'A', 'W', 'C', 'E', 'E', 'E', 'E', 'I', 'I', 'I', 'I',
+	'D', 'N', 'O', 'O', 'O', 'O', 'O', '*', 'O', 'U', 'U', 'U', 'U', 'Y', 'P', 'h',
+	'a', 'a', 'a', 'a', 'a', 'a', 'm', 'c', 'e', 'e', 'e', 'e', 'i', 'i', 'i', 'i',
+	'o', 'n', 'o', 'o', 'o', 'o', 'o', '+', 'o', 'u', 'u', 'u', 'u', 'y', 'p', 'y',
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// pdfFile is what the tests read back of a PDF file
+type pdfFile struct {
+	// objects holds the body of each object by number
+	objects map[int]string
+	// pages holds the decompressed content of each page, in order
+	pages []string
+	info  string
+}
+
+var (
+	pdfStartXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfTrailer   = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root (\d+) 0 R /Info (\d+) 0 R >>`)
+	pdfKids      = regexp.MustCompile(`/Kids \[((?:\d+ 0 R )*)\] /Count (\d+)`)
+	pdfReference = regexp.MustCompile(`(\d+) 0 R`)
+	pdfContents  = regexp.MustCompile(`/Contents (\d+) 0 R`)
+	pdfStream    = regexp.MustCompile(`(?s)^<< /Length (\d+) /Filter /FlateDecode >>\nstream\n(.*)\nendstream$`)
+)
+
+// readPDF reads a PDF file written by pdfDocument through its cross-reference
+// table, the way a reader does, failing the test on any inconsistency
+func readPDF(t *testing.T, data []byte) *pdfFile {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("no PDF header: %.20q", data)
+	}
+	match := pdfStartXref.FindSubmatch(data)
+	if match == nil {
+		t.Fatal("no startxref at the end of the file")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to the cross-reference table", xref)
+	}
+
+	var first, count int
+	rest := data[xref+len("xref\n"):]
+	if _, err := fmt.Sscanf(string(rest), "%d %d\n", &first, &count); err != nil || first != 0 {
+		t.Fatalf("cross-reference table: got %q, %v", rest[:20], err)
+	}
+	rest = rest[bytes.IndexByte(rest, '\n')+1:]
+	file := &pdfFile{objects: map[int]string{}}
+	for i := 0; i < count; i++ {
+		// every entry is 20 bytes long, end of line included
+		entry := string(rest[20*i : 20*(i+1)])
+		if i == 0 {
+			if entry != "0000000000 65535 f \n" {
+				t.Errorf("entry 0: got %q", entry)
+			}
+			continue
+		}
+		offset, err := strconv.Atoi(entry[:10])
+		if err != nil || entry[10:] != " 00000 n \n" {
+			t.Fatalf("entry %d: got %q", i, entry)
+		}
+		header := fmt.Sprintf("%d 0 obj\n", i)
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Fatalf("object %d: offset %d points to %.20q", i, offset, data[offset:])
+		}
+		body := data[offset+len(header):]
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if end < 0 {
+			t.Fatalf("object %d has no end", i)
+		}
+		file.objects[i] = string(body[:end])
+	}
+
+	trailer := pdfTrailer.FindSubmatch(rest[20*count:])
+	if trailer == nil {
+		t.Fatalf("trailer: got %q", rest[20*count:])
+	}
+	if size, _ := strconv.Atoi(string(trailer[1])); size != count {
+		t.Errorf("trailer size %d, the table has %d entries", size, count)
+	}
+	root, _ := strconv.Atoi(string(trailer[2]))
+	info, _ := strconv.Atoi(string(trailer[3]))
+	file.info = file.objects[info]
+	catalog := regexp.MustCompile(`^<< /Type /Catalog /Pages (\d+) 0 R >>$`).FindStringSubmatch(file.objects[root])
+	if catalog == nil {
+		t.Fatalf("catalog: got %q", file.objects[root])
+	}
+	pagesId, _ := strconv.Atoi(catalog[1])
+	kids := pdfKids.FindStringSubmatch(file.objects[pagesId])
+	if kids == nil {
+		t.Fatalf("page tree: got %q", file.objects[pagesId])
+	}
+	references := pdfReference.FindAllStringSubmatch(kids[1], -1)
+	if n, _ := strconv.Atoi(kids[2]); n != len(references) {
+		t.Errorf("page tree: /Count %d with %d kids", n, len(references))
+	}
+
+	for _, reference := range references {
+		id, _ := strconv.Atoi(reference[1])
+		contents := pdfContents.FindStringSubmatch(file.objects[id])
+		if contents == nil {
+			t.Fatalf("page %d: got %q", id, file.objects[id])
+		}
+		contentsId, _ := strconv.Atoi(contents[1])
+		stream := pdfStream.FindStringSubmatch(file.objects[contentsId])
+		if stream == nil {
+			t.Fatalf("contents of page %d: got %.60q", id, file.objects[contentsId])
+		}
+		if length, _ := strconv.Atoi(stream[1]); length != len(stream[2]) {
+			t.Errorf("contents of page %d: /Length %d, the stream has %d bytes", id, length, len(stream[2]))
+		}
+		r, err := zlib.NewReader(bytes.NewReader([]byte(stream[2])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.pages = append(file.pages, string(content))
+	}
+	return file
+}
+
+func TestPDFDocument(t *testing.T) {
+	doc := &pdfDocument{title: "Statement (draft) \\ Café"}
+	for i := 1; i <= 3; i++ {
+		doc.addPage()
+		doc.text(40, 40, i == 1, 12, fmt.Sprintf("Page %d: (a) \\ b €5 – 東", i))
+		doc.line(40, 50, 200, 50, 0.5)
+		doc.fillRect(40, 60, 100, 20, 0.9)
+	}
+	var out bytes.Buffer
+	if _, err := doc.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	file := readPDF(t, out.Bytes())
+	if len(file.pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(file.pages))
+	}
+	// parentheses and backslashes are escaped, the text is in Windows-1252
+	// and the characters it lacks become question marks
+	for i, page := range file.pages {
+		want := fmt.Sprintf("(Page %d: \\(a\\) \\\\ b \x805 \x96 ?) Tj ET\n", i+1)
+		if !bytes.Contains([]byte(page), []byte(want)) {
+			t.Errorf("page %d: got %q, want it to hold %q", i+1, page, want)
+		}
+	}
+	if !bytes.Contains([]byte(file.pages[0]), []byte("BT /F2 12 Tf 40 801.89 Td")) {
+		t.Errorf("page 1: got %q, want bold text 40 points below the top", file.pages[0])
+	}
+	if !bytes.Contains([]byte(file.info), []byte("/Title (Statement \\(draft\\) \\\\ Caf\xe9)")) {
+		t.Errorf("info: got %q", file.info)
+	}
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// qifAccountTypes are the QIF sections holding the transactions of an
+// account, investment accounts and lists such as categories are skipped
+var qifAccountTypes = map[string]bool{
+	"bank":  true,
+	"cash":  true,
+	"ccard": true,
+	"oth a": true,
+	"oth l": true,
+}
+
+// ParseQIF reads the transactions of the bank, cash and credit card accounts
+// in a QIF file. QIF dates do not say their order, dateFormat tells whether
+// the day (DD/MM/YYYY, DD.MM.YYYY, DD-MM-YYYY) or the month comes first, the
+// month by default as Quicken writes them. QIF files carry no currency, their
+// amounts are in currency.
+func ParseQIF(r io.Reader, dateFormat, currency string) ([]ImportRow, error) {
+	data, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decodeStatement(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []ImportRow{}
+	section := ""
+	record := map[byte]string{}
+	start := 0
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch {
+		case line[0] == '!':
+			if header := strings.ToLower(strings.TrimSpace(line[1:])); strings.HasPrefix(header, "type:") {
+				section = strings.TrimSpace(header[len("type:"):])
+			} else if !strings.HasPrefix(header, "option:") && !strings.HasPrefix(header, "clear:") {
+				// !Account describes the account of the next section
+				section = header
+			}
+			clear(record)
+		case line[0] == '^':
+			if qifAccountTypes[section] && len(record) > 0 {
+				if len(rows) == MaxImportRows {
+					return nil, fmt.Errorf("the file has more than %d transactions", MaxImportRows)
+				}
+				rows = append(rows, qifRow(record, start, dateFormat, currency))
+			}
+			clear(record)
+		default:
+			if len(record) == 0 {
+				start = i + 1
+			}
+			// split lines repeat their codes, the first of each is kept
+			if _, ok := record[line[0]]; !ok {
+				record[line[0]] = strings.TrimSpace(line[1:])
+			}
+		}
+	}
+	if qifAccountTypes[section] && len(record) > 0 && len(rows) < MaxImportRows {
+		rows = append(rows, qifRow(record, start, dateFormat, currency))
+	}
+	if section == "" && len(rows) == 0 {
+		return nil, errors.New("the file is not a QIF file, it does not start with a !Type line")
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file has no bank, cash or credit card transactions")
+	}
+	return rows, nil
+}
+
+// qifRow turns the fields of a QIF record into a row: D is the date, T (or
+// U) the amount, P the payee and M the memo
+func qifRow(record map[byte]string, line int, dateFormat, currency string) ImportRow {
+	row := ImportRow{Line: line, Title: record['P'], Description: record['M'], Currency: currency, Status: RowValid}
+	if row.Title == "" {
+		row.Title = row.Description
+	}
+	if row.Description == "" {
+		row.Description = row.Title
+	}
+	invalid := func(message string) ImportRow {
+		row.Status, row.Message = RowInvalid, message
+		return row
+	}
+
+	if row.Title == "" {
+		return invalid("The payee and memo are empty")
+	}
+	if !ValidCurrency(row.Currency) {
+		return invalid(fmt.Sprintf("Invalid currency %q", row.Currency))
+	}
+	date, err := parseQIFDate(record['D'], dateFormat)
+	if err != nil {
+		return invalid(fmt.Sprintf("Invalid date %q", record['D']))
+	}
+	row.Date = date
+	value, ok := record['T']
+	if !ok {
+		value = record['U']
+	}
+	amount, err := parseStatementAmount(value, guessDecimalSeparator(value), row.Currency)
+	if err != nil {
+		return invalid(fmt.Sprintf("Invalid amount %q", value))
+	}
+	switch {
+	case amount > 0:
+		row.Status, row.Message = RowSkipped, "Credit, not an expense"
+	case amount == 0:
+		row.Status, row.Message = RowSkipped, "The amount is zero"
+	default:
+		row.Amount = -amount
+	}
+	return row
+}
+
+// parseQIFDate reads the dates of QIF files, such as 09/05/2026, 9/ 5/26 or
+// 9/ 5'26 as Quicken writes years from 2000. Two digit years from 70 are in
+// the 1900s. Dates starting with a four digit year are read as year, month
+// and day.
+func parseQIFDate(value, dateFormat string) (Date, error) {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '\'' || r == ' '
+	})
+	if len(parts) != 3 {
+		return Date{}, errors.New("invalid date")
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Date{}, errors.New("invalid date")
+		}
+		numbers[i] = n
+	}
+
+	var year, month, day int
+	switch {
+	case len(parts[0]) == 4:
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	case strings.HasPrefix(dateFormat, "DD"):
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	default:
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	}
+	if len(parts[2]) <= 2 && len(parts[0]) != 4 {
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return Date{}, errors.New("invalid date")
+	}
+	return NewDate(t), nil
+}
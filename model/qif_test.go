@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQIF(t *testing.T) {
+	rows, err := ParseQIF(openFixture(t, "checking.qif"), "", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareRows(t, rows, []rowSummary{
+		{6, "2026-09-02", "Corner Shop", "Milk and bread", 4210, "USD", "", RowValid, ""},
+		{12, "2026-09-05", "ACME Payroll", "ACME Payroll", 0, "USD", "", RowSkipped, "Credit, not an expense"},
+		{16, "2026-09-10", "Landlord", "Landlord", 125000, "USD", "", RowValid, ""},
+		{25, "", "Invalid month", "Invalid month", 0, "USD", "", RowInvalid, `Invalid date "13/09/2026"`},
+		{29, "2026-09-15", "Zero", "Zero", 0, "USD", "", RowSkipped, "The amount is zero"},
+		{33, "2026-09-16", "Coffee", "Coffee", 350, "USD", "", RowValid, ""},
+	})
+}
+
+func TestParseQIFDayFirst(t *testing.T) {
+	rows, err := ParseQIF(openFixture(t, "card-dmy.qif"), "DD.MM.YYYY", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareRows(t, rows, []rowSummary{
+		{2, "2026-08-31", "Hôtel du Lac", "Hôtel du Lac", 123456, "EUR", "", RowValid, ""},
+		{6, "2026-09-01", "Bakery", "Bakery", 1250, "EUR", "", RowValid, ""},
+	})
+}
+
+func TestParseQIFErrors(t *testing.T) {
+	tests := []struct {
+		name, file, err string
+	}{
+		{"not QIF", "date,amount\n2026-09-01,1.00\n", "not a QIF file"},
+		{"no transactions", "!Type:Cat\nNGroceries\n^\n", "no bank, cash or credit card transactions"},
+		{"investments only", "!Type:Invst\nD09/01/2026\nNBuy\nT-100.00\n^\n", "no bank, cash or credit card transactions"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseQIF(strings.NewReader(test.file), "", "USD")
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseQIFDate(t *testing.T) {
+	tests := []struct {
+		value, format, want string
+	}{
+		{"09/05/2026", "", "2026-09-05"},
+		{"9/ 5'26", "", "2026-09-05"},
+		{"9/5/98", "", "1998-09-05"},
+		{"05/09/2026", "DD/MM/YYYY", "2026-09-05"},
+		{"05.09.26", "DD.MM.YYYY", "2026-09-05"},
+		{"2026-09-05", "DD/MM/YYYY", "2026-09-05"},
+		{"02/30/2026", "", ""},
+		{"2026", "", ""},
+	}
+	for _, test := range tests {
+		date, err := parseQIFDate(test.value, test.format)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%q: got %s, want an error", test.value, date)
+			}
+			continue
+		}
+		if err != nil || date.String() != test.want {
+			t.Errorf("%q with %q: got %s, %v, want %s", test.value, test.format, date, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies accepted in the FREQ part of a recurrence rule
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// maxOccurrences bounds the occurrences generated in one call so that a rule
+// starting decades ago cannot stall the scheduler
+const maxOccurrences = 10000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is the subset of an RFC 5545 RRULE that makes sense for dated
+// expenses: FREQ, INTERVAL, BYDAY for weekly rules, BYMONTHDAY for monthly
+// and yearly rules, COUNT and UNTIL. The first occurrence is the start date
+// of the recurring expense, like DTSTART.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	// ByDay lists the weekdays of a weekly rule, the weekday of the start
+	// date when empty
+	ByDay []time.Weekday
+	// ByMonthDay lists the days of a monthly or yearly rule, negative days
+	// count from the end of the month so -1 is the last day. The day of the
+	// start date is used when empty.
+	ByMonthDay []int
+	Count      int
+	Until      Date
+}
+
+// ParseRecurrence parses a rule such as "FREQ=MONTHLY;BYMONTHDAY=1" or
+// "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10"
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			if !slices.Contains([]string{FreqDaily, FreqWeekly, FreqMonthly, FreqYearly}, value) {
+				return nil, fmt.Errorf("unsupported FREQ %q, use DAILY, WEEKLY, MONTHLY or YEARLY", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(value); err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(value); err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+		case "UNTIL":
+			// UNTIL may be a date or a date-time, only the date is kept
+			if len(value) >= 8 {
+				var t time.Time
+				if t, err = time.Parse("20060102", value[:8]); err == nil {
+					r.Until = NewDate(t)
+				}
+			}
+			if r.Until.IsZero() {
+				return nil, fmt.Errorf("invalid UNTIL %q, use YYYYMMDD", value)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q, use MO, TU, WE, TH, FR, SA or SU", day)
+				}
+				if !slices.Contains(r.ByDay, weekday) {
+					r.ByDay = append(r.ByDay, weekday)
+				}
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(v)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", v)
+				}
+				if !slices.Contains(r.ByMonthDay, day) {
+					r.ByMonthDay = append(r.ByMonthDay, day)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("use either COUNT or UNTIL, not both")
+	}
+	if len(r.ByDay) > 0 && r.Freq != FreqWeekly {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != FreqMonthly && r.Freq != FreqYearly {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY or FREQ=YEARLY")
+	}
+	return r, nil
+}
+
+// String formats the rule in its canonical form
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekday := range r.ByDay {
+			for name, d := range weekdays {
+				if d == weekday {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns, in order, the occurrences of a rule starting on start
+// that fall between from and to inclusive. Dates that do not exist, such as
+// the 31st of a 30 day month, are skipped as RFC 5545 requires.
+func (r *Recurrence) Between(start, from, to Date) []Date {
+	last := to
+	if !r.Until.IsZero() && r.Until.Before(last.Time) {
+		last = r.Until
+	}
+
+	var dates []Date
+	seen := 0
+	for period := 0; ; period++ {
+		first, candidates := r.period(start, period)
+		if first.After(last.Time) {
+			return dates
+		}
+		for _, date := range candidates {
+			if date.Before(start.Time) {
+				continue
+			}
+			if date.After(last.Time) {
+				return dates
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return dates
+			}
+			if !date.Before(from.Time) {
+				dates = append(dates, date)
+				if len(dates) == maxOccurrences {
+					return dates
+				}
+			}
+		}
+	}
+}
+
+// period returns the first day of the nth period of the rule and the sorted
+// candidate dates within it
+func (r *Recurrence) period(start Date, n int) (Date, []Date) {
+	step := n * r.Interval
+	switch r.Freq {
+	case FreqDaily:
+		day := Date{start.AddDate(0, 0, step)}
+		return day, []Date{day}
+
+	case FreqWeekly:
+		monday := Date{start.AddDate(0, 0, -(int(start.Weekday())+6)%7+7*step)}
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		var dates []Date
+		for _, weekday := range days {
+			dates = append(dates, Date{monday.AddDate(0, 0, (int(weekday)+6)%7)})
+		}
+		slices.SortFunc(dates, func(a, b Date) int { return a.Compare(b.Time) })
+		return monday, dates
+
+	case FreqMonthly:
+		first := Date{time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)}
+		return first, r.monthDays(start, first)
+	}
+
+	first := Date{time.Date(start.Year()+step, 1, 1, 0, 0, 0, 0, time.UTC)}
+	month := Date{time.Date(first.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)}
+	return first, r.monthDays(start, month)
+}
+
+// monthDays resolves the BYMONTHDAY days, or the day of the start date, in
+// the month starting on first
+func (r *Recurrence) monthDays(start, first Date) []Date {
+	days := r.ByMonthDay
+	if len(days) == 0 {
+		days = []int{start.Day()}
+	}
+	length := first.AddDate(0, 1, -1).Day()
+
+	var dates []Date
+	for _, day := range days {
+		if day < 0 {
+			day = length + day + 1
+		}
+		if day >= 1 && day <= length {
+			dates = append(dates, Date{first.AddDate(0, 0, day-1)})
+		}
+	}
+	slices.SortFunc(dates, func(a, b Date) int { return a.Compare(b.Time) })
+	return slices.CompactFunc(dates, func(a, b Date) bool { return a.Equal(b.Time) })
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDate parses a YYYY-MM-DD date
+func testDate(value string) Date {
+	t, err := time.Parse(ISODate, value)
+	if err != nil {
+		panic(err)
+	}
+	return NewDate(t)
+}
+
+func joinDates(dates []Date) string {
+	s := make([]string, len(dates))
+	for i, date := range dates {
+		s[i] = date.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule, want, err string
+	}{
+		{"FREQ=MONTHLY;BYMONTHDAY=1", "FREQ=MONTHLY;BYMONTHDAY=1", ""},
+		{"rrule:freq=weekly;interval=2;byday=MO,TH;count=10", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10", ""},
+		{"FREQ=DAILY;INTERVAL=1;UNTIL=20261231T235959Z", "FREQ=DAILY;UNTIL=20261231", ""},
+		{"FREQ=YEARLY;BYMONTHDAY=-1,-1", "FREQ=YEARLY;BYMONTHDAY=-1", ""},
+		{"", "", "FREQ is required"},
+		{"FREQ=HOURLY", "", "unsupported FREQ"},
+		{"FREQ=DAILY;INTERVAL=0", "", "invalid INTERVAL"},
+		{"FREQ=DAILY;COUNT=-1", "", "invalid COUNT"},
+		{"FREQ=DAILY;UNTIL=2026", "", "invalid UNTIL"},
+		{"FREQ=WEEKLY;BYDAY=XX", "", "invalid BYDAY"},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", "", "invalid BYMONTHDAY"},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20261231", "", "either COUNT or UNTIL"},
+		{"FREQ=MONTHLY;BYDAY=MO", "", "BYDAY is only supported"},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", "", "BYMONTHDAY is only supported"},
+		{"FREQ=DAILY;BYSETPOS=1", "", "unsupported rule part"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got %v, want error %q", tt.rule, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || r.String() != tt.want {
+			t.Errorf("%q: got %v, %v, want %s", tt.rule, r, err, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	tests := []struct {
+		rule, start, from, to string
+		want                  string
+	}{
+		// days missing from a month are skipped rather than moved
+		{"FREQ=MONTHLY;BYMONTHDAY=31", "2026-01-31", "2026-01-01", "2026-06-30", "2026-01-31,2026-03-31,2026-05-31"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-15", "2026-01-01", "2026-03-31", "2026-01-31,2026-02-28,2026-03-31"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,1", "2026-01-10", "2026-01-01", "2026-02-20", "2026-01-15,2026-02-01,2026-02-15"},
+		{"FREQ=YEARLY", "2024-02-29", "2024-01-01", "2029-12-31", "2024-02-29,2028-02-29"},
+		// the Monday before the start date is not an occurrence
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4", "2026-10-15", "2026-01-01", "2027-12-31", "2026-10-15,2026-10-26,2026-10-29,2026-11-09"},
+		{"FREQ=WEEKLY", "2026-10-15", "2026-10-20", "2026-11-05", "2026-10-22,2026-10-29,2026-11-05"},
+		// COUNT counts from the start date, not from the range
+		{"FREQ=DAILY;COUNT=3", "2026-01-01", "2026-01-02", "2026-12-31", "2026-01-02,2026-01-03"},
+		{"FREQ=DAILY;INTERVAL=10;UNTIL=20260125", "2026-01-01", "2026-01-01", "2026-12-31", "2026-01-01,2026-01-11,2026-01-21"},
+		{"FREQ=DAILY", "2026-01-10", "2026-01-01", "2026-01-09", ""},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.rule, err)
+		}
+		got := joinDates(r.Between(testDate(tt.start), testDate(tt.from), testDate(tt.to)))
+		if got != tt.want {
+			t.Errorf("%q from %s: got %s, want %s", tt.rule, tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestMaterializeRecurringCatchUp(t *testing.T) {
+	store := NewMemoryStore()
+	user := UserData{Email: "jane@example.com"}
+	store.Users.Create(&user)
+	recurring := RecurringExpense{
+		UserId: int64(user.ID), Title: "Rent", Amount: 1000, Currency: "USD", Rule: "FREQ=DAILY",
+		StartDate: testDate("2000-01-01"), NextDate: testDate("2000-01-01"), Timezone: "UTC",
+	}
+	store.Recurring.Create(&recurring)
+
+	// 14611 days are due, a single run creates at most maxOccurrences of
+	// them and the next one resumes where it stopped
+	now := time.Date(2040, 1, 1, 12, 0, 0, 0, time.UTC)
+	runs := []struct {
+		created int
+		next    string
+	}{
+		{maxOccurrences, "2027-05-19"},
+		{4611, "2040-01-02"},
+		{0, "2040-01-02"},
+	}
+	for i, run := range runs {
+		created, err := MaterializeDue(store.Recurring, now)
+		stored, _ := store.Recurring.FindById(int64(recurring.ID))
+		if err != nil || created != run.created || stored.NextDate.String() != run.next {
+			t.Errorf("run %d: got %d, next %s, %v, want %d, next %s", i+1, created, stored.NextDate, err, run.created, run.next)
+		}
+	}
+}
+
+func TestDueSkipsInactiveUsers(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	users := []UserData{
+		{Email: "active@example.com"},
+		{Email: "disabled@example.com", DisabledAt: &now},
+		{Email: "deleting@example.com", DeleteAt: &now},
+	}
+	for i := range users {
+		store.Users.Create(&users[i])
+		store.Recurring.Create(&RecurringExpense{
+			UserId: int64(users[i].ID), Rule: "FREQ=DAILY", Timezone: "UTC",
+			StartDate: testDate("2026-01-01"), NextDate: testDate("2026-01-01"),
+		})
+	}
+
+	due, err := store.Recurring.Due(testDate("2026-01-31"))
+	if err != nil || len(due) != 1 || due[0].UserId != int64(users[0].ID) {
+		t.Errorf("got %v, %v, want the recurring expense of user %d only", due, err, users[0].ID)
+	}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RecurringExpense is the template of an expense that repeats on a schedule,
+// its occurrences are materialized into ExpenseData as they fall due
+type RecurringExpense struct {
+	gorm.Model
+	UserId      int64  `json:"userId" gorm:"index"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Amount is in minor units of Currency, e.g. cents for USD
+	Amount     int64  `json:"amount" gorm:"column:amount_minor"`
+	Currency   string `json:"currency" gorm:"type:char(3)"`
+	CategoryId uint   `json:"categoryId" gorm:"index"`
+	Category   string `json:"category"`
+	// Rule is the schedule as an RRULE, see Recurrence
+	Rule      string `json:"rule"`
+	StartDate Date   `json:"startDate" gorm:"type:date"`
+	EndDate   Date   `json:"endDate" gorm:"type:date"`
+	// Timezone is the IANA zone deciding when an occurrence's day has come
+	Timezone string `json:"timezone"`
+	// NextDate is the first date whose occurrences have not been
+	// materialized yet
+	NextDate Date `json:"nextDate" gorm:"type:date;index"`
+}
+
+// MarshalJSON writes the amount as an exact decimal number in the currency of
+// the recurring expense
+func (e RecurringExpense) MarshalJSON() ([]byte, error) {
+	type recurring RecurringExpense
+	return json.Marshal(struct {
+		recurring
+		Amount json.RawMessage `json:"amount"`
+	}{recurring(e), json.RawMessage(FormatAmount(e.Amount, e.Currency))})
+}
+
+// RecurringOverride skips or changes a single occurrence of a recurring
+// expense before it is materialized
+type RecurringOverride struct {
+	ID          uint `gorm:"primary_key"`
+	RecurringId uint `gorm:"unique_index:idx_recurring_override"`
+	// Occurrence is the date the rule gives the occurrence
+	Occurrence  Date `gorm:"type:date;unique_index:idx_recurring_override"`
+	Skip        bool
+	Title       string
+	Description string
+	// Amount and Date replace those of the occurrence when set
+	Amount *int64 `gorm:"column:amount_minor"`
+	Date   *Date  `gorm:"type:date"`
+}
+
+// Apply changes an occurrence's expense as the override says
+func (o *RecurringOverride) Apply(expense *ExpenseData) {
+	if o.Title != "" {
+		expense.Title = o.Title
+	}
+	if o.Description != "" {
+		expense.Description = o.Description
+	}
+	if o.Amount != nil {
+		expense.Amount = *o.Amount
+	}
+	if o.Date != nil {
+		expense.Date = *o.Date
+	}
+}
+
+// Recurrence parses the rule of the recurring expense
+func (e *RecurringExpense) Recurrence() (*Recurrence, error) {
+	return ParseRecurrence(e.Rule)
+}
+
+// Occurrences returns the occurrence dates between from and to inclusive,
+// limited by the start and end dates
+func (e *RecurringExpense) Occurrences(from, to Date) ([]Date, error) {
+	rule, err := e.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	if !e.EndDate.IsZero() && e.EndDate.Before(to.Time) {
+		to = e.EndDate
+	}
+	if to.Before(from.Time) {
+		return nil, nil
+	}
+	return rule.Between(e.StartDate, from, to), nil
+}
+
+// Expense builds the expense of the occurrence on date, without any override
+func (e *RecurringExpense) Expense(date Date) ExpenseData {
+	recurringId, occurrence := e.ID, date
+	return ExpenseData{
+		Title:          e.Title,
+		Description:    e.Description,
+		Amount:         e.Amount,
+		Currency:       e.Currency,
+		Date:           date,
+		CategoryId:     e.CategoryId,
+		Category:       e.Category,
+		UserId:         e.UserId,
+		RecurringId:    &recurringId,
+		OccurrenceDate: &occurrence,
+	}
+}
+
+// Today returns the current date in the timezone of the recurring expense
+func (e *RecurringExpense) Today(now time.Time) (Date, error) {
+	location, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return Date{}, err
+	}
+	return NewDate(now.In(location)), nil
+}
+
+// MaterializeRecurring creates the expenses of every occurrence of a
+// recurring expense due by now in its timezone and not created yet, applying
+// the overrides of single occurrences. It returns the number of expenses
+// created. Occurrences already created are never created twice, so it is
+// safe to run it again or from several instances.
+func MaterializeRecurring(repo RecurringRepository, recurring *RecurringExpense, now time.Time) (int, error) {
+	today, err := recurring.Today(now)
+	if err != nil {
+		return 0, err
+	}
+	if recurring.NextDate.After(today.Time) {
+		return 0, nil
+	}
+	dates, err := recurring.Occurrences(recurring.NextDate, today)
+	if err != nil {
+		return 0, err
+	}
+
+	overrides, err := repo.Overrides(recurring.ID)
+	if err != nil {
+		return 0, err
+	}
+	byDate := map[string]RecurringOverride{}
+	for _, o := range overrides {
+		byDate[o.Occurrence.String()] = o
+	}
+
+	var expenses []ExpenseData
+	for _, date := range dates {
+		expense := recurring.Expense(date)
+		if o, ok := byDate[date.String()]; ok {
+			if o.Skip {
+				continue
+			}
+			o.Apply(&expense)
+		}
+		expenses = append(expenses, expense)
+	}
+
+	// a capped catch-up resumes after the last occurrence it reached
+	next := Date{today.AddDate(0, 0, 1)}
+	if len(dates) == maxOccurrences {
+		next = Date{dates[len(dates)-1].AddDate(0, 0, 1)}
+	}
+	return repo.Materialize(recurring, expenses, next)
+}
+
+// MaterializeDue runs MaterializeRecurring for every recurring expense with
+// occurrences due by now. A recurring expense that fails does not stop the
+// others, the errors are returned together.
+func MaterializeDue(repo RecurringRepository, now time.Time) (int, error) {
+	// the zones furthest ahead of UTC are at most a day ahead
+	due, err := repo.Due(NewDate(now.UTC().AddDate(0, 0, 1)))
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for i := range due {
+		n, err := MaterializeRecurring(repo, &due[i], now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring expense %d: %w", due[i].ID, err))
+		}
+		created += n
+	}
+	return created, errors.Join(errs...)
+}
@@ -0,0 +1,322 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Groupings accepted by ExpenseRepository.Summarize, GroupByNone sums all
+// the matching expenses together
+const (
+	GroupByNone     = ""
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+	GroupByDay      = "day"
+	GroupByWeek     = "week"
+	GroupByMonth    = "month"
+	GroupByYear     = "year"
+)
+
+// SummaryGroupings lists the groupings a summary report may use
+var SummaryGroupings = []string{GroupByCategory, GroupByTag, GroupByDay, GroupByWeek, GroupByMonth, GroupByYear}
+
+// GroupTotal aggregates the expenses of a group in one currency. Key is the
+// category name, the tag, or the first day of the period as YYYY-MM-DD,
+// YYYY-MM or YYYY for dates.
+type GroupTotal struct {
+	Key      string `json:"key" example:"2026-10"`
+	Currency string `json:"currency" example:"USD"`
+	Count    int64  `json:"count"`
+	// Total, Min and Max are in minor units of Currency, written as decimal
+	// numbers together with the average amount of the group
+	Total int64 `json:"total" swaggertype:"number" example:"125.50"`
+	Min   int64 `json:"min" swaggertype:"number" example:"3.20"`
+	Max   int64 `json:"max" swaggertype:"number" example:"60.00"`
+	// BaseTotal is Total converted into BaseCurrency at the rate of each
+	// expense's date, in minor units of BaseCurrency
+	BaseCurrency string `json:"baseCurrency" example:"EUR"`
+	BaseTotal    int64  `json:"baseTotal" swaggertype:"number" example:"115.46"`
+	// Unconverted is the part of Total with no known rate into BaseCurrency,
+	// it is left out of BaseTotal
+	Unconverted int64 `json:"unconverted" swaggertype:"number" example:"0.00"`
+	// unconvertedCount is the number of expenses in Unconverted
+	unconvertedCount int64
+}
+
+// Average returns the mean amount of the group rounded to a minor unit
+func (g GroupTotal) Average() int64 {
+	if g.Count == 0 {
+		return 0
+	}
+	return int64(math.Round(float64(g.Total) / float64(g.Count)))
+}
+
+// MarshalJSON writes the amounts as exact decimal numbers in the currency of
+// the group
+func (g GroupTotal) MarshalJSON() ([]byte, error) {
+	amount := func(minor int64) json.RawMessage { return json.RawMessage(FormatAmount(minor, g.Currency)) }
+	return json.Marshal(struct {
+		Key          string          `json:"key,omitempty"`
+		Currency     string          `json:"currency"`
+		Count        int64           `json:"count"`
+		Total        json.RawMessage `json:"total"`
+		Average      json.RawMessage `json:"average"`
+		Min          json.RawMessage `json:"min"`
+		Max          json.RawMessage `json:"max"`
+		BaseCurrency string          `json:"baseCurrency,omitempty"`
+		BaseTotal    json.RawMessage `json:"baseTotal,omitempty"`
+		Unconverted  json.RawMessage `json:"unconverted"`
+	}{
+		g.Key, g.Currency, g.Count, amount(g.Total), amount(g.Average()), amount(g.Min), amount(g.Max),
+		g.BaseCurrency, baseAmount(g.BaseTotal, g.BaseCurrency), amount(g.Unconverted),
+	})
+}
+
+// baseAmount writes an amount in the base currency, nothing when there is no
+// base currency
+func baseAmount(minor int64, currency string) json.RawMessage {
+	if currency == "" {
+		return nil
+	}
+	return json.RawMessage(FormatAmount(minor, currency))
+}
+
+// Summary holds the totals of the expenses in a date range, per currency and
+// per group and currency
+type Summary struct {
+	StartDate Date         `json:"startDate" swaggertype:"string" example:"2026-10-01"`
+	EndDate   Date         `json:"endDate" swaggertype:"string" example:"2026-10-31"`
+	Totals    []GroupTotal `json:"totals"`
+	Groups    []GroupTotal `json:"groups"`
+	// Base is the total of every currency converted into the base currency
+	Base CurrencyTotal `json:"base"`
+	// Unconverted is the spending in currencies with no known rate into the
+	// base currency, it is left out of Base
+	Unconverted []CurrencyTotal `json:"unconverted"`
+}
+
+// TotalChange is the change of the total in one currency from an earlier
+// period to the current one
+type TotalChange struct {
+	Currency string `json:"currency" example:"USD"`
+	// Difference is in minor units of Currency, written as a decimal number
+	Difference int64 `json:"difference" swaggertype:"number" example:"-20.00"`
+	// Percent is nil when nothing was spent in the earlier period
+	Percent *float64 `json:"percent" example:"-13.7"`
+}
+
+// MarshalJSON writes the difference as an exact decimal number in its
+// currency
+func (c TotalChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Currency   string          `json:"currency"`
+		Difference json.RawMessage `json:"difference"`
+		Percent    *float64        `json:"percent"`
+	}{c.Currency, json.RawMessage(FormatAmount(c.Difference, c.Currency)), c.Percent})
+}
+
+// Comparison is the summary of an earlier period together with the change
+// from it to the current period
+type Comparison struct {
+	Summary
+	Change []TotalChange `json:"change"`
+	// BaseChange is the change of the total in the base currency
+	BaseChange TotalChange `json:"baseChange"`
+}
+
+// SummaryReport summarizes the expenses of a date range and compares them
+// with the previous period and with the same period a year earlier
+type SummaryReport struct {
+	GroupBy  string     `json:"groupBy" example:"category"`
+	Current  Summary    `json:"current"`
+	Previous Comparison `json:"previous"`
+	LastYear Comparison `json:"lastYear"`
+}
+
+// wholeMonths returns the number of calendar months from start to end when
+// the range covers whole months, 0 otherwise
+func wholeMonths(start, end Date) int {
+	if start.Day() != 1 || end.AddDate(0, 0, 1).Day() != 1 {
+		return 0
+	}
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+}
+
+// PreviousRange returns the range just before start and end. A range of whole
+// calendar months moves back by as many months, so October is compared with
+// September, any other range moves back by its own length.
+func PreviousRange(start, end Date) (Date, Date) {
+	if months := wholeMonths(start, end); months > 0 {
+		return Date{start.AddDate(0, -months, 0)}, Date{start.AddDate(0, 0, -1)}
+	}
+	days := int(end.Sub(start.Time)/(24*time.Hour)) + 1
+	return Date{start.AddDate(0, 0, -days)}, Date{start.AddDate(0, 0, -1)}
+}
+
+// LastYearRange returns the same range a year earlier, whole months staying
+// whole months and February 29 becoming February 28
+func LastYearRange(start, end Date) (Date, Date) {
+	if months := wholeMonths(start, end); months > 0 {
+		first := start.AddDate(-1, 0, 0)
+		return Date{first}, Date{first.AddDate(0, months, -1)}
+	}
+	return yearEarlier(start), yearEarlier(end)
+}
+
+// yearEarlier returns the same day a year earlier, February 28 for February
+// 29 instead of the March 1 that time.AddDate would give
+func yearEarlier(date Date) Date {
+	earlier := date.AddDate(-1, 0, 0)
+	if earlier.Month() != date.Month() {
+		earlier = earlier.AddDate(0, 0, -earlier.Day())
+	}
+	return Date{earlier}
+}
+
+// Summarize builds the summary report of the expenses matching the filter
+// between its StartDate and EndDate, both of which must be set. Amounts are
+// also converted into the base currency at the rate of their date.
+func Summarize(expenses ExpenseRepository, converter *Converter, base string, filter ExpenseFilter, groupBy string) (*SummaryReport, error) {
+	report := &SummaryReport{GroupBy: groupBy}
+	var err error
+	if report.Current, err = summarizeRange(expenses, converter, base, filter, groupBy, filter.StartDate, filter.EndDate); err != nil {
+		return nil, err
+	}
+
+	start, end := PreviousRange(filter.StartDate, filter.EndDate)
+	if report.Previous.Summary, err = summarizeRange(expenses, converter, base, filter, groupBy, start, end); err != nil {
+		return nil, err
+	}
+	report.Previous.Change = totalChanges(report.Current.Totals, report.Previous.Totals)
+	report.Previous.BaseChange = totalChange(base, report.Current.Base.Total, report.Previous.Base.Total)
+
+	start, end = LastYearRange(filter.StartDate, filter.EndDate)
+	if report.LastYear.Summary, err = summarizeRange(expenses, converter, base, filter, groupBy, start, end); err != nil {
+		return nil, err
+	}
+	report.LastYear.Change = totalChanges(report.Current.Totals, report.LastYear.Totals)
+	report.LastYear.BaseChange = totalChange(base, report.Current.Base.Total, report.LastYear.Base.Total)
+	return report, nil
+}
+
+func summarizeRange(expenses ExpenseRepository, converter *Converter, base string, filter ExpenseFilter, groupBy string, start, end Date) (Summary, error) {
+	filter.StartDate, filter.EndDate = start, end
+	summary := Summary{StartDate: start, EndDate: end, Base: CurrencyTotal{Currency: base}, Unconverted: []CurrencyTotal{}}
+	var err error
+	if summary.Totals, err = convertedSummary(expenses, converter, base, filter, GroupByNone); err != nil {
+		return summary, err
+	}
+	for _, t := range summary.Totals {
+		summary.Base.Total += t.BaseTotal
+		summary.Base.Count += t.Count - t.unconvertedCount
+		if t.unconvertedCount > 0 {
+			summary.Unconverted = addCurrencyTotal(summary.Unconverted, t.Currency, t.unconvertedCount, t.Unconverted)
+		}
+	}
+	summary.Groups, err = convertedSummary(expenses, converter, base, filter, groupBy)
+	return summary, err
+}
+
+// convertedSummary aggregates the expenses matching the filter per group and
+// currency, each group total converted into the base currency at the rate of
+// the expenses' dates
+func convertedSummary(expenses ExpenseRepository, converter *Converter, base string, filter ExpenseFilter, groupBy string) ([]GroupTotal, error) {
+	totals, err := expenses.Summarize(filter, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := expenses.DailyTotals(filter, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := convertDailyTotals(converter, base, daily)
+	if err != nil {
+		return nil, err
+	}
+	for i := range totals {
+		c := converted[[2]string{totals[i].Key, totals[i].Currency}]
+		totals[i].BaseCurrency, totals[i].BaseTotal = base, c.base
+		totals[i].Unconverted, totals[i].unconvertedCount = c.unconverted, c.unconvertedCount
+	}
+	return totals, nil
+}
+
+// convertedTotal is the total of a group in one currency converted into the
+// base currency, and the part of it with no known rate
+type convertedTotal struct {
+	base             int64
+	unconverted      int64
+	unconvertedCount int64
+}
+
+// convertDailyTotals converts the daily totals into the base currency at the
+// rate of their day and adds them up per key and currency
+func convertDailyTotals(converter *Converter, base string, daily []DailyTotal) (map[[2]string]convertedTotal, error) {
+	totals := map[[2]string]convertedTotal{}
+	for _, t := range daily {
+		key := [2]string{t.Key, t.Currency}
+		total := totals[key]
+		converted, err := converter.Convert(t.Total, t.Currency, base, t.Date)
+		switch {
+		case errors.Is(err, ErrNoRate):
+			total.unconverted += t.Total
+			total.unconvertedCount += t.Count
+		case err != nil:
+			return nil, err
+		default:
+			total.base += converted.Amount
+		}
+		totals[key] = total
+	}
+	return totals, nil
+}
+
+// totalChanges compares the totals of the current period with an earlier one
+// for every currency spent in either
+func totalChanges(current, earlier []GroupTotal) []TotalChange {
+	totals := map[string][2]int64{}
+	for _, t := range current {
+		v := totals[t.Currency]
+		v[0] = t.Total
+		totals[t.Currency] = v
+	}
+	for _, t := range earlier {
+		v := totals[t.Currency]
+		v[1] = t.Total
+		totals[t.Currency] = v
+	}
+
+	changes := []TotalChange{}
+	for currency, v := range totals {
+		changes = append(changes, totalChange(currency, v[0], v[1]))
+	}
+	slices.SortFunc(changes, func(a, b TotalChange) int { return strings.Compare(a.Currency, b.Currency) })
+	return changes
+}
+
+// totalChange computes the change from an earlier total to the current one
+func totalChange(currency string, current, earlier int64) TotalChange {
+	change := TotalChange{Currency: currency, Difference: current - earlier}
+	if earlier != 0 {
+		percent := math.Round(float64(change.Difference)*1000/float64(earlier)) / 10
+		change.Percent = &percent
+	}
+	return change
+}
+
+// dateKey returns the key of the date grouping a date falls in
+func dateKey(date Date, groupBy string) string {
+	switch groupBy {
+	case GroupByWeek:
+		return date.AddDate(0, 0, -(int(date.Weekday())+6)%7).Format(ISODate)
+	case GroupByMonth:
+		return date.Format("2006-01")
+	case GroupByYear:
+		return date.Format("2006")
+	}
+	return date.Format(ISODate)
+}
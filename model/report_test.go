@@ -0,0 +1,212 @@
+package model
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeBaseCurrency(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		var rates []ExchangeRate
+		for _, r := range []struct{ date, rate string }{{"2026-09-01", "1.10"}, {"2026-10-10", "1.20"}} {
+			rate, err := NewExchangeRate(testDate(r.date), "EUR", "USD", r.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rates = append(rates, rate)
+		}
+		if err := store.Rates.Save(rates); err != nil {
+			t.Fatal(err)
+		}
+		createExpenses(t, store, 1,
+			ExpenseData{Title: "x", Category: "food", Amount: 1000, Currency: "EUR", Date: testDate("2026-10-05"), Tags: []string{"a"}},
+			// converted at the rate of its own date
+			ExpenseData{Title: "x", Category: "food", Amount: 1000, Currency: "EUR", Date: testDate("2026-10-15"), Tags: []string{"a", "b"}},
+			ExpenseData{Title: "x", Category: "rent", Amount: 5000, Date: testDate("2026-10-20"), Tags: []string{"b"}},
+			// no rate is known for pounds
+			ExpenseData{Title: "x", Category: "food", Amount: 700, Currency: "GBP", Date: testDate("2026-10-21"), Tags: []string{"a"}},
+			ExpenseData{Title: "x", Category: "rent", Amount: 2000, Date: testDate("2026-09-10")},
+			ExpenseData{Title: "x", Category: "food", Amount: 1000, Currency: "EUR", Date: testDate("2026-09-15")},
+		)
+
+		filter := ExpenseFilter{UserId: 1, StartDate: testDate("2026-10-01"), EndDate: testDate("2026-10-31")}
+		report, err := Summarize(store.Expenses, NewConverter(store.Rates), "USD", filter, GroupByCategory)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		type row struct {
+			key, currency            string
+			total, base, unconverted int64
+		}
+		rows := func(totals []GroupTotal) []row {
+			got := []row{}
+			for _, g := range totals {
+				if g.BaseCurrency != "USD" {
+					t.Errorf("%s %s: got base currency %q, want USD", g.Key, g.Currency, g.BaseCurrency)
+				}
+				got = append(got, row{g.Key, g.Currency, g.Total, g.BaseTotal, g.Unconverted})
+			}
+			return got
+		}
+		wantTotals := []row{{"", "EUR", 2000, 2300, 0}, {"", "GBP", 700, 0, 700}, {"", "USD", 5000, 5000, 0}}
+		if got := rows(report.Current.Totals); !slices.Equal(got, wantTotals) {
+			t.Errorf("totals: got %v, want %v", got, wantTotals)
+		}
+		wantGroups := []row{{"food", "EUR", 2000, 2300, 0}, {"food", "GBP", 700, 0, 700}, {"rent", "USD", 5000, 5000, 0}}
+		if got := rows(report.Current.Groups); !slices.Equal(got, wantGroups) {
+			t.Errorf("groups: got %v, want %v", got, wantGroups)
+		}
+		if want := (CurrencyTotal{"USD", 3, 7300}); report.Current.Base != want {
+			t.Errorf("base: got %+v, want %+v", report.Current.Base, want)
+		}
+		if want := []CurrencyTotal{{"GBP", 1, 700}}; !slices.Equal(report.Current.Unconverted, want) {
+			t.Errorf("unconverted: got %v, want %v", report.Current.Unconverted, want)
+		}
+
+		if report.Previous.Base.Total != 3100 {
+			t.Errorf("previous base: got %d, want 3100", report.Previous.Base.Total)
+		}
+		if c := report.Previous.BaseChange; c.Currency != "USD" || c.Difference != 4200 || c.Percent == nil || *c.Percent != 135.5 {
+			t.Errorf("previous change: got %+v, want +42.00 USD, 135.5%%", c)
+		}
+		if c := report.LastYear.BaseChange; c.Difference != 7300 || c.Percent != nil {
+			t.Errorf("last year change: got %+v, want +73.00 USD without a percentage", c)
+		}
+
+		data, err := json.Marshal(report.Current.Groups[0])
+		if err != nil || !strings.Contains(string(data), `"baseCurrency":"USD","baseTotal":23.00,"unconverted":0.00`) {
+			t.Errorf("group JSON: got %s, %v", data, err)
+		}
+
+		tags, err := TagTotals(store.Expenses, NewConverter(store.Rates), "USD", filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotTags := []row{}
+		for _, tag := range tags {
+			gotTags = append(gotTags, row{tag.Tag, tag.Currency, tag.Total, tag.BaseTotal, tag.Unconverted})
+		}
+		wantTags := []row{{"a", "EUR", 2000, 2300, 0}, {"a", "GBP", 700, 0, 700}, {"b", "EUR", 1000, 1200, 0}, {"b", "USD", 5000, 5000, 0}}
+		if !slices.Equal(gotTags, wantTags) {
+			t.Errorf("tags: got %v, want %v", gotTags, wantTags)
+		}
+	})
+}
+
+func TestPreviousRange(t *testing.T) {
+	tests := []struct {
+		start, end, wantStart, wantEnd string
+	}{
+		// whole months move back by as many months
+		{"2026-10-01", "2026-10-31", "2026-09-01", "2026-09-30"},
+		{"2026-03-01", "2026-03-31", "2026-02-01", "2026-02-28"},
+		{"2024-03-01", "2024-03-31", "2024-02-01", "2024-02-29"},
+		{"2026-01-01", "2026-03-31", "2025-10-01", "2025-12-31"},
+		{"2026-01-01", "2026-01-31", "2025-12-01", "2025-12-31"},
+		// any other range by its own length
+		{"2026-10-10", "2026-10-16", "2026-10-03", "2026-10-09"},
+		{"2026-01-15", "2026-02-14", "2025-12-15", "2026-01-14"},
+		{"2024-02-29", "2024-02-29", "2024-02-28", "2024-02-28"},
+		{"2024-03-01", "2024-03-30", "2024-01-31", "2024-02-29"},
+	}
+	for _, tt := range tests {
+		start, end := PreviousRange(testDate(tt.start), testDate(tt.end))
+		if start.String() != tt.wantStart || end.String() != tt.wantEnd {
+			t.Errorf("%s to %s: got %s to %s, want %s to %s", tt.start, tt.end, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestLastYearRange(t *testing.T) {
+	tests := []struct {
+		start, end, wantStart, wantEnd string
+	}{
+		{"2026-10-01", "2026-10-31", "2025-10-01", "2025-10-31"},
+		{"2026-10-10", "2026-10-16", "2025-10-10", "2025-10-16"},
+		// February stays a whole month either way
+		{"2024-02-01", "2024-02-29", "2023-02-01", "2023-02-28"},
+		{"2025-02-01", "2025-02-28", "2024-02-01", "2024-02-29"},
+		{"2024-01-01", "2024-02-29", "2023-01-01", "2023-02-28"},
+		// February 29 is compared with February 28, never with March 1
+		{"2024-02-29", "2024-02-29", "2023-02-28", "2023-02-28"},
+		{"2024-02-20", "2024-02-29", "2023-02-20", "2023-02-28"},
+		{"2024-02-29", "2024-03-05", "2023-02-28", "2023-03-05"},
+		{"2025-12-29", "2026-01-04", "2024-12-29", "2025-01-04"},
+	}
+	for _, tt := range tests {
+		start, end := LastYearRange(testDate(tt.start), testDate(tt.end))
+		if start.String() != tt.wantStart || end.String() != tt.wantEnd {
+			t.Errorf("%s to %s: got %s to %s, want %s to %s", tt.start, tt.end, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+// groupingDates are dates around the edges of weeks, months and years
+var groupingDates = []struct {
+	date, week, month, year string
+}{
+	{"2024-02-29", "2024-02-26", "2024-02", "2024"},
+	// the week of February 29 ends in March
+	{"2024-03-03", "2024-02-26", "2024-03", "2024"},
+	{"2024-12-31", "2024-12-30", "2024-12", "2024"},
+	// ISO week 1 of 2025 starts in 2024
+	{"2025-01-01", "2024-12-30", "2025-01", "2025"},
+	{"2025-12-28", "2025-12-22", "2025-12", "2025"},
+	// 2026 starts on a Thursday, its first week on a Monday of 2025
+	{"2026-01-01", "2025-12-29", "2026-01", "2026"},
+	{"2026-01-04", "2025-12-29", "2026-01", "2026"},
+	{"2026-01-05", "2026-01-05", "2026-01", "2026"},
+	{"2027-01-01", "2026-12-28", "2027-01", "2027"},
+}
+
+func TestDateKey(t *testing.T) {
+	for _, tt := range groupingDates {
+		date := testDate(tt.date)
+		for groupBy, want := range map[string]string{GroupByDay: tt.date, GroupByWeek: tt.week, GroupByMonth: tt.month, GroupByYear: tt.year} {
+			if got := dateKey(date, groupBy); got != want {
+				t.Errorf("%s by %s: got %s, want %s", tt.date, groupBy, got, want)
+			}
+		}
+	}
+}
+
+// TestSummarizeDateGroups checks that the SQL expressions of the date
+// groupings agree with dateKey
+func TestSummarizeDateGroups(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		for _, d := range groupingDates {
+			createExpenses(t, store, 1, ExpenseData{Title: d.date, Amount: 100, Date: testDate(d.date)})
+		}
+		for _, groupBy := range []string{GroupByDay, GroupByWeek, GroupByMonth, GroupByYear} {
+			want := map[string]int64{}
+			for _, d := range groupingDates {
+				want[dateKey(testDate(d.date), groupBy)] += 100
+			}
+			groups, err := store.Expenses.Summarize(ExpenseFilter{UserId: 1}, groupBy)
+			if err != nil {
+				t.Fatalf("%s: %v", groupBy, err)
+			}
+			got := map[string]int64{}
+			for _, g := range groups {
+				got[g.Key] += g.Total
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("%s: got %v, want %v", groupBy, got, want)
+			}
+
+			daily, err := store.Expenses.DailyTotals(ExpenseFilter{UserId: 1}, groupBy)
+			if err != nil {
+				t.Fatalf("%s daily: %v", groupBy, err)
+			}
+			for _, d := range daily {
+				if want := dateKey(d.Date, groupBy); d.Key != want {
+					t.Errorf("%s daily %s: got key %s, want %s", groupBy, d.Date, d.Key, want)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,315 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"expense-tracker/config"
+)
+
+// ErrNotFound is returned by repositories when no matching record exists
+var ErrNotFound = errors.New("record not found")
+
+// UserRepository defines the storage operations available for users
+type UserRepository interface {
+	Create(user *UserData) error
+	FindById(id int64) (*UserData, error)
+	FindByEmail(email string) (*UserData, error)
+	Update(user *UserData) error
+	// DueForDeletion lists the users whose DeleteAt has passed at now,
+	// together with those soft-deleted before deletions were made permanent
+	DueForDeletion(now time.Time) ([]UserData, error)
+	// Purge deletes the user and everything they own for good, in a single
+	// transaction. The audit log keeps the entries about the user.
+	Purge(id int64) error
+	// Search returns a page of the users matching the query, sorted by ID
+	Search(query UserQuery) (*UserPage, error)
+	// Stats counts what the user stores
+	Stats(id int64) (*UserStats, error)
+}
+
+// ExpenseRepository defines the storage operations available for expenses.
+// Create and Update also store the expense's tags, creating the tags the
+// user does not have yet.
+type ExpenseRepository interface {
+	Create(expense *ExpenseData) error
+	FindById(id int64) (*ExpenseData, error)
+	List(query ExpenseQuery) (*ExpensePage, error)
+	// TotalsByTag sums the expenses matching the filter per tag and currency
+	TotalsByTag(filter ExpenseFilter) ([]TagTotal, error)
+	// DailyTotals sums the expenses matching the filter per date, group,
+	// one of the GroupBy values, and currency, sorted by date, key and
+	// currency
+	DailyTotals(filter ExpenseFilter, groupBy string) ([]DailyTotal, error)
+	// Summarize aggregates the expenses matching the filter per currency
+	// and group, one of the GroupBy values, sorted by key and currency. With
+	// GroupByTag an expense counts towards each of its tags.
+	Summarize(filter ExpenseFilter, groupBy string) ([]GroupTotal, error)
+	Update(expense *ExpenseData) error
+	Delete(id int64) error
+}
+
+// TagRepository defines the storage operations available for tags
+type TagRepository interface {
+	FindById(id int64) (*Tag, error)
+	FindByName(userId int64, name string) (*Tag, error)
+	// List returns the user's tags sorted by name with their expense count
+	List(userId int64) ([]Tag, error)
+	Update(tag *Tag) error
+	// Merge moves the expenses of source to target and deletes source
+	Merge(source, target *Tag) error
+	// Delete removes the tag from its expenses and deletes it
+	Delete(id int64) error
+}
+
+// CategoryRepository defines the storage operations available for categories
+type CategoryRepository interface {
+	Create(category *Category) error
+	// Seed creates the DefaultCategories for a user who has no categories yet
+	Seed(userId int64) error
+	FindById(id int64) (*Category, error)
+	FindByName(userId int64, name string) (*Category, error)
+	List(userId int64) ([]Category, error)
+	// Update saves the category and copies its name onto the linked expenses
+	Update(category *Category) error
+	// Delete removes the category after moving its expenses to the
+	// replacement and its subcategories up to its own parent
+	Delete(id int64, replacement *Category) error
+}
+
+// RecurringRepository defines the storage operations available for
+// recurring expenses and the overrides of their occurrences
+type RecurringRepository interface {
+	Create(recurring *RecurringExpense) error
+	FindById(id int64) (*RecurringExpense, error)
+	List(userId int64) ([]RecurringExpense, error)
+	Update(recurring *RecurringExpense) error
+	// Delete removes the template, the expenses it generated are kept
+	Delete(id int64) error
+	// Due lists the recurring expenses of active users, neither disabled nor
+	// waiting to be deleted, whose NextDate is on or before the given date and
+	// not past their end date
+	Due(on Date) ([]RecurringExpense, error)
+	// Materialize creates the expenses of occurrences that do not have one
+	// yet and moves NextDate to next, in a single transaction. It returns
+	// the number of expenses created.
+	Materialize(recurring *RecurringExpense, expenses []ExpenseData, next Date) (int, error)
+	// FindOccurrence returns the expense generated for an occurrence
+	FindOccurrence(recurringId uint, occurrence Date) (*ExpenseData, error)
+	Overrides(recurringId uint) ([]RecurringOverride, error)
+	// SaveOverride creates or replaces the override of an occurrence
+	SaveOverride(override *RecurringOverride) error
+	DeleteOverride(recurringId uint, occurrence Date) error
+}
+
+// BudgetRepository defines the storage operations available for budgets
+type BudgetRepository interface {
+	Create(budget *Budget) error
+	FindById(id int64) (*Budget, error)
+	List(userId int64) ([]Budget, error)
+	Update(budget *Budget) error
+	Delete(id int64) error
+}
+
+// TokenRepository stores the refresh tokens and the revocation list of
+// access tokens
+type TokenRepository interface {
+	Create(token *RefreshToken) error
+	// FindByHash returns the refresh token with the given hash, whether it
+	// was used or not
+	FindByHash(hash string) (*RefreshToken, error)
+	// Rotate marks token as used and stores next in a single transaction,
+	// it returns ErrTokenReused when token was already used or revoked
+	Rotate(token, next *RefreshToken) error
+	// RevokeFamily revokes the refresh tokens of a family and the access
+	// tokens issued with them
+	RevokeFamily(family string) error
+	// RevokeUser revokes every refresh token of a user and the access tokens
+	// issued with them
+	RevokeUser(userId int64) error
+	// RevokeAccess adds an access token to the revocation list until it
+	// expires
+	RevokeAccess(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+// EmailTokenRepository stores the tokens sent by email
+type EmailTokenRepository interface {
+	// Create stores the token, the unused tokens of the same user and
+	// purpose stop working
+	Create(token *EmailToken) error
+	// Consume marks the unused and unexpired token with the given hash and
+	// purpose as used and returns it, ErrNotFound is returned otherwise
+	Consume(hash, purpose string) (*EmailToken, error)
+}
+
+// MFARepository stores the two-factor authentication settings, recovery codes
+// and pending login challenges of the users
+type MFARepository interface {
+	// FindSettings returns the settings of a user, ErrNotFound when they
+	// never enrolled
+	FindSettings(userId int64) (*MFASettings, error)
+	SaveSettings(settings *MFASettings) error
+	// DeleteSettings disables two-factor authentication for the user and
+	// deletes their recovery codes
+	DeleteSettings(userId int64) error
+	// UseStep records the time step of an accepted code, ErrNotFound is
+	// returned when a code of that step or a later one was already used
+	UseStep(userId, step int64) error
+
+	// ReplaceRecoveryCodes stores new recovery codes by hash in place of the
+	// previous ones
+	ReplaceRecoveryCodes(userId int64, hashes []string) error
+	// UseRecoveryCode marks the unused code with the given hash as used,
+	// ErrNotFound is returned otherwise
+	UseRecoveryCode(userId int64, hash string) error
+	CountRecoveryCodes(userId int64) (int, error)
+
+	CreateChallenge(challenge *MFAChallenge) error
+	// FindChallenge returns the unused and unexpired challenge with the given
+	// hash, ErrNotFound otherwise
+	FindChallenge(hash string) (*MFAChallenge, error)
+	// FailChallenge counts a wrong code, the challenge is used up after
+	// MFAChallengeAttempts of them
+	FailChallenge(id uint) error
+	// CompleteChallenge marks the challenge as used, ErrNotFound is returned
+	// when it already was
+	CompleteChallenge(id uint) error
+}
+
+// APITokenRepository stores the API tokens of the users
+type APITokenRepository interface {
+	Create(token *APIToken) error
+	// FindByHash returns the token with the given hash, expired or not
+	FindByHash(hash string) (*APIToken, error)
+	// List returns the tokens of a user, newest first
+	List(userId int64) ([]APIToken, error)
+	// Delete revokes a token of the user, ErrNotFound is returned when the
+	// user has no such token
+	Delete(userId int64, id uint) error
+	DeleteByUser(userId int64) error
+	// Touch records when the token was last used
+	Touch(id uint, usedAt time.Time) error
+}
+
+// LoginLimiter tracks the failed logins per key and tells how long a key must
+// wait before its next attempt
+type LoginLimiter interface {
+	// Wait returns how long the key must wait at now before trying again, 0
+	// when it may try
+	Wait(key string, now time.Time) (time.Duration, error)
+	// Fail records a failed login of the key under the policy and returns the
+	// updated throttle and whether this failure locked the key out
+	Fail(key string, policy LoginPolicy, now time.Time) (*LoginThrottle, bool, error)
+	// Reset forgets the failures of the key
+	Reset(key string) error
+}
+
+// AuditRepository stores the audit log of security relevant events
+type AuditRepository interface {
+	Record(entry *AuditEntry) error
+	// List returns a page of the entries matching the query, newest first
+	List(query AuditQuery) (*AuditPage, error)
+}
+
+// ExportRepository stores the data exports and their archives
+type ExportRepository interface {
+	Create(export *DataExport) error
+	FindById(id int64) (*DataExport, error)
+	// List returns the exports of a user, newest first
+	List(userId int64) ([]DataExport, error)
+	// Pending lists the exports waiting to be built, oldest first
+	Pending() ([]DataExport, error)
+	// Complete saves a built export together with its archive, which is
+	// nil when the export failed
+	Complete(export *DataExport, archive []byte) error
+	// Archive returns the archive of a ready export
+	Archive(id uint) ([]byte, error)
+	// DeleteExpired deletes the exports that expired before now with their
+	// archives and returns how many it deleted
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// ImportMappingRepository stores the column mappings the users saved for their
+// CSV statements
+type ImportMappingRepository interface {
+	Create(mapping *ImportMapping) error
+	FindById(id int64) (*ImportMapping, error)
+	// List returns the mappings of a user sorted by name
+	List(userId int64) ([]ImportMapping, error)
+	Update(mapping *ImportMapping) error
+	Delete(id int64) error
+}
+
+// ImportRepository stores the imports with their rows and creates and deletes
+// the expenses of their batch
+type ImportRepository interface {
+	// Create stores the import together with its rows
+	Create(imp *Import, rows []ImportRow) error
+	FindById(id int64) (*Import, error)
+	// List returns the imports of a user, newest first
+	List(userId int64) ([]Import, error)
+	// Rows returns the rows of an import in file order
+	Rows(importId uint) ([]ImportRow, error)
+	// Commit creates the expenses of a previewed import and marks it
+	// committed in a single transaction, ErrImportState is returned when
+	// the import is no longer a preview
+	Commit(imp *Import, expenses []ExpenseData) error
+	// Rollback deletes the expenses of a committed import and marks it
+	// rolled back in a single transaction, ErrImportState is returned when
+	// the import is not committed
+	Rollback(imp *Import) error
+	// Delete removes an import that is not committed with its rows
+	Delete(id int64) error
+	// Imported returns which of the external IDs the user's expenses
+	// already carry
+	Imported(userId int64, externalIds []string) (map[string]bool, error)
+}
+
+// RateRepository stores the exchange rates used to convert amounts
+type RateRepository interface {
+	// Save inserts the rates, replacing any rate already stored for the same
+	// date and currency pair
+	Save(rates []ExchangeRate) error
+	// FindLatest returns the latest rate from base to quote published on or
+	// before the given date
+	FindLatest(base, quote string, on Date) (*ExchangeRate, error)
+	// Bases lists the base currencies rates are published against
+	Bases() ([]string, error)
+}
+
+// Store bundles the repositories used by the API
+type Store struct {
+	Users       UserRepository
+	Expenses    ExpenseRepository
+	Categories  CategoryRepository
+	Tags        TagRepository
+	Recurring   RecurringRepository
+	Budgets     BudgetRepository
+	Tokens      TokenRepository
+	EmailTokens EmailTokenRepository
+	MFA         MFARepository
+	APITokens   APITokenRepository
+	Rates       RateRepository
+	Exports     ExportRepository
+	// ImportMappings and Imports hold the statement imports
+	ImportMappings ImportMappingRepository
+	Imports        ImportRepository
+	Logins         LoginLimiter
+	Audit          AuditRepository
+}
+
+// OpenStore creates the store for the configured driver. The "memory" driver
+// keeps everything in process, any other driver is opened through gorm.
+func OpenStore(driver, url string) (*Store, error) {
+	if driver == config.DriverMemory {
+		return NewMemoryStore(), nil
+	}
+
+	db, err := config.Connect(driver, url)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s database: %w", driver, err)
+	}
+	return NewGormStore(db)
+}
@@ -0,0 +1,547 @@
+package model
+
+import (
+	"errors"
+	"expense-tracker/config"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// forEachStore runs a test against a fresh store of every backend, so that the
+// memory and the SQL repositories keep the same contract
+func forEachStore(t *testing.T, test func(t *testing.T, store *Store)) {
+	for _, driver := range []string{config.DriverMemory, config.DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			// a file rather than ":memory:", which would give every
+			// connection of the pool a database of its own
+			store, err := OpenStore(driver, filepath.Join(t.TempDir(), "expenses.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			test(t, store)
+		})
+	}
+}
+
+// createExpenses stores the expenses of a user and returns them with their IDs
+func createExpenses(t *testing.T, store *Store, userId int64, expenses ...ExpenseData) []ExpenseData {
+	for i := range expenses {
+		expenses[i].UserId = userId
+		if expenses[i].Currency == "" {
+			expenses[i].Currency = "USD"
+		}
+		if err := store.Expenses.Create(&expenses[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return expenses
+}
+
+// expenseIds returns the IDs of the expenses in order
+func expenseIds(expenses []ExpenseData) []uint {
+	ids := []uint{}
+	for _, e := range expenses {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestUserRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		user := &UserData{Email: "jane@example.com", FirstName: "Jane"}
+		if err := store.Users.Create(user); err != nil || user.ID == 0 {
+			t.Fatalf("create: got ID %d, %v", user.ID, err)
+		}
+		user.FirstName = "Janet"
+		if err := store.Users.Update(user); err != nil {
+			t.Fatal(err)
+		}
+		found, err := store.Users.FindByEmail("jane@example.com")
+		if err != nil || found.ID != user.ID || found.FirstName != "Janet" {
+			t.Errorf("find by email: got %+v, %v", found, err)
+		}
+		if _, err := store.Users.FindById(int64(user.ID) + 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown ID: got %v, want ErrNotFound", err)
+		}
+		if _, err := store.Users.FindByEmail("john@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown email: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestExpenseRepository(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		expense := createExpenses(t, store, 1, ExpenseData{Title: "Lunch", Amount: 1250, Date: testDate("2026-10-15"), Tags: []string{" Work", "food", "work"}})[0]
+		if !slices.Equal(expense.Tags, []string{"food", "work"}) {
+			t.Errorf("created tags: got %q, want [food work]", expense.Tags)
+		}
+
+		expense.Tags = []string{"Travel", "food"}
+		expense.Amount = 1300
+		if err := store.Expenses.Update(&expense); err != nil {
+			t.Fatal(err)
+		}
+		found, err := store.Expenses.FindById(int64(expense.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Amount != 1300 || found.Currency != "USD" || found.Date != testDate("2026-10-15") || !slices.Equal(found.Tags, []string{"food", "travel"}) {
+			t.Errorf("updated expense: got %d %s %s %q", found.Amount, found.Currency, found.Date, found.Tags)
+		}
+		// the tags of the user stay until they are deleted
+		tags, err := store.Tags.List(1)
+		if err != nil || len(tags) != 3 {
+			t.Errorf("tags: got %+v, %v, want food, travel and work", tags, err)
+		}
+
+		if err := store.Expenses.Delete(int64(expense.ID)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Expenses.FindById(int64(expense.ID)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted expense: got %v, want ErrNotFound", err)
+		}
+		if err := store.Expenses.Delete(int64(expense.ID)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleting again: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestExpenseFilter(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		recurringId := uint(7)
+		expenses := createExpenses(t, store, 1,
+			ExpenseData{Title: "Groceries", Description: "Weekly shop", Category: "Food", CategoryId: 1, Amount: 4520, Date: testDate("2026-10-01")},
+			ExpenseData{Title: "Train", Category: "Travel", CategoryId: 2, Amount: 1250, Currency: "EUR", Date: testDate("2026-10-15")},
+			ExpenseData{Title: "Rent", Category: "Housing", CategoryId: 3, Amount: 100000, Date: testDate("2026-10-31"), RecurringId: &recurringId},
+			// 1500 yen have no minor units and compare as 1500.0000
+			ExpenseData{Title: "Ramen", Description: "GROCERIES on the way", Category: "Food", CategoryId: 1, Amount: 1500, Currency: "JPY", Date: testDate("2026-11-01")},
+		)
+		// expenses of other users never match
+		createExpenses(t, store, 2, ExpenseData{Title: "Groceries", Category: "Food", Amount: 4520, Date: testDate("2026-10-01")})
+		amount := func(value string) *int64 {
+			scaled, err := ParseScaledAmount(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &scaled
+		}
+
+		tests := []struct {
+			name   string
+			filter ExpenseFilter
+			want   []int
+		}{
+			{"none", ExpenseFilter{}, []int{0, 1, 2, 3}},
+			{"start date", ExpenseFilter{StartDate: testDate("2026-10-15")}, []int{1, 2, 3}},
+			{"end date", ExpenseFilter{EndDate: testDate("2026-10-31")}, []int{0, 1, 2}},
+			{"single day", ExpenseFilter{StartDate: testDate("2026-10-15"), EndDate: testDate("2026-10-15")}, []int{1}},
+			{"category", ExpenseFilter{Category: "Food"}, []int{0, 3}},
+			{"category IDs", ExpenseFilter{CategoryIds: []uint{2, 3}}, []int{1, 2}},
+			{"minimum amount", ExpenseFilter{MinAmount: amount("45.20")}, []int{0, 2, 3}},
+			{"maximum amount", ExpenseFilter{MaxAmount: amount("12.50")}, []int{1}},
+			{"amount range", ExpenseFilter{MinAmount: amount("20"), MaxAmount: amount("1000")}, []int{0, 2}},
+			{"search ignores case", ExpenseFilter{Search: "groceries"}, []int{0, 3}},
+			{"search in description", ExpenseFilter{Search: "weekly"}, []int{0}},
+			{"recurring", ExpenseFilter{RecurringId: recurringId}, []int{2}},
+			{"no match", ExpenseFilter{Category: "Food", StartDate: testDate("2026-10-02"), EndDate: testDate("2026-10-31")}, []int{}},
+		}
+		for _, tt := range tests {
+			tt.filter.UserId = 1
+			page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: tt.filter, Sort: SortById, Limit: 10})
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			want := []uint{}
+			for _, i := range tt.want {
+				want = append(want, expenses[i].ID)
+			}
+			if got := expenseIds(page.Expenses); !slices.Equal(got, want) || page.Total != int64(len(want)) {
+				t.Errorf("%s: got %v of %d, want %v", tt.name, got, page.Total, want)
+			}
+		}
+	})
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		_, token := NewRefreshToken(1, "family")
+		token.AccessId, token.AccessExpiresAt = "first", time.Now().Add(time.Hour).UTC()
+		if err := store.Tokens.Create(token); err != nil {
+			t.Fatal(err)
+		}
+		_, next := NewRefreshToken(1, "family")
+		next.AccessId, next.AccessExpiresAt = "second", time.Now().Add(time.Hour).UTC()
+		if err := store.Tokens.Rotate(token, next); err != nil || token.UsedAt == nil {
+			t.Fatalf("rotate: got %v", err)
+		}
+
+		// a token is rotated once, the stored copy tells it was used
+		stored, err := store.Tokens.FindByHash(token.Hash)
+		if err != nil || stored.UsedAt == nil {
+			t.Fatalf("rotated token: got %+v, %v", stored, err)
+		}
+		_, other := NewRefreshToken(1, "family")
+		if err := store.Tokens.Rotate(stored, other); !errors.Is(err, ErrTokenReused) {
+			t.Errorf("rotating again: got %v, want ErrTokenReused", err)
+		}
+		if _, err := store.Tokens.FindByHash(other.Hash); !errors.Is(err, ErrNotFound) {
+			t.Errorf("token of a refused rotation: got %v, want ErrNotFound", err)
+		}
+
+		// revoking the family revokes the access tokens issued with it
+		if err := store.Tokens.RevokeFamily("family"); err != nil {
+			t.Fatal(err)
+		}
+		for _, jti := range []string{"first", "second"} {
+			if revoked, err := store.Tokens.IsRevoked(jti); err != nil || !revoked {
+				t.Errorf("access token %s: got revoked %t, %v", jti, revoked, err)
+			}
+		}
+		if err := store.Tokens.Rotate(next, other); !errors.Is(err, ErrTokenReused) {
+			t.Errorf("rotating a revoked token: got %v, want ErrTokenReused", err)
+		}
+	})
+}
+
+func TestConsumeEmailToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		user := &UserData{Email: "jane@example.com"}
+		if err := store.Users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+		create := func(purpose string, ttl time.Duration) *EmailToken {
+			_, token := NewEmailToken(user, purpose, ttl)
+			if err := store.EmailTokens.Create(token); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}
+
+		token := create(TokenResetPassword, time.Hour)
+		if _, err := store.EmailTokens.Consume(token.Hash, TokenVerifyEmail); !errors.Is(err, ErrNotFound) {
+			t.Errorf("other purpose: got %v, want ErrNotFound", err)
+		}
+		consumed, err := store.EmailTokens.Consume(token.Hash, TokenResetPassword)
+		if err != nil || consumed.UserId != int64(user.ID) || consumed.UsedAt == nil {
+			t.Fatalf("consume: got %+v, %v", consumed, err)
+		}
+		if _, err := store.EmailTokens.Consume(token.Hash, TokenResetPassword); !errors.Is(err, ErrNotFound) {
+			t.Errorf("consuming again: got %v, want ErrNotFound", err)
+		}
+
+		expired := create(TokenVerifyEmail, -time.Minute)
+		if _, err := store.EmailTokens.Consume(expired.Hash, TokenVerifyEmail); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expired: got %v, want ErrNotFound", err)
+		}
+		// a new token replaces the unused ones of the same purpose
+		replaced := create(TokenVerifyEmail, time.Hour)
+		latest := create(TokenVerifyEmail, time.Hour)
+		if _, err := store.EmailTokens.Consume(replaced.Hash, TokenVerifyEmail); !errors.Is(err, ErrNotFound) {
+			t.Errorf("replaced: got %v, want ErrNotFound", err)
+		}
+		if _, err := store.EmailTokens.Consume(latest.Hash, TokenVerifyEmail); err != nil {
+			t.Errorf("latest: got %v", err)
+		}
+	})
+}
+
+func TestImportRollback(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		imp := &Import{UserId: 1, Format: "ofx", Category: "Others", Status: ImportPreview}
+		rows := []ImportRow{
+			{Line: 1, Date: testDate("2026-10-01"), Title: "Coffee", Amount: 350, Currency: "USD", ExternalId: "acct:1", Status: RowValid},
+			{Line: 2, Date: testDate("2026-10-02"), Title: "Books", Amount: 2599, Currency: "USD", ExternalId: "acct:2", Status: RowValid},
+		}
+		if err := store.Imports.Create(imp, rows); err != nil {
+			t.Fatal(err)
+		}
+		// the user's own expense is left alone by the rollback
+		own := createExpenses(t, store, 1, ExpenseData{Title: "Lunch", Amount: 1250, Date: testDate("2026-10-01")})[0]
+
+		stored, err := store.Imports.Rows(imp.ID)
+		if err != nil || len(stored) != 2 || stored[1].Title != "Books" || stored[1].Amount != 2599 {
+			t.Fatalf("rows: got %+v, %v", stored, err)
+		}
+		var expenses []ExpenseData
+		for _, row := range stored {
+			expenses = append(expenses, row.Expense(imp))
+		}
+		if err := store.Imports.Commit(imp, expenses); err != nil || imp.Status != ImportCommitted {
+			t.Fatalf("commit: got %s, %v", imp.Status, err)
+		}
+		if err := store.Imports.Commit(imp, expenses); !errors.Is(err, ErrImportState) {
+			t.Errorf("committing again: got %v, want ErrImportState", err)
+		}
+		if err := store.Imports.Delete(int64(imp.ID)); !errors.Is(err, ErrImportState) {
+			t.Errorf("deleting a committed import: got %v, want ErrImportState", err)
+		}
+		imported, err := store.Imports.Imported(1, []string{"acct:1", "acct:2", "acct:3"})
+		if err != nil || len(imported) != 2 || !imported["acct:1"] || !imported["acct:2"] {
+			t.Errorf("imported: got %v, %v, want acct:1 and acct:2", imported, err)
+		}
+		if imported, _ := store.Imports.Imported(2, []string{"acct:1"}); len(imported) != 0 {
+			t.Errorf("imported by another user: got %v", imported)
+		}
+
+		if err := store.Imports.Rollback(imp); err != nil || imp.Status != ImportRolledBack {
+			t.Fatalf("rollback: got %s, %v", imp.Status, err)
+		}
+		page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: 1}, Sort: SortById, Limit: 10})
+		if err != nil || !slices.Equal(expenseIds(page.Expenses), []uint{own.ID}) {
+			t.Errorf("expenses after the rollback: got %v, %v, want only %d", expenseIds(page.Expenses), err, own.ID)
+		}
+		// the transactions can be imported again
+		if imported, _ := store.Imports.Imported(1, []string{"acct:1", "acct:2"}); len(imported) != 0 {
+			t.Errorf("imported after the rollback: got %v", imported)
+		}
+		if err := store.Imports.Rollback(imp); !errors.Is(err, ErrImportState) {
+			t.Errorf("rolling back again: got %v, want ErrImportState", err)
+		}
+		found, err := store.Imports.FindById(int64(imp.ID))
+		if err != nil || found.Status != ImportRolledBack || found.Created != 2 || found.RolledBackAt == nil {
+			t.Errorf("stored import: got %+v, %v", found, err)
+		}
+	})
+}
+
+// account is a user together with a row of every kind of data a user stores
+type account struct {
+	user                                  *UserData
+	recurringId, exportId, importId       uint
+	refreshHash, emailHash, challengeHash string
+}
+
+// createAccount stores a user deleted at deleteAt together with a row of
+// every kind of data a user stores
+func createAccount(t *testing.T, store *Store, email string, deleteAt time.Time) *account {
+	user := &UserData{Email: email, DeleteAt: &deleteAt}
+	if err := store.Users.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	userId := int64(user.ID)
+	a := &account{user: user}
+
+	category := &Category{UserId: userId, Name: "Travel"}
+	if err := store.Categories.Create(category); err != nil {
+		t.Fatal(err)
+	}
+	expenses := createExpenses(t, store, userId,
+		ExpenseData{Title: "flight", Amount: 40000, Date: testDate("2026-10-01"), Category: "Travel", Tags: []string{"trip"}},
+		ExpenseData{Title: "hotel", Amount: 30000, Date: testDate("2026-10-02"), Tags: []string{"trip"}},
+	)
+	// soft-deleted expenses go as well
+	if err := store.Expenses.Delete(int64(expenses[1].ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Budgets.Create(&Budget{UserId: userId, Name: "Trips", CategoryId: &category.ID, Amount: 100000,
+		Currency: "USD", Period: "monthly", StartDate: testDate("2026-10-01")}); err != nil {
+		t.Fatal(err)
+	}
+
+	recurring := &RecurringExpense{UserId: userId, Title: "rent", Amount: 90000, Currency: "USD", Rule: "FREQ=MONTHLY",
+		StartDate: testDate("2026-10-01"), NextDate: testDate("2026-11-01")}
+	if err := store.Recurring.Create(recurring); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Recurring.SaveOverride(&RecurringOverride{RecurringId: recurring.ID, Occurrence: testDate("2026-11-01"), Skip: true}); err != nil {
+		t.Fatal(err)
+	}
+	a.recurringId = recurring.ID
+
+	export := &DataExport{UserId: userId, Status: ExportPending}
+	if err := store.Exports.Create(export); err != nil {
+		t.Fatal(err)
+	}
+	export.Status = ExportReady
+	if err := store.Exports.Complete(export, []byte("archive")); err != nil {
+		t.Fatal(err)
+	}
+	a.exportId = export.ID
+
+	if err := store.ImportMappings.Create(&ImportMapping{UserId: userId, Name: "Checking", DateColumn: "Date", AmountColumn: "Amount"}); err != nil {
+		t.Fatal(err)
+	}
+	imp := &Import{UserId: userId, Format: "csv", Category: "Others", Status: ImportPreview}
+	rows := []ImportRow{{Line: 1, Date: testDate("2026-10-01"), Title: "Coffee", Amount: 350, Currency: "USD", Status: RowValid}}
+	if err := store.Imports.Create(imp, rows); err != nil {
+		t.Fatal(err)
+	}
+	a.importId = imp.ID
+
+	_, refresh := NewRefreshToken(userId, NewTokenId())
+	if err := store.Tokens.Create(refresh); err != nil {
+		t.Fatal(err)
+	}
+	a.refreshHash = refresh.Hash
+	_, emailToken := NewEmailToken(user, TokenVerifyEmail, time.Hour)
+	if err := store.EmailTokens.Create(emailToken); err != nil {
+		t.Fatal(err)
+	}
+	a.emailHash = emailToken.Hash
+	_, apiToken := NewAPIToken(userId, "script", []string{ScopeExpensesRead}, time.Hour)
+	if err := store.APITokens.Create(apiToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.MFA.SaveSettings(&MFASettings{UserId: userId, Secret: NewTOTPSecret()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MFA.ReplaceRecoveryCodes(userId, []string{HashToken("recovery code")}); err != nil {
+		t.Fatal(err)
+	}
+	_, challenge := NewMFAChallenge(userId)
+	if err := store.MFA.CreateChallenge(challenge); err != nil {
+		t.Fatal(err)
+	}
+	a.challengeHash = challenge.Hash
+
+	if _, _, err := store.Logins.Fail(AccountLoginKey(email), AccountLoginPolicy, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// accountRows counts the rows of each kind the account still has. Looking the
+// email token up uses it, so each account can only be counted once.
+func accountRows(t *testing.T, store *Store, a *account) map[string]int {
+	userId := int64(a.user.ID)
+	count := func(n int, err error) int {
+		if errors.Is(err, ErrNotFound) {
+			return 0
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	one := func(err error) int {
+		return count(1, err)
+	}
+
+	rows := map[string]int{}
+	_, err := store.Users.FindById(userId)
+	rows["user"] = one(err)
+	page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: ExpenseFilter{UserId: userId}, Limit: 10})
+	rows["expenses"] = count(len(page.Expenses), err)
+	categories, err := store.Categories.List(userId)
+	rows["categories"] = count(len(categories), err)
+	tags, err := store.Tags.List(userId)
+	rows["tags"] = count(len(tags), err)
+	budgets, err := store.Budgets.List(userId)
+	rows["budgets"] = count(len(budgets), err)
+	recurring, err := store.Recurring.List(userId)
+	rows["recurring"] = count(len(recurring), err)
+	overrides, err := store.Recurring.Overrides(a.recurringId)
+	rows["overrides"] = count(len(overrides), err)
+	exports, err := store.Exports.List(userId)
+	rows["exports"] = count(len(exports), err)
+	archive, err := store.Exports.Archive(a.exportId)
+	rows["archives"] = count(len(archive)/len("archive"), err)
+	mappings, err := store.ImportMappings.List(userId)
+	rows["import mappings"] = count(len(mappings), err)
+	imports, err := store.Imports.List(userId)
+	rows["imports"] = count(len(imports), err)
+	importRows, err := store.Imports.Rows(a.importId)
+	rows["import rows"] = count(len(importRows), err)
+	_, err = store.Tokens.FindByHash(a.refreshHash)
+	rows["refresh tokens"] = one(err)
+	_, err = store.EmailTokens.Consume(a.emailHash, TokenVerifyEmail)
+	rows["email tokens"] = one(err)
+	apiTokens, err := store.APITokens.List(userId)
+	rows["API tokens"] = count(len(apiTokens), err)
+	_, err = store.MFA.FindSettings(userId)
+	rows["MFA settings"] = one(err)
+	rows["recovery codes"] = count(store.MFA.CountRecoveryCodes(userId))
+	_, err = store.MFA.FindChallenge(a.challengeHash)
+	rows["MFA challenges"] = one(err)
+	return rows
+}
+
+func TestPurgeUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		now := time.Now().UTC()
+		gone := createAccount(t, store, "jane@example.com", now.Add(-time.Minute))
+		// still within the grace period
+		kept := createAccount(t, store, "john@example.com", now.Add(time.Hour))
+
+		due, err := store.Users.DueForDeletion(now)
+		if err != nil || len(due) != 1 || due[0].ID != gone.user.ID {
+			t.Fatalf("due for deletion: got %+v, %v, want only user %d", due, err, gone.user.ID)
+		}
+		if err := store.Users.Purge(int64(gone.user.ID)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Users.Purge(int64(gone.user.ID)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("purging again: got %v, want ErrNotFound", err)
+		}
+
+		for kind, n := range accountRows(t, store, gone) {
+			if n != 0 {
+				t.Errorf("purged user: %d %s left", n, kind)
+			}
+		}
+		want := map[string]int{"user": 1, "expenses": 1, "categories": 1, "tags": 1, "budgets": 1, "recurring": 1,
+			"overrides": 1, "exports": 1, "archives": 1, "import mappings": 1, "imports": 1, "import rows": 1,
+			"refresh tokens": 1, "email tokens": 1, "API tokens": 1, "MFA settings": 1, "recovery codes": 1, "MFA challenges": 1}
+		if got := accountRows(t, store, kept); !maps.Equal(got, want) {
+			t.Errorf("kept user: got %v, want %v", got, want)
+		}
+		if due, err := store.Users.DueForDeletion(now); err != nil || len(due) != 0 {
+			t.Errorf("due after the purge: got %+v, %v", due, err)
+		}
+	})
+}
+
+// TestPurgeUserRows checks in the SQL tables themselves that a purge leaves
+// no row of the user behind, soft-deleted rows and rows joining the user's
+// rows included
+func TestPurgeUserRows(t *testing.T) {
+	db, err := config.Connect(config.DriverSQLite, filepath.Join(t.TempDir(), "expenses.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := NewGormStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableRows := func() map[string]int {
+		var tables []string
+		if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Pluck("name", &tables).Error; err != nil {
+			t.Fatal(err)
+		}
+		rows := map[string]int{}
+		for _, table := range tables {
+			var n int
+			if err := db.Table(table).Count(&n).Error; err != nil {
+				t.Fatal(err)
+			}
+			rows[table] = n
+		}
+		return rows
+	}
+
+	now := time.Now().UTC()
+	createAccount(t, store, "john@example.com", now.Add(time.Hour))
+	before := tableRows()
+	gone := createAccount(t, store, "jane@example.com", now.Add(-time.Minute))
+	// the tables a user has no rows in
+	shared := map[string]bool{"exchange_rates": true, "audit_entries": true, "revoked_tokens": true}
+	for table, n := range tableRows() {
+		if !shared[table] && n == before[table] {
+			t.Errorf("%s: the account has no rows in it, the test misses a kind of data", table)
+		}
+	}
+
+	if err := store.Users.Purge(int64(gone.user.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if after := tableRows(); !maps.Equal(after, before) {
+		t.Errorf("rows per table after the purge: got %v, want %v", after, before)
+	}
+}
@@ -0,0 +1,395 @@
+package model
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxStatementExpenses is the number of expenses a statement lists at most,
+// its totals always cover every expense
+const MaxStatementExpenses = 2000
+
+// Statement is the printable account of a user's spending over a date range:
+// the totals by category compared with earlier periods, the status of the
+// budgets on its last day and the list of its expenses
+type Statement struct {
+	User      UserData
+	StartDate Date
+	EndDate   Date
+	// Report summarizes the range by category
+	Report   *SummaryReport
+	Budgets  []BudgetStatus
+	Expenses []ExpenseData
+	// Unlisted is the number of expenses left out of Expenses beyond
+	// MaxStatementExpenses
+	Unlisted    int64
+	GeneratedAt time.Time
+}
+
+// statementTable is a table of a statement with its cells already written
+type statementTable struct {
+	Title   string
+	Columns []statementColumn
+	Rows    []statementRow
+	// Empty replaces the table when it has no rows, Note follows it
+	Empty string
+	Note  string
+}
+
+type statementColumn struct {
+	Name string
+	// Number aligns the column to the right
+	Number bool
+	// Weight is the share of the width the column gets on paper, in
+	// relation to the other columns
+	Weight float64
+}
+
+type statementRow struct {
+	Cells []string
+	// Alert marks the row, such as an overspent budget
+	Alert bool
+}
+
+// statementView is what the templates and the PDF layout render
+type statementView struct {
+	Title     string
+	Owner     string
+	Period    string
+	Generated string
+	Tables    []statementTable
+}
+
+//go:embed templates/statement.html
+var statementTemplateText string
+
+var statementTemplate = template.Must(template.New("statement").Parse(statementTemplateText))
+
+// RenderHTML writes the statement as an HTML page, laid out for printing
+func (s *Statement) RenderHTML(w io.Writer) error {
+	return statementTemplate.Execute(w, s.view())
+}
+
+// RenderPDF writes the statement as an A4 PDF document
+func (s *Statement) RenderPDF(w io.Writer) error {
+	view := s.view()
+	doc := &pdfDocument{title: view.Title + ", " + view.Period}
+	layout := &statementLayout{doc: doc}
+	layout.newPage()
+	doc.text(statementMargin, layout.y+16, true, 18, view.Title)
+	layout.y += 28
+	for _, line := range []string{view.Owner, view.Period, view.Generated} {
+		doc.text(statementMargin, layout.y+10, false, 10, line)
+		layout.y += 14
+	}
+	for _, table := range view.Tables {
+		layout.table(table)
+	}
+
+	// the page numbers are known once every page is laid out
+	for i, page := range doc.pages {
+		doc.page = page
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(doc.pages))
+		doc.text(pdfPageWidth-statementMargin-pdfTextWidth(footer, false, 8), pdfPageHeight-24, false, 8, footer)
+		doc.text(statementMargin, pdfPageHeight-24, false, 8, view.Title+", "+view.Period)
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// Layout of the PDF document, in points
+const (
+	statementMargin    = 40
+	statementRowHeight = 14
+	statementFontSize  = 8.5
+	statementCellPad   = 4
+)
+
+// statementLayout places the tables of a statement one below the other,
+// starting new pages as they fill up
+type statementLayout struct {
+	doc *pdfDocument
+	y   float64
+}
+
+func (l *statementLayout) newPage() {
+	l.doc.addPage()
+	l.y = statementMargin
+}
+
+// room starts a new page unless height fits on the current one
+func (l *statementLayout) room(height float64) {
+	if l.y+height > pdfPageHeight-statementMargin-16 {
+		l.newPage()
+	}
+}
+
+func (l *statementLayout) table(t statementTable) {
+	doc := l.doc
+	// a title is not left alone at the bottom of a page
+	l.y += 16
+	l.room(20 + 2*statementRowHeight)
+	doc.text(statementMargin, l.y+12, true, 12, t.Title)
+	l.y += 20
+	if len(t.Rows) == 0 {
+		doc.text(statementMargin, l.y+10, false, 9, t.Empty)
+		l.y += 14
+		return
+	}
+
+	totalWeight := 0.0
+	for _, c := range t.Columns {
+		totalWeight += c.Weight
+	}
+	widths := make([]float64, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = (pdfPageWidth - 2*statementMargin) * c.Weight / totalWeight
+	}
+	row := func(cells []string, bold bool, shade float64) {
+		if shade < 1 {
+			doc.fillRect(statementMargin, l.y, pdfPageWidth-2*statementMargin, statementRowHeight, shade)
+		}
+		x := float64(statementMargin)
+		for i, cell := range cells {
+			cell = fitText(cell, bold, statementFontSize, widths[i]-2*statementCellPad)
+			left := x + statementCellPad
+			if t.Columns[i].Number {
+				left = x + widths[i] - statementCellPad - pdfTextWidth(cell, bold, statementFontSize)
+			}
+			doc.text(left, l.y+10, bold, statementFontSize, cell)
+			x += widths[i]
+		}
+		l.y += statementRowHeight
+		doc.line(statementMargin, l.y, pdfPageWidth-statementMargin, l.y, 0.3)
+	}
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Name
+	}
+
+	row(header, true, 0.9)
+	for _, r := range t.Rows {
+		if l.y+statementRowHeight > pdfPageHeight-statementMargin-16 {
+			// the header is repeated at the top of every page
+			l.newPage()
+			row(header, true, 0.9)
+		}
+		shade := 1.0
+		if r.Alert {
+			shade = 0.8
+		}
+		row(r.Cells, r.Alert, shade)
+	}
+	if t.Note != "" {
+		l.room(14)
+		doc.text(statementMargin, l.y+11, false, 8, t.Note)
+		l.y += 14
+	}
+}
+
+// fitText shortens text with an ellipsis until it fits in width
+func fitText(text string, bold bool, size, width float64) string {
+	if pdfTextWidth(text, bold, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"…", bold, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// view writes out the cells of the statement
+func (s *Statement) view() statementView {
+	layout, ok := DateFormats[s.User.DateFormat]
+	if !ok {
+		layout = ISODate
+	}
+	date := func(d Date) string { return d.Format(layout) }
+	period := func(start, end Date) string { return date(start) + " – " + date(end) }
+	location, err := time.LoadLocation(s.User.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	view := statementView{
+		Title:     "Expense statement",
+		Owner:     strings.TrimSpace(s.User.FirstName+" "+s.User.LastName) + " <" + s.User.Email + ">",
+		Period:    period(s.StartDate, s.EndDate),
+		Generated: "Generated on " + s.GeneratedAt.In(location).Format("2 January 2006, 15:04 MST"),
+	}
+	report := s.Report
+
+	summary := statementTable{
+		Title: "Summary",
+		Columns: []statementColumn{
+			{"Currency", false, 1}, {"Expenses", true, 1}, {"Total", true, 1.6},
+			{"Previous period", true, 1.6}, {"Change", true, 2}, {"Last year", true, 1.6}, {"Change", true, 2},
+		},
+		Empty: "No expenses in this period.",
+		Note: fmt.Sprintf("The previous period is %s and last year %s.",
+			period(report.Previous.StartDate, report.Previous.EndDate), period(report.LastYear.StartDate, report.LastYear.EndDate)),
+	}
+	for _, currency := range statementCurrencies(report) {
+		current := findTotal(report.Current.Totals, "", currency)
+		summary.Rows = append(summary.Rows, statementRow{Cells: []string{
+			currency, fmt.Sprint(current.Count), displayAmount(current.Total, currency),
+			displayAmount(findTotal(report.Previous.Totals, "", currency).Total, currency),
+			displayChange(report.Previous.Change, currency),
+			displayAmount(findTotal(report.LastYear.Totals, "", currency).Total, currency),
+			displayChange(report.LastYear.Change, currency),
+		}})
+	}
+	// the currencies add up in the base currency
+	if base := report.Current.Base.Currency; base != "" && (len(summary.Rows) > 1 || len(summary.Rows) == 1 && summary.Rows[0].Cells[0] != base) {
+		summary.Rows = append(summary.Rows, statementRow{Cells: []string{
+			"All in " + base, fmt.Sprint(report.Current.Base.Count), displayAmount(report.Current.Base.Total, base),
+			displayAmount(report.Previous.Base.Total, base), displayChange([]TotalChange{report.Previous.BaseChange}, base),
+			displayAmount(report.LastYear.Base.Total, base), displayChange([]TotalChange{report.LastYear.BaseChange}, base),
+		}})
+		if len(report.Current.Unconverted)+len(report.Previous.Unconverted)+len(report.LastYear.Unconverted) > 0 {
+			summary.Note += " Spending in currencies without an exchange rate into " + base + " is left out of its total."
+		}
+	}
+
+	categories := statementTable{
+		Title: "Spending by category",
+		Columns: []statementColumn{
+			{"Category", false, 3}, {"Currency", false, 1}, {"Expenses", true, 1},
+			{"Total", true, 1.6}, {"Share", true, 1}, {"Previous period", true, 1.6},
+		},
+		Empty: "No expenses in this period.",
+	}
+	groups := slices.Clone(report.Current.Groups)
+	slices.SortFunc(groups, func(a, b GroupTotal) int {
+		return cmp.Or(strings.Compare(a.Currency, b.Currency), cmp.Compare(b.Total, a.Total), strings.Compare(a.Key, b.Key))
+	})
+	for _, g := range groups {
+		share := ""
+		if total := findTotal(report.Current.Totals, "", g.Currency).Total; total != 0 {
+			share = fmt.Sprintf("%.1f%%", math.Round(float64(g.Total)*1000/float64(total))/10)
+		}
+		categories.Rows = append(categories.Rows, statementRow{Cells: []string{
+			cmp.Or(g.Key, "Uncategorized"), g.Currency, fmt.Sprint(g.Count), displayAmount(g.Total, g.Currency), share,
+			displayAmount(findTotal(report.Previous.Groups, g.Key, g.Currency).Total, g.Currency),
+		}})
+	}
+
+	budgets := statementTable{
+		Title: "Budgets",
+		Columns: []statementColumn{
+			{"Budget", false, 2.4}, {"Period", false, 2.4}, {"Budgeted", true, 1.6},
+			{"Spent", true, 1.6}, {"Remaining", true, 1.6}, {"Used", true, 1},
+		},
+		Empty: "No budget is active on " + date(s.EndDate) + ".",
+		Note:  "Budgets are shown for their period containing " + date(s.EndDate) + ".",
+	}
+	unconverted := false
+	for _, b := range s.Budgets {
+		budgets.Rows = append(budgets.Rows, statementRow{
+			Cells: []string{
+				b.Name, period(b.StartDate, b.EndDate), displayAmount(b.Budgeted, b.Currency),
+				displayAmount(b.Spent, b.Currency), displayAmount(b.Remaining, b.Currency), fmt.Sprintf("%.1f%%", b.Percent),
+			},
+			Alert: b.Remaining < 0,
+		})
+		unconverted = unconverted || len(b.Unconverted) > 0
+	}
+	if unconverted {
+		budgets.Note += " Spending in currencies without an exchange rate into the budget currency is left out."
+	}
+
+	expenses := statementTable{
+		Title: "Expenses",
+		Columns: []statementColumn{
+			{"Date", false, 1.3}, {"Title", false, 3.4}, {"Category", false, 1.8}, {"Tags", false, 1.8}, {"Amount", true, 1.7},
+		},
+		Empty: "No expenses in this period.",
+	}
+	for _, e := range s.Expenses {
+		expenses.Rows = append(expenses.Rows, statementRow{Cells: []string{
+			date(e.Date), strings.Join(strings.Fields(e.Title), " "), e.Category, strings.Join(e.Tags, ", "),
+			displayAmount(e.Amount, e.Currency) + " " + e.Currency,
+		}})
+	}
+	if s.Unlisted > 0 {
+		expenses.Note = fmt.Sprintf("%d more expenses are not listed, they are included in the totals.", s.Unlisted)
+	}
+
+	view.Tables = []statementTable{summary, categories, budgets, expenses}
+	return view
+}
+
+// statementCurrencies lists the currencies spent in the report's periods
+func statementCurrencies(report *SummaryReport) []string {
+	var currencies []string
+	for _, totals := range [][]GroupTotal{report.Current.Totals, report.Previous.Totals, report.LastYear.Totals} {
+		for _, t := range totals {
+			if !slices.Contains(currencies, t.Currency) {
+				currencies = append(currencies, t.Currency)
+			}
+		}
+	}
+	slices.Sort(currencies)
+	return currencies
+}
+
+// findTotal returns the total of the key in the currency, a zero total when
+// there is none
+func findTotal(totals []GroupTotal, key, currency string) GroupTotal {
+	for _, t := range totals {
+		if t.Key == key && t.Currency == currency {
+			return t
+		}
+	}
+	return GroupTotal{Key: key, Currency: currency}
+}
+
+// displayChange writes the change of the total in the currency with its sign
+// and percentage
+func displayChange(changes []TotalChange, currency string) string {
+	for _, c := range changes {
+		if c.Currency != currency {
+			continue
+		}
+		text := displayAmount(c.Difference, currency)
+		if c.Difference > 0 {
+			text = "+" + text
+		}
+		if c.Percent != nil {
+			text += fmt.Sprintf(" (%+.1f%%)", *c.Percent)
+		}
+		return text
+	}
+	return ""
+}
+
+// displayAmount writes an amount for people to read, with its thousands
+// separated, e.g. 123456 USD as 1,234.56
+func displayAmount(minor int64, currency string) string {
+	value := FormatAmount(minor, currency)
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign, value = "-", value[1:]
+	}
+	whole, fraction, found := strings.Cut(value, ".")
+	var b bytes.Buffer
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if found {
+		b.WriteString("." + fraction)
+	}
+	return sign + b.String()
+}
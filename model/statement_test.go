@@ -0,0 +1,97 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStatement returns a statement of October 2026 listing n expenses
+func testStatement(user UserData, title string, n int) *Statement {
+	s := &Statement{
+		User:      user,
+		StartDate: testDate("2026-10-01"),
+		EndDate:   testDate("2026-10-31"),
+		Report: &SummaryReport{
+			GroupBy: GroupByCategory,
+			Current: Summary{
+				Totals: []GroupTotal{{Currency: "USD", Count: int64(n), Total: int64(n) * 1250}},
+				Groups: []GroupTotal{{Key: title, Currency: "USD", Count: int64(n), Total: int64(n) * 1250}},
+			},
+		},
+		Budgets: []BudgetStatus{{Name: title, StartDate: testDate("2026-10-01"), EndDate: testDate("2026-10-31"),
+			Currency: "USD", Budgeted: 2000, Spent: int64(n) * 1250, Remaining: 2000 - int64(n)*1250}},
+		GeneratedAt: time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC),
+	}
+	for i := 0; i < n; i++ {
+		s.Expenses = append(s.Expenses, ExpenseData{Date: NewDate(testDate("2026-10-01").AddDate(0, 0, i%31)), Title: title,
+			Category: title, Tags: []string{title}, Amount: 1250, Currency: "USD"})
+	}
+	return s
+}
+
+func TestStatementHTML(t *testing.T) {
+	title := `<script>alert("x")</script> & <b>'bold'</b>`
+	user := UserData{FirstName: "<img src=x onerror=alert(1)>", Email: "jane@example.com"}
+	var out bytes.Buffer
+	if err := testStatement(user, title, 3).RenderHTML(&out); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+	for _, raw := range []string{"<script>", "<img", "<b>"} {
+		if strings.Contains(html, raw) {
+			t.Errorf("the page holds %q unescaped", raw)
+		}
+	}
+	escaped := "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &lt;b&gt;&#39;bold&#39;&lt;/b&gt;"
+	// the title of the expenses, their category, tags and the budget
+	if n := strings.Count(html, escaped); n != 3*3+2 {
+		t.Errorf("got the escaped title %d times, want 11", n)
+	}
+	if !strings.Contains(html, "<p>&lt;img src=x onerror=alert(1)&gt; &lt;jane@example.com&gt;</p>") {
+		t.Error("the owner is not escaped")
+	}
+	// the overspent budget is marked
+	if !strings.Contains(html, `<tr class="alert">`) {
+		t.Error("the overspent budget is not marked")
+	}
+}
+
+func TestStatementPDF(t *testing.T) {
+	user := UserData{FirstName: "Zoë", LastName: "(Admin)", Email: "zoe@example.com", DateFormat: "DD/MM/YYYY"}
+	for _, n := range []int{0, 3, 150} {
+		t.Run(fmt.Sprint(n, " expenses"), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := testStatement(user, "Groceries (weekly)", n).RenderPDF(&out); err != nil {
+				t.Fatal(err)
+			}
+			file := readPDF(t, out.Bytes())
+
+			// the expenses fill more pages, whose tables start with the header
+			pages := len(file.pages)
+			if n < 150 && pages != 1 || n == 150 && pages < 3 {
+				t.Fatalf("got %d pages", pages)
+			}
+			for i, page := range file.pages {
+				footer := fmt.Sprintf("(Page %d of %d) Tj", i+1, pages)
+				if !strings.Contains(page, footer) {
+					t.Errorf("page %d has no footer %q", i+1, footer)
+				}
+				if i > 0 && !strings.Contains(page, "(Date) Tj") {
+					t.Errorf("page %d does not repeat the header of the expenses", i+1)
+				}
+			}
+			if !strings.Contains(file.pages[0], "(Zo\xeb \\(Admin\\) <zoe@example.com>) Tj") {
+				t.Errorf("page 1 does not name the owner: %q", file.pages[0])
+			}
+			if got := strings.Count(strings.Join(file.pages, ""), "(Groceries \\(weekly\\)) Tj"); got < n {
+				t.Errorf("got the title of the expenses %d times, want at least %d", got, n)
+			}
+			if !strings.Contains(file.info, "/Title (Expense statement, 01/10/2026 \x96 31/10/2026)") {
+				t.Errorf("info: got %q", file.info)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jinzhu/gorm"
+)
+
+// maxTagLength is the longest tag name accepted, in characters
+const maxTagLength = 64
+
+// ErrInvalidTag is returned for tag names that are empty, too long or
+// contain a comma, which separates tags in the listing filter
+var ErrInvalidTag = errors.New("tags must be 1 to 64 characters long and cannot contain commas")
+
+// Tag is a free-form label owned by a user, an expense can carry any number
+// of tags and a tag any number of expenses
+type Tag struct {
+	gorm.Model
+	UserId int64  `json:"userId" gorm:"index"`
+	Name   string `json:"name"`
+	// Expenses is the number of expenses carrying the tag, it is filled in
+	// when tags are listed and never stored
+	Expenses int64 `json:"expenses" gorm:"-"`
+}
+
+// ExpenseTag links an expense to one of its tags
+type ExpenseTag struct {
+	ExpenseId uint `gorm:"primary_key;auto_increment:false"`
+	TagId     uint `gorm:"primary_key;auto_increment:false;index"`
+}
+
+// TagTotal is the number and total amount of a user's expenses carrying a
+// tag, for one currency
+type TagTotal struct {
+	Tag      string `json:"tag" example:"trip-lagos"`
+	Currency string `json:"currency" example:"USD"`
+	Count    int64  `json:"count"`
+	// Total is in minor units of Currency, written as a decimal number
+	Total int64 `json:"total" swaggertype:"number" example:"125.50"`
+	// BaseTotal is Total converted into BaseCurrency at the rate of each
+	// expense's date, in minor units of BaseCurrency
+	BaseCurrency string `json:"baseCurrency" example:"EUR"`
+	BaseTotal    int64  `json:"baseTotal" swaggertype:"number" example:"115.46"`
+	// Unconverted is the part of Total with no known rate into BaseCurrency,
+	// it is left out of BaseTotal
+	Unconverted int64 `json:"unconverted" swaggertype:"number" example:"0.00"`
+}
+
+// MarshalJSON writes the totals as exact decimal numbers in their currency
+func (t TagTotal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Tag          string          `json:"tag"`
+		Currency     string          `json:"currency"`
+		Count        int64           `json:"count"`
+		Total        json.RawMessage `json:"total"`
+		BaseCurrency string          `json:"baseCurrency,omitempty"`
+		BaseTotal    json.RawMessage `json:"baseTotal,omitempty"`
+		Unconverted  json.RawMessage `json:"unconverted"`
+	}{
+		t.Tag, t.Currency, t.Count, json.RawMessage(FormatAmount(t.Total, t.Currency)),
+		t.BaseCurrency, baseAmount(t.BaseTotal, t.BaseCurrency), json.RawMessage(FormatAmount(t.Unconverted, t.Currency)),
+	})
+}
+
+// TagTotals sums the expenses matching the filter per tag and currency, each
+// total also converted into the base currency at the rate of the expenses'
+// dates
+func TagTotals(expenses ExpenseRepository, converter *Converter, base string, filter ExpenseFilter) ([]TagTotal, error) {
+	totals, err := expenses.TotalsByTag(filter)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := expenses.DailyTotals(filter, GroupByTag)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := convertDailyTotals(converter, base, daily)
+	if err != nil {
+		return nil, err
+	}
+	for i := range totals {
+		c := converted[[2]string{totals[i].Tag, totals[i].Currency}]
+		totals[i].BaseCurrency, totals[i].BaseTotal, totals[i].Unconverted = base, c.base, c.unconverted
+	}
+	return totals, nil
+}
+
+// NormalizeTag trims and lower-cases a tag name so that "Trip-Lagos" and
+// "trip-lagos " are the same tag
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || utf8.RuneCountInString(name) > maxTagLength || strings.Contains(name, ",") {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// NormalizeTags normalizes a list of tag names, dropping duplicates, and
+// returns them sorted
+func NormalizeTags(names []string) ([]string, error) {
+	tags := []string{}
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if i := sort.SearchStrings(tags, tag); i == len(tags) || tags[i] != tag {
+			tags = append(tags, "")
+			copy(tags[i+1:], tags[i:])
+			tags[i] = tag
+		}
+	}
+	return tags, nil
+}
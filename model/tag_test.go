@@ -0,0 +1,117 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// createTaggedExpenses stores the expenses of the tag tests, in USD unless
+// stated otherwise
+func createTaggedExpenses(t *testing.T, store *Store) []ExpenseData {
+	expenses := createExpenses(t, store, 1,
+		ExpenseData{Title: "flight", Amount: 40000, Date: testDate("2026-10-01"), Tags: []string{"trip", "work"}},
+		ExpenseData{Title: "hotel", Amount: 30000, Date: testDate("2026-10-02"), Tags: []string{"trip", "work", "client"}},
+		ExpenseData{Title: "dinner", Amount: 5000, Currency: "EUR", Date: testDate("2026-10-02"), Tags: []string{"trip"}},
+		ExpenseData{Title: "laptop", Amount: 150000, Date: testDate("2026-10-03"), Tags: []string{"work"}},
+		ExpenseData{Title: "rent", Amount: 90000, Date: testDate("2026-10-04")},
+	)
+	// the same tags of another user never count
+	createExpenses(t, store, 2, ExpenseData{Title: "flight", Amount: 1000, Date: testDate("2026-10-01"), Tags: []string{"trip", "work"}})
+	return expenses
+}
+
+func TestTagFilter(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		expenses := createTaggedExpenses(t, store)
+
+		tests := []struct {
+			name   string
+			filter ExpenseFilter
+			want   []int
+		}{
+			{"any of one", ExpenseFilter{Tags: []string{"trip"}}, []int{0, 1, 2}},
+			// expenses carrying several of the tags are listed once
+			{"any of two", ExpenseFilter{Tags: []string{"trip", "work"}}, []int{0, 1, 2, 3}},
+			{"any of unknown", ExpenseFilter{Tags: []string{"nothing"}}, []int{}},
+			{"all of one", ExpenseFilter{Tags: []string{"work"}, AllTags: true}, []int{0, 1, 3}},
+			{"all of two", ExpenseFilter{Tags: []string{"trip", "work"}, AllTags: true}, []int{0, 1}},
+			{"all of three", ExpenseFilter{Tags: []string{"client", "trip", "work"}, AllTags: true}, []int{1}},
+			{"all with unknown", ExpenseFilter{Tags: []string{"trip", "nothing"}, AllTags: true}, []int{}},
+			{"combined", ExpenseFilter{Tags: []string{"trip"}, StartDate: testDate("2026-10-02")}, []int{1, 2}},
+		}
+		for _, tt := range tests {
+			tt.filter.UserId = 1
+			page, err := store.Expenses.List(ExpenseQuery{ExpenseFilter: tt.filter, Sort: SortById, Limit: 10})
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			want := []uint{}
+			for _, i := range tt.want {
+				want = append(want, expenses[i].ID)
+			}
+			if got := expenseIds(page.Expenses); !slices.Equal(got, want) || page.Total != int64(len(want)) {
+				t.Errorf("%s: got %v of %d, want %v", tt.name, got, page.Total, want)
+			}
+		}
+	})
+}
+
+func TestTotalsByTag(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *Store) {
+		createTaggedExpenses(t, store)
+
+		tests := []struct {
+			name   string
+			filter ExpenseFilter
+			want   []TagTotal
+		}{
+			// an expense counts once towards each of its tags
+			{"all expenses", ExpenseFilter{}, []TagTotal{
+				{Tag: "client", Currency: "USD", Count: 1, Total: 30000},
+				{Tag: "trip", Currency: "EUR", Count: 1, Total: 5000},
+				{Tag: "trip", Currency: "USD", Count: 2, Total: 70000},
+				{Tag: "work", Currency: "USD", Count: 3, Total: 220000},
+			}},
+			// matching several of the filter's tags counts an expense once
+			// per tag all the same
+			{"any tag", ExpenseFilter{Tags: []string{"trip", "work"}}, []TagTotal{
+				{Tag: "client", Currency: "USD", Count: 1, Total: 30000},
+				{Tag: "trip", Currency: "EUR", Count: 1, Total: 5000},
+				{Tag: "trip", Currency: "USD", Count: 2, Total: 70000},
+				{Tag: "work", Currency: "USD", Count: 3, Total: 220000},
+			}},
+			{"all tags", ExpenseFilter{Tags: []string{"client", "work"}, AllTags: true}, []TagTotal{
+				{Tag: "client", Currency: "USD", Count: 1, Total: 30000},
+				{Tag: "trip", Currency: "USD", Count: 1, Total: 30000},
+				{Tag: "work", Currency: "USD", Count: 1, Total: 30000},
+			}},
+			{"untagged", ExpenseFilter{StartDate: testDate("2026-10-04")}, []TagTotal{}},
+		}
+		for _, tt := range tests {
+			tt.filter.UserId = 1
+			got, err := store.Expenses.TotalsByTag(tt.filter)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+			}
+
+			// the tag grouping of the summary adds up the same way
+			groups, err := store.Expenses.Summarize(tt.filter, GroupByTag)
+			if err != nil {
+				t.Errorf("%s summary: %v", tt.name, err)
+				continue
+			}
+			summed := []TagTotal{}
+			for _, g := range groups {
+				summed = append(summed, TagTotal{Tag: g.Key, Currency: g.Currency, Count: g.Count, Total: g.Total})
+			}
+			if !slices.Equal(summed, tt.want) {
+				t.Errorf("%s summary: got %+v, want %+v", tt.name, summed, tt.want)
+			}
+		}
+	})
+}
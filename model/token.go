@@ -0,0 +1,77 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged, every
+// exchange issues a new one so active sessions keep going
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrTokenReused is returned when a refresh token that was already exchanged
+// or revoked is presented again
+var ErrTokenReused = errors.New("refresh token already used")
+
+// RefreshToken is a single-use token exchanged for a new access token and a
+// new refresh token. Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID     uint  `gorm:"primary_key"`
+	UserId int64 `gorm:"index"`
+	// Family groups the tokens rotated from one login, presenting a token
+	// of the family a second time revokes all of them
+	Family string `gorm:"index"`
+	Hash   string `gorm:"unique_index"`
+	// AccessId and AccessExpiresAt identify the access token issued with
+	// the refresh token so it can be revoked with its family
+	AccessId        string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// RevokedToken is an access token revoked before it expired, it is kept
+// until then
+type RevokedToken struct {
+	Jti       string    `gorm:"primary_key"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// NewTokenId returns a random identifier for a token or a token family
+func NewTokenId() string {
+	raw := make([]byte, 16)
+	rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+// NewRefreshToken creates the refresh token of a family and returns it with
+// the token to hand to the client, which is never stored
+func NewRefreshToken(userId int64, family string) (string, *RefreshToken) {
+	token := randomToken()
+	return token, &RefreshToken{
+		UserId:    userId,
+		Family:    family,
+		Hash:      HashToken(token),
+		ExpiresAt: time.Now().UTC().Add(RefreshTokenTTL),
+	}
+}
+
+// randomToken returns a random URL-safe token of 256 bits
+func randomToken() string {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// HashToken returns the hash under which a token is stored, a fast hash is
+// enough since tokens are long random strings
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,94 +1,118 @@
 package model
 
 import (
-	"expense-tracker/config"
-	"log"
+	"encoding/json"
+	"time"
 
 	"github.com/jinzhu/gorm"
 )
 
-var db *gorm.DB
-
 type UserData struct {
 	gorm.Model
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
-	Password  string `json:"password"`
-}
-
-type ExpenseData struct {
-	gorm.Model
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`
-	Category	string	`json:"category"`
-	UserId      int64   `json:"userId"`
+	// Password is the argon2id hash of the password, it is never sent
+	Password string `json:"-"`
+	// DateFormat is the preferred input format for dates, one of the keys of
+	// DateFormats, accepted in addition to ISO 8601
+	DateFormat string `json:"dateFormat"`
+	// BaseCurrency is the ISO 4217 code amounts are converted into for the user
+	BaseCurrency string `json:"baseCurrency" gorm:"type:char(3)"`
+	// Timezone is the IANA time zone of the user, Locale their BCP 47
+	// language tag
+	Timezone string `json:"timezone"`
+	Locale   string `json:"locale"`
+	// EmailVerifiedAt is when the user proved they own Email, nil until then
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	// PendingEmail is the address Email changes to once the user confirms
+	// they own it
+	PendingEmail string `json:"pendingEmail"`
+	// Role is one of Roles, RoleUser for everyone but the staff
+	Role string `json:"role" gorm:"default:'user'"`
+	// DisabledAt is when an admin disabled the account, nil while it is
+	// active
+	DisabledAt *time.Time `json:"disabledAt"`
+	// DeleteAt is when the account and everything it owns are deleted for
+	// good, nil unless the user asked to delete it
+	DeleteAt *time.Time `json:"deleteAt" gorm:"index"`
 }
 
-func init() {
-	config.Connect()
-	db = config.GetDB()
-	db.DB().SetConnMaxLifetime(10 * 60 * 1000)
-	db.DB().SetMaxIdleConns(10)
-	db.DB().SetMaxOpenConns(100)
-	db.AutoMigrate(&UserData{}, &ExpenseData{})
-	log.Println("Connected to database successfully")
-}
-
-func (u *UserData) CreateUser() *UserData {
-	db.NewRecord(u)
-	db.Create(&u)
-	return u
-}
-
-func GetUsers() []UserData {
-	var users []UserData
-	result := db.Find(&users)
-	if result.Error != nil {
-		return []UserData{}
-	}
-	return users
+// Disabled tells whether the account was disabled
+func (u *UserData) Disabled() bool {
+	return u.DisabledAt != nil
 }
 
-func GetUserById(id int64) (*UserData, *gorm.DB) {
-	var user UserData
-	result := db.Where("ID=?", id).First(&user)
-	return &user, result
+// DeletionPending tells whether the account waits for its deletion
+func (u *UserData) DeletionPending() bool {
+	return u.DeleteAt != nil
 }
 
-func DeleteUserById(id int64) UserData {
-	var user UserData
-	db.Where("ID=?", id).First(&user)
-	db.Where("ID=?", id).Delete(&user)
-	return user
+type ExpenseData struct {
+	gorm.Model
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Amount is in minor units of Currency, e.g. cents for USD
+	Amount   int64  `json:"amount" gorm:"column:amount_minor"`
+	Currency string `json:"currency" gorm:"type:char(3)"`
+	Date     Date   `json:"date" gorm:"type:date"`
+	// CategoryId references the user's Category, Category holds its name
+	// and is kept in step when the category is renamed
+	CategoryId uint   `json:"categoryId" gorm:"index"`
+	Category   string `json:"category"`
+	UserId     int64  `json:"userId"`
+	// Tags are the names of the expense's tags, sorted, they are stored in
+	// the ExpenseTag join table
+	Tags []string `json:"tags" gorm:"-"`
+	// RecurringId and OccurrenceDate link an expense generated from a
+	// RecurringExpense to its template and occurrence, each occurrence is
+	// generated at most once
+	RecurringId    *uint `json:"recurringId,omitempty" gorm:"unique_index:idx_expense_occurrence"`
+	OccurrenceDate *Date `json:"occurrenceDate,omitempty" gorm:"type:date;unique_index:idx_expense_occurrence"`
+	// ImportId is the Import the expense was created by, rolling the import
+	// back deletes it
+	ImportId *uint `json:"importId,omitempty" gorm:"index"`
+	// ExternalId identifies the transaction at the bank the expense was
+	// imported from, importing it again is skipped
+	ExternalId string `json:"externalId,omitempty" gorm:"index"`
+	// Converted is the amount in the user's base currency, it is filled in
+	// when the expense is returned and never stored
+	Converted *ConvertedAmount `json:"converted,omitempty" gorm:"-"`
+	// Budgets is the status of the budgets the expense counts against, it is
+	// filled in when the expense is created and never stored
+	Budgets []BudgetStatus `json:"budgets,omitempty" gorm:"-"`
 }
 
-func (e *ExpenseData) CreateExpense() *ExpenseData {
-	db.NewRecord(e)
-	db.Create(&e)
-	return e
+// MarshalJSON writes the amount as an exact decimal number in the currency of
+// the expense, 1250 minor units of USD are written as 12.50
+func (e ExpenseData) MarshalJSON() ([]byte, error) {
+	type expense ExpenseData
+	return json.Marshal(struct {
+		expense
+		Amount json.RawMessage `json:"amount"`
+	}{expense(e), json.RawMessage(FormatAmount(e.Amount, e.Currency))})
 }
 
-func GetExpense() []ExpenseData {
-	var expense []ExpenseData
-	result := db.Find(&expense)
-	if result.Error != nil {
-		return []ExpenseData{}
+// UnmarshalJSON reads the decimal amount written by MarshalJSON back into
+// minor units, the amount may be given as a JSON number or string
+func (e *ExpenseData) UnmarshalJSON(data []byte) error {
+	type expense ExpenseData
+	var decoded struct {
+		*expense
+		Amount json.Number `json:"amount"`
 	}
-	return expense
-}
-
-func GetExpenseById(id int64) (ExpenseData, *gorm.DB) {
-	var expense ExpenseData
-	result := db.Where("ID=?", id).First(&expense)
-	return expense, result
-}
-
-func DeleteExpenseById(id int64) ExpenseData {
-	var expense ExpenseData
-	db.Where("ID=?", id).First(&expense)
-	db.Where("ID=?", id).Delete(&expense)
-	return expense
+	decoded.expense = (*expense)(e)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Amount == "" {
+		e.Amount = 0
+		return nil
+	}
+	amount, err := ParseAmount(decoded.Amount.String(), e.Currency)
+	if err != nil {
+		return err
+	}
+	e.Amount = amount
+	return nil
 }
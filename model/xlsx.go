@@ -0,0 +1,237 @@
+package model
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of the cells of an XLSX export, as indexes into the cellXfs of
+// xlsxStyles
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleDate
+	xlsxStyleTime
+	// amounts with 0, 2 and 3 decimal places
+	xlsxStyleAmount0
+	xlsxStyleAmount2
+	xlsxStyleAmount3
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Expenses" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// xlsxStyles holds the cell styles, %s is the number format of dates
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="3"><numFmt numFmtId="164" formatCode="%s"/><numFmt numFmtId="165" formatCode="%s hh:mm:ss"/><numFmt numFmtId="166" formatCode="#,##0.000"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="7">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+// xlsxEpoch is day 0 of the dates of spreadsheets
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxExpenseWriter writes a workbook with a single sheet. The rows are
+// streamed into the sheet with inline strings, so nothing is kept for the
+// shared strings table most writers build.
+type xlsxExpenseWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	options ExportOptions
+	row     int
+}
+
+func newXLSXExpenseWriter(w io.Writer, options ExportOptions) (*xlsxExpenseWriter, error) {
+	archive := zip.NewWriter(w)
+	dateFormat := xlsxDateFormat(options.DateFormat)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", strings.ReplaceAll(xlsxStyles, "%s", dateFormat)},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxExpenseWriter{archive: archive, sheet: bufio.NewWriter(file), options: options}
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`)
+	header := make([]interface{}, len(options.Columns))
+	for i, column := range options.Columns {
+		header[i] = column
+	}
+	x.writeRow(header, xlsxStyleHeader)
+	return x, nil
+}
+
+func (x *xlsxExpenseWriter) Write(e *ExpenseData) error {
+	values := make([]interface{}, len(x.options.Columns))
+	for i, column := range x.options.Columns {
+		values[i] = exportValue(e, column)
+	}
+	x.writeRow(values, xlsxStyleDefault)
+	return nil
+}
+
+// writeRow writes the cells of a row, strings in the style
+func (x *xlsxExpenseWriter) writeRow(values []interface{}, style int) {
+	x.row++
+	row := strconv.Itoa(x.row)
+	x.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range values {
+		ref := xlsxColumn(i) + row
+		switch v := value.(type) {
+		case string:
+			x.inlineString(ref, v, style)
+		case []string:
+			x.inlineString(ref, strings.Join(v, ";"), style)
+		case Date:
+			if !v.IsZero() {
+				x.number(ref, strconv.Itoa(int(v.Sub(xlsxEpoch).Hours()/24)), xlsxStyleDate)
+			}
+		case time.Time:
+			days := v.UTC().Sub(xlsxEpoch).Seconds() / 86400
+			x.number(ref, strconv.FormatFloat(days, 'f', 6, 64), xlsxStyleTime)
+		case exportMoney:
+			x.number(ref, FormatAmount(v.amount, v.currency), xlsxAmountStyle(v.currency))
+		case uint:
+			x.number(ref, strconv.FormatUint(uint64(v), 10), xlsxStyleDefault)
+		}
+	}
+	x.sheet.WriteString(`</row>`)
+}
+
+func (x *xlsxExpenseWriter) inlineString(ref, value string, style int) {
+	if value == "" {
+		return
+	}
+	x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"` + xlsxStyleAttr(style) + `><is><t xml:space="preserve">`)
+	xml.EscapeText(x.sheet, []byte(xlsxText(value)))
+	x.sheet.WriteString(`</t></is></c>`)
+}
+
+func (x *xlsxExpenseWriter) number(ref, value string, style int) {
+	x.sheet.WriteString(`<c r="` + ref + `"` + xlsxStyleAttr(style) + `><v>` + value + `</v></c>`)
+}
+
+func (x *xlsxExpenseWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Flush()
+}
+
+func (x *xlsxExpenseWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// xlsxColumn returns the letters of the column at the index from 0
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+func xlsxStyleAttr(style int) string {
+	if style == xlsxStyleDefault {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// xlsxAmountStyle returns the style showing the decimal places of the currency
+func xlsxAmountStyle(currency string) int {
+	switch MinorUnits(currency) {
+	case 0:
+		return xlsxStyleAmount0
+	case 3:
+		return xlsxStyleAmount3
+	}
+	return xlsxStyleAmount2
+}
+
+// xlsxDateFormat turns a date format such as DD.MM.YYYY into a spreadsheet
+// number format, escaping the separators so they are not localized
+func xlsxDateFormat(format string) string {
+	if _, ok := DateFormats[format]; !ok {
+		format = "YYYY-MM-DD"
+	}
+	var code strings.Builder
+	for _, r := range strings.ToLower(format) {
+		if r != 'd' && r != 'm' && r != 'y' {
+			code.WriteRune('\\')
+		}
+		code.WriteRune(r)
+	}
+	return code.String()
+}
+
+// xlsxText drops the characters XML 1.0 cannot hold
+func xlsxText(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+	"expense-tracker/model"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterAdminRoutes = func(router *mux.Router, h *controller.Handler) {
+	staff := func(route *mux.Route) { h.RequireRoles(route, model.RoleAdmin, model.RoleSupport) }
+	admin := func(route *mux.Route) { h.RequireRoles(route, model.RoleAdmin) }
+
+	staff(router.HandleFunc("/admin/users", h.AdminListUsers).Methods("GET"))
+	staff(router.HandleFunc("/admin/users/{id}", h.AdminGetUser).Methods("GET"))
+	staff(router.HandleFunc("/admin/users/{id}/stats", h.AdminGetUserStats).Methods("GET"))
+	admin(router.HandleFunc("/admin/users/{id}/disable", h.AdminDisableUser).Methods("POST"))
+	admin(router.HandleFunc("/admin/users/{id}/enable", h.AdminEnableUser).Methods("POST"))
+	staff(router.HandleFunc("/admin/users/{id}/logout", h.AdminLogoutUser).Methods("POST"))
+	staff(router.HandleFunc("/admin/users/{id}/password-reset", h.AdminResetPassword).Methods("POST"))
+	admin(router.HandleFunc("/admin/users/{id}/role", h.AdminSetRole).Methods("PUT"))
+	admin(router.HandleFunc("/admin/audit", h.AdminGetAuditLog).Methods("GET"))
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+	"expense-tracker/model"
+	"expense-tracker/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAdminRoutes(t *testing.T) {
+	store := model.NewMemoryStore()
+	utils.SetKeySet(&utils.KeySet{Keys: map[string]*utils.JWTKey{}, Legacy: []byte("secret")})
+	utils.SetRevocationList(store.Tokens)
+	h := controller.NewHandler(store, "USD")
+	h.Mailer = &utils.LogMailer{}
+	router := mux.NewRouter()
+	router.Use(h.Authenticate)
+	RegisterAdminRoutes(router, h)
+
+	users := map[string]*model.UserData{}
+	tokens := map[string]string{}
+	for _, role := range []string{model.RoleAdmin, model.RoleSupport, model.RoleUser} {
+		user := &model.UserData{Email: role + "@example.com", Role: role}
+		if err := store.Users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+		token, err := utils.SignJWTToken(int64(user.ID), user.Email, model.NewTokenId())
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[role], tokens[role] = user, token
+	}
+	target := &model.UserData{Email: "target@example.com", Role: model.RoleUser}
+	if err := store.Users.Create(target); err != nil {
+		t.Fatal(err)
+	}
+
+	path := func(user *model.UserData, action string) string {
+		return fmt.Sprintf("/admin/users/%d%s", user.ID, action)
+	}
+	tests := []struct {
+		role, method, path, body string
+		want                     int
+		// action is recorded in the audit log when the request is served
+		action string
+	}{
+		// support agents may look and help, not change accounts or roles
+		{model.RoleSupport, "GET", "/admin/users", "", http.StatusOK, model.AuditAdminSearchUsers},
+		{model.RoleSupport, "GET", path(target, ""), "", http.StatusOK, model.AuditAdminViewUser},
+		{model.RoleSupport, "GET", path(target, "/stats"), "", http.StatusOK, model.AuditAdminViewStats},
+		{model.RoleSupport, "POST", path(target, "/logout"), "", http.StatusOK, model.AuditAdminLogout},
+		{model.RoleSupport, "POST", path(target, "/password-reset"), "", http.StatusAccepted, model.AuditAdminPasswordReset},
+		{model.RoleSupport, "POST", path(target, "/disable"), "", http.StatusForbidden, ""},
+		{model.RoleSupport, "POST", path(target, "/enable"), "", http.StatusForbidden, ""},
+		{model.RoleSupport, "PUT", path(users[model.RoleSupport], "/role"), `{"role": "admin"}`, http.StatusForbidden, ""},
+		{model.RoleSupport, "PUT", path(target, "/role"), `{"role": "admin"}`, http.StatusForbidden, ""},
+		{model.RoleSupport, "GET", "/admin/audit", "", http.StatusForbidden, ""},
+		// users may do nothing at all
+		{model.RoleUser, "GET", "/admin/users", "", http.StatusForbidden, ""},
+		{model.RoleUser, "PUT", path(users[model.RoleUser], "/role"), `{"role": "admin"}`, http.StatusForbidden, ""},
+		// admins may not change their own role nor disable themselves
+		{model.RoleAdmin, "PUT", path(users[model.RoleAdmin], "/role"), `{"role": "user"}`, http.StatusBadRequest, ""},
+		{model.RoleAdmin, "POST", path(users[model.RoleAdmin], "/disable"), "", http.StatusBadRequest, ""},
+		{model.RoleAdmin, "PUT", path(target, "/role"), `{"role": "owner"}`, http.StatusBadRequest, ""},
+		{model.RoleAdmin, "PUT", path(target, "/role"), `{"role": "support"}`, http.StatusOK, model.AuditAdminSetRole},
+		{model.RoleAdmin, "POST", path(target, "/disable"), `{"reason": "fraud"}`, http.StatusOK, model.AuditAdminDisable},
+		{model.RoleAdmin, "POST", path(target, "/enable"), "", http.StatusOK, model.AuditAdminEnable},
+		{model.RoleAdmin, "GET", "/admin/audit", "", http.StatusOK, model.AuditAdminViewAudit},
+	}
+	for _, tt := range tests {
+		before, err := store.Audit.List(model.AuditQuery{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("Authorization", "Bearer "+tokens[tt.role])
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s %s %s: got %d %s, want %d", tt.role, tt.method, tt.path, w.Code, w.Body, tt.want)
+		}
+
+		after, err := store.Audit.List(model.AuditQuery{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.action == "" {
+			if after.Total != before.Total {
+				t.Errorf("%s %s %s: a refused request was audited as %s", tt.role, tt.method, tt.path, after.Entries[0].Action)
+			}
+			continue
+		}
+		if after.Total != before.Total+1 {
+			t.Errorf("%s %s %s: got %d new audit entries, want 1", tt.role, tt.method, tt.path, after.Total-before.Total)
+			continue
+		}
+		entry := after.Entries[0]
+		if entry.Action != tt.action || entry.ActorId == nil || *entry.ActorId != int64(users[tt.role].ID) {
+			t.Errorf("%s %s %s: got audit entry %+v, want %s by user %d", tt.role, tt.method, tt.path, entry, tt.action, users[tt.role].ID)
+		}
+		if strings.HasPrefix(tt.path, path(target, "")) && (entry.UserId == nil || *entry.UserId != int64(target.ID)) {
+			t.Errorf("%s %s %s: got audit entry about %v, want user %d", tt.role, tt.method, tt.path, entry.UserId, target.ID)
+		}
+	}
+
+	// nobody but the admin changed a role
+	for role, user := range users {
+		if found, err := store.Users.FindById(int64(user.ID)); err != nil || found.Role != role {
+			t.Errorf("%s: got %+v, %v", role, found, err)
+		}
+	}
+	if found, err := store.Users.FindById(int64(target.ID)); err != nil || found.Role != model.RoleSupport || found.Disabled() {
+		t.Errorf("target: got %+v, %v, want an enabled support agent", found, err)
+	}
+	page, err := store.Audit.List(model.AuditQuery{Action: model.AuditAdminSetRole, Limit: 10})
+	if err != nil || page.Total != 1 || page.Entries[0].Detail != "user to support" {
+		t.Errorf("role changes: got %+v, %v", page, err)
+	}
+}
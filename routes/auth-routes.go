@@ -6,7 +6,22 @@ import (
 	"github.com/gorilla/mux"
 )
 
-var RegisterAuthRoutes = func(router *mux.Router) {
-	router.HandleFunc("/auth/register", controller.RegisterUser).Methods("POST")
-	router.HandleFunc("/auth/login", controller.LoginUser).Methods("POST")
+var RegisterAuthRoutes = func(router *mux.Router, h *controller.Handler) {
+	h.Public(router.HandleFunc("/auth/register", h.RegisterUser).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/login", h.LoginUser).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/refresh", h.RefreshToken).Methods("POST"))
+	router.HandleFunc("/auth/logout", h.Logout).Methods("POST")
+	router.HandleFunc("/auth/logout-all", h.LogoutEverywhere).Methods("POST")
+	h.Public(router.HandleFunc("/auth/forgot-password", h.ForgotPassword).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/reset-password", h.ResetPassword).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/verify-email", h.VerifyEmail).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/verify-email/resend", h.ResendVerificationEmail).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/confirm-email", h.ConfirmEmailChange).Methods("POST"))
+	h.Public(router.HandleFunc("/auth/restore-account", h.RestoreAccount).Methods("POST"))
+	router.HandleFunc("/auth/mfa", h.GetMFAStatus).Methods("GET")
+	router.HandleFunc("/auth/mfa/enroll", h.EnrollMFA).Methods("POST")
+	router.HandleFunc("/auth/mfa/confirm", h.ConfirmMFA).Methods("POST")
+	router.HandleFunc("/auth/mfa/recovery-codes", h.RegenerateRecoveryCodes).Methods("POST")
+	router.HandleFunc("/auth/mfa/disable", h.DisableMFA).Methods("POST")
+	h.Public(router.HandleFunc("/auth/mfa/verify", h.VerifyMFA).Methods("POST"))
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterBudgetRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/budgets", h.GetBudgets).Methods("GET")
+	router.HandleFunc("/budgets", h.CreateBudget).Methods("POST")
+	router.HandleFunc("/budgets/status", h.GetBudgetStatuses).Methods("GET")
+	router.HandleFunc("/budgets/{id}", h.GetBudgetById).Methods("GET")
+	router.HandleFunc("/budgets/{id}", h.UpdateBudget).Methods("PATCH")
+	router.HandleFunc("/budgets/{id}", h.DeleteBudget).Methods("DELETE")
+	router.HandleFunc("/budgets/{id}/status", h.GetBudgetStatus).Methods("GET")
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterCategoryRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/categories", h.CreateCategory).Methods("POST")
+	router.HandleFunc("/categories", h.GetCategories).Methods("GET")
+	router.HandleFunc("/categories/{id}", h.GetCategoryById).Methods("GET")
+	router.HandleFunc("/categories/{id}", h.UpdateCategory).Methods("PATCH")
+	router.HandleFunc("/categories/{id}", h.DeleteCategory).Methods("DELETE")
+}
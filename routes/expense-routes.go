@@ -2,19 +2,24 @@ package routes
 
 import (
 	"expense-tracker/controller"
+	"expense-tracker/model"
 
 	"github.com/gorilla/mux"
 )
 
-var RegisterExpenseRoutes = func(router *mux.Router) {
-	router.HandleFunc("/expenses", controller.CreateExpense).Methods("POST")
-	router.HandleFunc("/expenses", controller.GetExpense).Methods("GET")
-	router.HandleFunc("/expenses/week", controller.FilterExpenseByWeek).Methods("GET")
-	router.HandleFunc("/expenses/month", controller.FilterExpenseByMonth).Methods("GET")
-	router.HandleFunc("/expenses/past-three-month", controller.FilterExpenseByPastThreeMonth).Methods("GET")
-	router.HandleFunc("/expenses/dates", controller.FilterExpenseByCustomDate).Methods("GET")
-	router.HandleFunc("/expenses/category", controller.FilterExpenseByCategory).Methods("GET")
-	router.HandleFunc("/expenses/{id}", controller.GetExpenseById).Methods("GET")
-	router.HandleFunc("/expenses/{id}", controller.UpdateExpense).Methods("PATCH")
-	router.HandleFunc("/expenses/{id}", controller.DeleteExpenseById).Methods("DELETE")
+var RegisterExpenseRoutes = func(router *mux.Router, h *controller.Handler) {
+	read := func(route *mux.Route) { h.RequireScopes(route, model.ScopeExpensesRead) }
+	write := func(route *mux.Route) { h.RequireScopes(route, model.ScopeExpensesWrite) }
+
+	write(router.HandleFunc("/expenses", h.CreateExpense).Methods("POST"))
+	read(router.HandleFunc("/expenses", h.GetExpense).Methods("GET"))
+	read(router.HandleFunc("/expenses/week", h.FilterExpenseByWeek).Methods("GET"))
+	read(router.HandleFunc("/expenses/month", h.FilterExpenseByMonth).Methods("GET"))
+	read(router.HandleFunc("/expenses/past-three-month", h.FilterExpenseByPastThreeMonth).Methods("GET"))
+	read(router.HandleFunc("/expenses/dates", h.FilterExpenseByCustomDate).Methods("GET"))
+	read(router.HandleFunc("/expenses/category", h.FilterExpenseByCategory).Methods("GET"))
+	read(router.HandleFunc("/expenses/export", h.ExportExpenses).Methods("GET"))
+	read(router.HandleFunc("/expenses/{id}", h.GetExpenseById).Methods("GET"))
+	write(router.HandleFunc("/expenses/{id}", h.UpdateExpense).Methods("PATCH"))
+	write(router.HandleFunc("/expenses/{id}", h.DeleteExpenseById).Methods("DELETE"))
 }
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+	"expense-tracker/model"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterImportRoutes = func(router *mux.Router, h *controller.Handler) {
+	read := func(route *mux.Route) { h.RequireScopes(route, model.ScopeExpensesRead) }
+	write := func(route *mux.Route) { h.RequireScopes(route, model.ScopeExpensesWrite) }
+
+	read(router.HandleFunc("/imports/mappings", h.GetImportMappings).Methods("GET"))
+	write(router.HandleFunc("/imports/mappings", h.CreateImportMapping).Methods("POST"))
+	read(router.HandleFunc("/imports/mappings/{id}", h.GetImportMapping).Methods("GET"))
+	write(router.HandleFunc("/imports/mappings/{id}", h.UpdateImportMapping).Methods("PUT"))
+	write(router.HandleFunc("/imports/mappings/{id}", h.DeleteImportMapping).Methods("DELETE"))
+	write(router.HandleFunc("/imports", h.CreateImport).Methods("POST"))
+	read(router.HandleFunc("/imports", h.GetImports).Methods("GET"))
+	read(router.HandleFunc("/imports/{id}", h.GetImport).Methods("GET"))
+	write(router.HandleFunc("/imports/{id}", h.DeleteImport).Methods("DELETE"))
+	write(router.HandleFunc("/imports/{id}/commit", h.CommitImport).Methods("POST"))
+	write(router.HandleFunc("/imports/{id}/rollback", h.RollbackImport).Methods("POST"))
+}
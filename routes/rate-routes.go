@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+	"expense-tracker/model"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterRateRoutes = func(router *mux.Router, h *controller.Handler) {
+	h.RequireRoles(router.HandleFunc("/rates", h.UploadRates).Methods("POST"), model.RoleAdmin)
+	router.HandleFunc("/rates", h.GetRate).Methods("GET")
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterRecurringRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/recurring", h.GetRecurringExpenses).Methods("GET")
+	router.HandleFunc("/recurring", h.CreateRecurringExpense).Methods("POST")
+	router.HandleFunc("/recurring/{id}", h.GetRecurringExpenseById).Methods("GET")
+	router.HandleFunc("/recurring/{id}", h.UpdateRecurringExpense).Methods("PATCH")
+	router.HandleFunc("/recurring/{id}", h.DeleteRecurringExpense).Methods("DELETE")
+	router.HandleFunc("/recurring/{id}/occurrences", h.GetOccurrences).Methods("GET")
+	router.HandleFunc("/recurring/{id}/occurrences/{date}", h.UpdateOccurrence).Methods("PUT")
+	router.HandleFunc("/recurring/{id}/occurrences/{date}", h.DeleteOccurrenceChange).Methods("DELETE")
+}
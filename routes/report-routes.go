@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+	"expense-tracker/model"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterReportRoutes = func(router *mux.Router, h *controller.Handler) {
+	h.RequireScopes(router.HandleFunc("/reports/summary", h.GetSummaryReport).Methods("GET"), model.ScopeReportsRead)
+	h.RequireScopes(router.HandleFunc("/reports/tags", h.GetTagReport).Methods("GET"), model.ScopeReportsRead)
+	// statements list the expenses too
+	h.RequireScopes(router.HandleFunc("/reports/statement", h.GetStatement).Methods("GET"), model.ScopeReportsRead, model.ScopeExpensesRead)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterTagRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/tags", h.GetTags).Methods("GET")
+	router.HandleFunc("/tags/{id}", h.RenameTag).Methods("PATCH")
+	router.HandleFunc("/tags/{id}", h.DeleteTag).Methods("DELETE")
+	router.HandleFunc("/tags/{id}/merge", h.MergeTag).Methods("POST")
+}
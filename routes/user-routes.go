@@ -6,7 +6,16 @@ import (
 	"github.com/gorilla/mux"
 )
 
-var RegisterUserRoutes = func(router *mux.Router) {
-	router.HandleFunc("/users/me", controller.GetMyAccount).Methods("GET")
-	router.HandleFunc("/users/me", controller.DeleteMyAccount).Methods("DELETE")
-}
\ No newline at end of file
+var RegisterUserRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/users/me", h.GetMyAccount).Methods("GET")
+	router.HandleFunc("/users/me", h.UpdateMyAccount).Methods("PATCH")
+	router.HandleFunc("/users/me", h.DeleteMyAccount).Methods("DELETE")
+	router.HandleFunc("/users/me/password", h.ChangeMyPassword).Methods("POST")
+	router.HandleFunc("/users/me/export", h.GetExports).Methods("GET")
+	router.HandleFunc("/users/me/export", h.RequestExport).Methods("POST")
+	router.HandleFunc("/users/me/export/{id}", h.GetExport).Methods("GET")
+	router.HandleFunc("/users/me/export/{id}/download", h.DownloadExport).Methods("GET")
+	router.HandleFunc("/users/me/tokens", h.GetAPITokens).Methods("GET")
+	router.HandleFunc("/users/me/tokens", h.CreateAPIToken).Methods("POST")
+	router.HandleFunc("/users/me/tokens/{id}", h.DeleteAPIToken).Methods("DELETE")
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"expense-tracker/controller"
+
+	"github.com/gorilla/mux"
+)
+
+var RegisterWellKnownRoutes = func(router *mux.Router, h *controller.Handler) {
+	router.HandleFunc("/.well-known/jwks.json", h.GetJWKS).Methods("GET")
+}
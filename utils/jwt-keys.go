@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// JWTKey is a key access tokens are signed or verified with, identified in
+// the token header by its ID
+type JWTKey struct {
+	Id     string
+	Method jwt.SigningMethod
+	// Private is nil for keys that only verify tokens
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the key new access tokens are signed with and every key
+// tokens are still verified with
+type KeySet struct {
+	Signing *JWTKey
+	// Keys holds the verification keys by ID, the signing key included
+	Keys map[string]*JWTKey
+	// Legacy is the HMAC secret of tokens issued before asymmetric keys were
+	// introduced, they carry no key ID. It is nil once JWT_KEY is removed.
+	Legacy []byte
+}
+
+// LoadKeySet reads the keys of the directory, one PEM file per key named
+// after its ID: <id>.pem holds a PKCS#8 private key, RSA or Ed25519, which
+// can sign tokens, <id>.pub.pem a PKIX public key which only verifies them.
+// signingId picks the signing key, by default the private key with the
+// greatest ID.
+func LoadKeySet(dir, signingId string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	set := &KeySet{Keys: map[string]*JWTKey{}}
+	for _, path := range paths {
+		key, err := readJWTKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, ok := set.Keys[key.Id]; ok && key.Private == nil {
+			// the private key of the same ID covers verification
+			continue
+		}
+		set.Keys[key.Id] = key
+	}
+
+	ids := make([]string, 0, len(set.Keys))
+	for id := range set.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if set.Keys[id].Private != nil && (signingId == "" || id == signingId) {
+			set.Signing = set.Keys[id]
+		}
+	}
+	if set.Signing == nil {
+		if signingId != "" {
+			return nil, fmt.Errorf("no private key %q in %s", signingId, dir)
+		}
+		return nil, fmt.Errorf("no private key in %s", dir)
+	}
+	return set, nil
+}
+
+// readJWTKey reads a private or public key from a PEM file
+func readJWTKey(path string) (*JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".pem")
+	key := &JWTKey{Id: strings.TrimSuffix(name, ".pub")}
+	if strings.HasSuffix(name, ".pub") {
+		key.Public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	} else {
+		key.Private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if signer, ok := key.Private.(crypto.Signer); ok {
+			key.Public = signer.Public()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+// GenerateJWTKey writes a new private key of the algorithm, rsa or ed25519,
+// into the directory and returns its ID, which is the current UTC time so
+// newer keys sort last
+func GenerateJWTKey(dir, algorithm string) (string, error) {
+	var private crypto.PrivateKey
+	var err error
+	switch algorithm {
+	case "rsa":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ed25519":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", fmt.Errorf("unknown key algorithm %q, use rsa or ed25519", algorithm)
+	}
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	id := time.Now().UTC().Format("20060102T150405Z")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, id+".pem")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", err
+	}
+	return id, f.Close()
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// N and E are the modulus and exponent of RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv and X are the curve and public key of Ed25519 keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the set of keys other services verify access tokens with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the verification keys of the set as a JWKS, the HMAC
+// secret of legacy tokens is never published
+func (s *KeySet) PublicKeys() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if s == nil {
+		return jwks
+	}
+	ids := make([]string, 0, len(s.Keys))
+	for id := range s.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		key := s.Keys[id]
+		jwk := JWK{Kid: id, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
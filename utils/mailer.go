@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewMailer returns the mailer selected by MAIL_DRIVER
+func NewMailer(env *EnvData) Mailer {
+	switch env.MailDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Addr:     net.JoinHostPort(env.SMTPHost, env.SMTPPort),
+			Host:     env.SMTPHost,
+			Username: env.SMTPUsername,
+			Password: env.SMTPPassword,
+			From:     env.MailFrom,
+		}
+	case "file":
+		return &FileMailer{Path: env.MailFile, From: env.MailFrom}
+	case "log":
+		return &LogMailer{From: env.MailFrom}
+	}
+	log.Fatalf("Unknown MAIL_DRIVER %q", env.MailDriver)
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server, authenticating when a
+// username is set
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, formatMessage(m.From, msg))
+}
+
+// FileMailer appends emails to a file instead of sending them, for
+// development and tests
+type FileMailer struct {
+	Path string
+	From string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(formatMessage(m.From, msg), "\r\n"...)); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// LogMailer writes emails to the log instead of sending them
+type LogMailer struct {
+	From string
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// formatMessage builds the RFC 5322 message of an email, header values have
+// line breaks removed so they cannot inject headers
+func formatMessage(from string, msg Message) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
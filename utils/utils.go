@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -13,9 +14,36 @@ import (
 )
 
 type EnvData struct {
-    DBURL   string
-    JWTKey  string
-    PORT  string
+    DBDriver string
+    DBURL    string
+    JWTKey   string
+    PORT     string
+    // JWTKeyDir holds the keys access tokens are signed and verified with,
+    // JWTSigningKeyId picks the signing key among them
+    JWTKeyDir       string
+    JWTSigningKeyId string
+    // Currency is the ISO 4217 code used for expenses created without one
+    Currency string
+    // AppURL is the base URL of the client, links sent by email point to it
+    AppURL   string
+    // RequireVerified refuses sessions to users who did not verify their email
+    RequireVerified bool
+    // MailDriver selects how emails are sent: smtp, file or log
+    MailDriver   string
+    MailFrom     string
+    MailFile     string
+    SMTPHost     string
+    SMTPPort     string
+    SMTPUsername string
+    SMTPPassword string
+    // LoginLimiter selects where failed logins are counted: database, shared
+    // by every instance, or memory
+    LoginLimiter string
+    // TrustProxy takes the client address from the X-Forwarded-For header
+    TrustProxy bool
+    // DeletionGrace is how long a deleted account can be restored before it
+    // and everything it stores are deleted for good
+    DeletionGrace time.Duration
 }
 
 func ParseBody(r *http.Request, x interface{}) {
@@ -27,72 +55,176 @@ func ParseBody(r *http.Request, x interface{}) {
 }
 
 func LoadEnv() *EnvData {
+    // the .env file is optional, variables may also come from the environment
     err := godotenv.Load(".env")
-    if err != nil {
+    if err != nil && !errors.Is(err, os.ErrNotExist) {
         log.Fatalf("Error loading .env file: %v", err)
     }
 
     cfg := &EnvData{
-        DBURL: os.Getenv("DATABASE_URL"),
-        JWTKey: os.Getenv("JWT_KEY"),
-        PORT: os.Getenv("PORT"),
+        DBDriver: os.Getenv("DB_DRIVER"),
+        DBURL:    os.Getenv("DATABASE_URL"),
+        JWTKey:   os.Getenv("JWT_KEY"),
+        JWTKeyDir:       os.Getenv("JWT_KEY_DIR"),
+        JWTSigningKeyId: os.Getenv("JWT_SIGNING_KEY_ID"),
+        PORT:     os.Getenv("PORT"),
+        Currency: os.Getenv("DEFAULT_CURRENCY"),
+        AppURL:   os.Getenv("APP_URL"),
+        RequireVerified: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+        MailDriver:   os.Getenv("MAIL_DRIVER"),
+        MailFrom:     os.Getenv("MAIL_FROM"),
+        MailFile:     os.Getenv("MAIL_FILE"),
+        SMTPHost:     os.Getenv("SMTP_HOST"),
+        SMTPPort:     os.Getenv("SMTP_PORT"),
+        SMTPUsername: os.Getenv("SMTP_USERNAME"),
+        SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+        LoginLimiter: os.Getenv("LOGIN_LIMITER"),
+        TrustProxy:   os.Getenv("TRUST_PROXY") == "true",
     }
 
-    if cfg.DBURL == "" {
+    if cfg.DBDriver == "" {
+        cfg.DBDriver = "mysql"
+    }
+    if cfg.Currency == "" {
+        cfg.Currency = "USD"
+    }
+    if cfg.AppURL == "" {
+        cfg.AppURL = "http://localhost:" + cfg.PORT
+    }
+    if cfg.MailDriver == "" {
+        cfg.MailDriver = "log"
+    }
+    if cfg.MailFrom == "" {
+        cfg.MailFrom = "no-reply@localhost"
+    }
+    if cfg.MailFile == "" {
+        cfg.MailFile = "mail.log"
+    }
+    if cfg.SMTPPort == "" {
+        cfg.SMTPPort = "587"
+    }
+    if cfg.LoginLimiter == "" {
+        cfg.LoginLimiter = "database"
+    }
+    if cfg.LoginLimiter != "database" && cfg.LoginLimiter != "memory" {
+        log.Fatalf("Unknown LOGIN_LIMITER %q, use database or memory", cfg.LoginLimiter)
+    }
+    cfg.DeletionGrace = 30 * 24 * time.Hour
+    if grace := os.Getenv("ACCOUNT_DELETION_GRACE"); grace != "" {
+        if cfg.DeletionGrace, err = time.ParseDuration(grace); err != nil || cfg.DeletionGrace < 0 {
+            log.Fatalf("Invalid ACCOUNT_DELETION_GRACE %q, use a duration such as 720h", grace)
+        }
+    }
+    if cfg.MailDriver == "smtp" && cfg.SMTPHost == "" {
+        log.Fatal("SMTP_HOST is not set")
+    }
+    if cfg.DBURL == "" && cfg.DBDriver != "memory" {
         log.Fatal("DATABASE_URL is not set")
     }
 	if cfg.PORT == "" {
         log.Fatal("PORT is not set")
     }
-    if cfg.JWTKey == "" {
-        log.Fatal("JWT_KEY is not set")
+    if cfg.JWTKey == "" && cfg.JWTKeyDir == "" {
+        log.Fatal("JWT_KEY_DIR or JWT_KEY is not set")
     }
 
     return cfg
 }
 
-func SignJWTToken(userId int64, email string) (string, error) {
-	var (
-		key []byte
-		t   *jwt.Token
-	)
-
-	env := LoadEnv()
-	key = []byte(env.JWTKey)
-	t = jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"iss":   "expense-tracker",
-			"sub":   userId,
-			"email": email,
-			"exp": time.Now().Add(time.Hour * 1).Unix(),
-		})
-	s, err := t.SignedString(key)
-
-	return s, err
+// AccessTokenTTL is how long an access token is valid
+const AccessTokenTTL = time.Hour
+
+// RevocationList tells whether an access token was revoked before it expired
+type RevocationList interface {
+	IsRevoked(jti string) (bool, error)
 }
 
-func VerifyJWTToken(tokenString string) (*jwt.Token, error) {
-	env := LoadEnv()
-	key := []byte(env.JWTKey)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
+var revocationList RevocationList
+
+// SetRevocationList sets the list VerifyJWTToken checks access tokens against
+func SetRevocationList(list RevocationList) {
+	revocationList = list
+}
+
+var keySet *KeySet
+
+// SetKeySet sets the keys access tokens are signed and verified with, they
+// are read once at startup
+func SetKeySet(set *KeySet) {
+	keySet = set
+}
+
+// SignJWTToken issues an access token for the user, jti identifies the token
+// so that it can be revoked. Tokens are signed with the signing key of the
+// key set and name it in their kid header, or with the legacy HMAC secret
+// when no key directory is configured.
+func SignJWTToken(userId int64, email, jti string) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":   "expense-tracker",
+		"sub":   userId,
+		"email": email,
+		"jti":   jti,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(AccessTokenTTL).Unix(),
+	}
+	if keySet.Signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(keySet.Legacy)
+	}
+	t := jwt.NewWithClaims(keySet.Signing.Method, claims)
+	t.Header["kid"] = keySet.Signing.Id
+	return t.SignedString(keySet.Signing.Private)
+}
+
+// verificationKey returns the key a token must be verified with, the key
+// named by its kid header, which must use the algorithm of the token
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || keySet.Legacy == nil {
+			return nil, jwt.NewValidationError("token has no key ID", jwt.ValidationErrorSignatureInvalid)
 		}
-		return key, nil
-	})
+		return keySet.Legacy, nil
+	}
+	key, ok := keySet.Keys[kid]
+	if !ok {
+		return nil, jwt.NewValidationError("unknown key ID", jwt.ValidationErrorSignatureInvalid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
+	}
+	return key.Public, nil
+}
+
+func VerifyJWTToken(tokenString string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, verificationKey)
 	if err != nil {
 		return nil, err
 	}
 	if !token.Valid {
 		return nil, jwt.NewValidationError("invalid token", jwt.ValidationErrorExpired)
 	}
-	
+
 	// Check if the token has expired
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["exp"] != nil {
 		if exp, ok := claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(time.Now()) {
 			return nil, jwt.NewValidationError("token has expired", jwt.ValidationErrorExpired)
 		}
 	}
+
+	// tokens without an ID cannot be revoked and are no longer accepted
+	jti, _ := token.Claims.(jwt.MapClaims)["jti"].(string)
+	if jti == "" {
+		return nil, jwt.NewValidationError("token has no ID", jwt.ValidationErrorClaimsInvalid)
+	}
+	if revocationList != nil {
+		revoked, err := revocationList.IsRevoked(jti)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, jwt.NewValidationError("token has been revoked", jwt.ValidationErrorClaimsInvalid)
+		}
+	}
 	return token, nil
 }
 
@@ -111,21 +243,26 @@ func GetJWTTokenFromHeader(r *http.Request) (string, error) {
 	return token, nil
 }
 
-func GetUserIdFromJWTToken(r *http.Request) (int64, error) {
-	tokenString, err := GetJWTTokenFromHeader(r)
-	if err != nil {
-		return 0, err
-	}
+// AccessClaims holds the claims of a verified access token
+type AccessClaims struct {
+	UserId    int64
+	TokenId   string
+	ExpiresAt time.Time
+}
 
+// ParseAccessToken verifies an access token and returns its claims
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
 	token, err := VerifyJWTToken(tokenString)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["sub"] != nil {
-		if userId, ok := claims["sub"].(float64); ok {
-			return int64(userId), nil
-		}
+	claims := token.Claims.(jwt.MapClaims)
+	userId, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, jwt.NewValidationError("user ID not found in token", jwt.ValidationErrorClaimsInvalid)
 	}
-	return 0, jwt.NewValidationError("user ID not found in token", jwt.ValidationErrorClaimsInvalid)
-}
\ No newline at end of file
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	return &AccessClaims{UserId: int64(userId), TokenId: jti, ExpiresAt: time.Unix(int64(exp), 0)}, nil
+}
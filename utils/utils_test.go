@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type revokedIds map[string]bool
+
+func (r revokedIds) IsRevoked(jti string) (bool, error) {
+	return r[jti], nil
+}
+
+func newJWTKey(t *testing.T, id string, method jwt.SigningMethod) *JWTKey {
+	var private crypto.Signer
+	var err error
+	if method == jwt.SigningMethodEdDSA {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &JWTKey{Id: id, Method: method, Private: private, Public: private.Public()}
+}
+
+func TestVerifyJWTToken(t *testing.T) {
+	current := newJWTKey(t, "2026-10", jwt.SigningMethodEdDSA)
+	previous := newJWTKey(t, "2026-01", jwt.SigningMethodRS256)
+	retired := newJWTKey(t, "2025-01", jwt.SigningMethodRS256)
+	legacy := []byte("secret")
+	// the previous key only verifies tokens, as when its private key was
+	// removed after a rotation
+	SetKeySet(&KeySet{
+		Signing: current,
+		Keys:    map[string]*JWTKey{current.Id: current, previous.Id: {Id: previous.Id, Method: previous.Method, Public: previous.Public}},
+		Legacy:  legacy,
+	})
+	SetRevocationList(revokedIds{"revoked": true})
+	defer SetRevocationList(nil)
+
+	claims := func(jti string, exp time.Duration) jwt.MapClaims {
+		return jwt.MapClaims{"sub": 1, "jti": jti, "exp": time.Now().Add(exp).Unix()}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	issued, err := SignJWTToken(1, "jane@example.com", "issued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := claims("id", time.Hour)
+
+	tests := []struct {
+		name, token string
+		ok          bool
+	}{
+		{"issued by SignJWTToken", issued, true},
+		{"signing key", sign(jwt.SigningMethodEdDSA, current.Id, current.Private, valid), true},
+		{"previous key", sign(jwt.SigningMethodRS256, previous.Id, previous.Private, valid), true},
+		{"legacy HMAC token without kid", sign(jwt.SigningMethodHS256, "", legacy, valid), true},
+		{"legacy HMAC token with another secret", sign(jwt.SigningMethodHS256, "", []byte("guess"), valid), false},
+		{"unknown kid", sign(jwt.SigningMethodRS256, retired.Id, retired.Private, valid), false},
+		{"kid signed by another key", sign(jwt.SigningMethodRS256, previous.Id, retired.Private, valid), false},
+		// a public key must never be used as an HMAC secret
+		{"HMAC token naming an RSA key", sign(jwt.SigningMethodHS256, previous.Id, []byte("public key bytes"), valid), false},
+		{"RS256 token naming an Ed25519 key", sign(jwt.SigningMethodRS256, current.Id, previous.Private, valid), false},
+		{"RS256 token without kid", sign(jwt.SigningMethodRS256, "", previous.Private, valid), false},
+		{"unsigned token", sign(jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, valid), false},
+		{"expired", sign(jwt.SigningMethodEdDSA, current.Id, current.Private, claims("id", -time.Minute)), false},
+		{"without ID", sign(jwt.SigningMethodEdDSA, current.Id, current.Private, claims("", time.Hour)), false},
+		{"revoked", sign(jwt.SigningMethodEdDSA, current.Id, current.Private, claims("revoked", time.Hour)), false},
+		{"tampered", issued[:len(issued)-4] + "AAAA", false},
+		{"garbage", "not.a.token", false},
+	}
+	for _, tt := range tests {
+		_, err := VerifyJWTToken(tt.token)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok %t", tt.name, err, tt.ok)
+		}
+	}
+
+	// without JWT_KEY legacy tokens are refused
+	SetKeySet(&KeySet{Signing: current, Keys: map[string]*JWTKey{current.Id: current}})
+	if _, err := VerifyJWTToken(sign(jwt.SigningMethodHS256, "", legacy, valid)); err == nil {
+		t.Error("legacy token accepted without a legacy secret")
+	}
+}